@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,13 +13,28 @@ import (
 
 type (
 	Config struct {
-		HTTP HTTP `yaml:"http"`
-		Log  Log  `yaml:"logger"`
-		WS   WS   `yaml:"websocket"`
+		HTTP      HTTP      `yaml:"http"`
+		Log       Log       `yaml:"logger"`
+		WS        WS        `yaml:"websocket"`
+		Storage   Storage   `yaml:"storage"`
+		PG        PG        `yaml:"postgres"`
+		Broker    Broker    `yaml:"broker"`
+		Redis     Redis     `yaml:"redis"`
+		Auth      Auth      `yaml:"auth"`
+		Meeting   Meeting   `yaml:"meeting"`
+		Chat      Chat      `yaml:"chat"`
+		SFU       SFU       `yaml:"sfu"`
+		Recording Recording `yaml:"recording"`
+		ICE       ICE       `yaml:"ice"`
+		TURN      TURN      `yaml:"turn"`
+		Webhooks  Webhooks  `yaml:"webhooks"`
 	}
 
 	HTTP struct {
 		Port string `yaml:"port"`
+		// TrustedProxies - прокси, чьим X-Forwarded-For можно верить при определении
+		// адреса клиента. Пустой список - адрес клиента берется из соединения
+		TrustedProxies []string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES" env-separator:","`
 	}
 
 	Log struct {
@@ -31,9 +49,141 @@ type (
 		PingPeriod      time.Duration `yaml:"ping_period"`
 		MaxMessageSize  int64         `yaml:"max_message_size"`
 		UserJoinDelay   time.Duration `yaml:"user_join_delay"`
+		ResumeGrace     time.Duration `yaml:"resume_grace" env:"WS_RESUME_GRACE"`
+		ResumeQueueSize int           `yaml:"resume_queue_size"`
+		SendQueueSize   int           `yaml:"send_queue_size"`
+		SendQueuePolicy string        `yaml:"send_queue_policy" env:"WS_SEND_QUEUE_POLICY"`
 	}
+
+	Storage struct {
+		Type string `yaml:"type" env:"STORAGE_TYPE"`
+	}
+
+	PG struct {
+		URL     string `yaml:"url" env:"PG_URL"`
+		PoolMax int    `yaml:"pool_max" env:"PG_POOL_MAX"`
+	}
+
+	Broker struct {
+		Type string `yaml:"type" env:"BROKER_TYPE"`
+	}
+
+	Redis struct {
+		Addr     string `yaml:"addr" env:"REDIS_ADDR"`
+		Password string `yaml:"password" env:"REDIS_PASSWORD"`
+		DB       int    `yaml:"db" env:"REDIS_DB"`
+		Channel  string `yaml:"channel"`
+	}
+
+	Auth struct {
+		Secret   string        `yaml:"secret" env:"AUTH_SECRET" env-required:"true"`
+		TokenTTL time.Duration `yaml:"token_ttl" env:"AUTH_TOKEN_TTL"`
+	}
+
+	Meeting struct {
+		IdleTTL         time.Duration `yaml:"idle_ttl" env:"MEETING_IDLE_TTL"`
+		JanitorInterval time.Duration `yaml:"janitor_interval" env:"MEETING_JANITOR_INTERVAL"`
+		DefaultMode     string        `yaml:"default_mode" env:"MEETING_DEFAULT_MODE"`
+		// GhostTTL - сколько участник без подключения остается во встрече, прежде чем
+		// janitor его удалит. Вернуться под прежним ID он может и после этого
+		GhostTTL time.Duration `yaml:"ghost_ttl" env:"MEETING_GHOST_TTL"`
+		// AttendanceTTL - сколько хранится журнал посещаемости после завершения встречи,
+		// 0 - бессрочно
+		AttendanceTTL time.Duration `yaml:"attendance_ttl" env:"MEETING_ATTENDANCE_TTL"`
+		// PasscodeMaxAttempts неверных кодов доступа с одного адреса блокируют
+		// вход во встречу с него на PasscodeLockout, а после PasscodeMeetingMaxAttempts
+		// неверных кодов со всех адресов коды встречи до конца PasscodeLockout сверяются по одному
+		PasscodeMaxAttempts        int           `yaml:"passcode_max_attempts" env:"MEETING_PASSCODE_MAX_ATTEMPTS"`
+		PasscodeMeetingMaxAttempts int           `yaml:"passcode_meeting_max_attempts" env:"MEETING_PASSCODE_MEETING_MAX_ATTEMPTS"`
+		PasscodeLockout            time.Duration `yaml:"passcode_lockout" env:"MEETING_PASSCODE_LOCKOUT"`
+	}
+
+	Chat struct {
+		HistorySize int `yaml:"history_size" env:"CHAT_HISTORY_SIZE"`
+		MaxMessages int `yaml:"max_messages" env:"CHAT_MAX_MESSAGES"`
+		MaxLength   int `yaml:"max_length"`
+	}
+
+	SFU struct {
+		Enabled bool `yaml:"enabled" env:"SFU_ENABLED"`
+		// PublicIP - адрес, который сервер объявляет в ICE кандидатах, если он за NAT
+		PublicIP    string   `yaml:"public_ip" env:"SFU_PUBLIC_IP"`
+		UDPPortMin  uint16   `yaml:"udp_port_min" env:"SFU_UDP_PORT_MIN"`
+		UDPPortMax  uint16   `yaml:"udp_port_max" env:"SFU_UDP_PORT_MAX"`
+		STUNServers []string `yaml:"stun_servers" env:"SFU_STUN_SERVERS" env-separator:","`
+	}
+
+	// Recording - серверная запись встреч. Рекордер использует сетевые параметры sfu
+	Recording struct {
+		Enabled bool   `yaml:"enabled" env:"RECORDING_ENABLED"`
+		Dir     string `yaml:"dir" env:"RECORDING_DIR"`
+	}
+
+	// ICE - серверы, которые клиенты получают в GET /api/ice-servers
+	ICE struct {
+		STUNServers []string `yaml:"stun_servers" env:"ICE_STUN_SERVERS" env-separator:","`
+	}
+
+	// TURN - встроенный TURN/STUN сервер. Host - адрес сервера в URL для клиентов,
+	// по умолчанию PublicIP. Relay к частным, loopback и link-local адресам запрещен,
+	// кроме сетей из AllowedPeers
+	TURN struct {
+		Enabled       bool          `yaml:"enabled" env:"TURN_ENABLED"`
+		PublicIP      string        `yaml:"public_ip" env:"TURN_PUBLIC_IP"`
+		Host          string        `yaml:"host" env:"TURN_HOST"`
+		Port          int           `yaml:"port" env:"TURN_PORT"`
+		Realm         string        `yaml:"realm"`
+		RelayPortMin  uint16        `yaml:"relay_port_min" env:"TURN_RELAY_PORT_MIN"`
+		RelayPortMax  uint16        `yaml:"relay_port_max" env:"TURN_RELAY_PORT_MAX"`
+		Secret        string        `yaml:"secret" env:"TURN_SECRET"`
+		CredentialTTL time.Duration `yaml:"credential_ttl" env:"TURN_CREDENTIAL_TTL"`
+		AllowedPeers  []string      `yaml:"allowed_peers" env:"TURN_ALLOWED_PEERS" env-separator:","`
+	}
+
+	// Webhooks - уведомления внешних систем о встречах и участниках. URLs получают
+	// все события с подписью Secret, Subscriptions задают события и секрет отдельно.
+	// AdminToken открывает журнал доставок, пустой - журнал недоступен
+	Webhooks struct {
+		Enabled        bool                  `yaml:"enabled" env:"WEBHOOKS_ENABLED"`
+		Secret         string                `yaml:"secret" env:"WEBHOOKS_SECRET"`
+		URLs           []string              `yaml:"urls" env:"WEBHOOKS_URLS" env-separator:","`
+		Subscriptions  []WebhookSubscription `yaml:"subscriptions"`
+		Timeout        time.Duration         `yaml:"timeout"`
+		MaxAttempts    int                   `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS"`
+		InitialBackoff time.Duration         `yaml:"initial_backoff" env:"WEBHOOKS_INITIAL_BACKOFF"`
+		MaxBackoff     time.Duration         `yaml:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF"`
+		Workers        int                   `yaml:"workers"`
+		QueueSize      int                   `yaml:"queue_size"`
+		LogSize        int                   `yaml:"log_size"`
+		DeadLetterSize int                   `yaml:"dead_letter_size"`
+		AdminToken     string                `yaml:"admin_token" env:"WEBHOOKS_ADMIN_TOKEN"`
+	}
+
+	// WebhookSubscription - пустой Secret - общий секрет вебхуков, пустой Events - все события
+	WebhookSubscription struct {
+		URL    string   `yaml:"url"`
+		Secret string   `yaml:"secret"`
+		Events []string `yaml:"events"`
+	}
+)
+
+const (
+	StorageMemory   = "memory"
+	StoragePostgres = "postgres"
+
+	BrokerMemory = "memory"
+	BrokerRedis  = "redis"
+
+	QueueDropOldest = "drop_oldest"
+	QueueDisconnect = "disconnect"
+
+	ModeMesh = "mesh"
+	ModeSFU  = "sfu"
 )
 
+// WebhookEvents - события, на которые можно подписаться
+var WebhookEvents = []string{"meeting.created", "meeting.ended", "participant.joined", "participant.left"}
+
 func NewConfig() (*Config, error) {
 	cfg := &Config{}
 
@@ -51,6 +201,54 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if err := validateWS(cfg.WS); err != nil {
+		return nil, err
+	}
+
+	if cfg.Auth.TokenTTL <= 0 {
+		return nil, fmt.Errorf("auth token_ttl must be positive")
+	}
+
+	if cfg.Meeting.IdleTTL <= 0 || cfg.Meeting.JanitorInterval <= 0 || cfg.Meeting.GhostTTL <= 0 {
+		return nil, fmt.Errorf("meeting idle_ttl, janitor_interval and ghost_ttl must be positive")
+	}
+
+	if cfg.Meeting.AttendanceTTL < 0 {
+		return nil, fmt.Errorf("meeting attendance_ttl must not be negative")
+	}
+
+	if cfg.Meeting.PasscodeMaxAttempts <= 0 || cfg.Meeting.PasscodeMeetingMaxAttempts <= 0 || cfg.Meeting.PasscodeLockout <= 0 {
+		return nil, fmt.Errorf("meeting passcode_max_attempts, passcode_meeting_max_attempts and passcode_lockout must be positive")
+	}
+
+	if err := validateSFU(cfg.Meeting.DefaultMode, cfg.SFU); err != nil {
+		return nil, err
+	}
+
+	if cfg.Recording.Enabled && cfg.Recording.Dir == "" {
+		return nil, fmt.Errorf("recording dir is required when recording is enabled")
+	}
+
+	if err := validateTURN(&cfg.TURN); err != nil {
+		return nil, err
+	}
+
+	if err := validateWebhooks(&cfg.Webhooks); err != nil {
+		return nil, err
+	}
+
+	if cfg.Chat.HistorySize <= 0 || cfg.Chat.MaxMessages <= 0 || cfg.Chat.MaxLength <= 0 {
+		return nil, fmt.Errorf("chat history_size, max_messages and max_length must be positive")
+	}
+
+	if err := validateStorage(&cfg.Storage, cfg.PG); err != nil {
+		return nil, err
+	}
+
+	if err := validateBroker(&cfg.Broker, cfg.Redis); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
@@ -61,3 +259,152 @@ func validateDestination(destination string) error {
 	}
 	return nil
 }
+
+func validateWS(ws WS) error {
+	if ws.PongWait <= 0 || ws.PingPeriod <= 0 {
+		return fmt.Errorf("websocket pong_wait and ping_period must be positive")
+	}
+	if ws.PingPeriod >= ws.PongWait {
+		return fmt.Errorf("websocket ping_period (%s) must be less than pong_wait (%s)", ws.PingPeriod, ws.PongWait)
+	}
+	if ws.MaxMessageSize <= 0 {
+		return fmt.Errorf("websocket max_message_size must be positive")
+	}
+	if ws.ResumeGrace < 0 || ws.ResumeQueueSize <= 0 {
+		return fmt.Errorf("websocket resume_grace must not be negative and resume_queue_size must be positive")
+	}
+	if ws.SendQueueSize <= 0 {
+		return fmt.Errorf("websocket send_queue_size must be positive")
+	}
+	if ws.SendQueuePolicy != QueueDropOldest && ws.SendQueuePolicy != QueueDisconnect {
+		return fmt.Errorf("invalid websocket send_queue_policy: %s. Use '%s' or '%s'", ws.SendQueuePolicy, QueueDropOldest, QueueDisconnect)
+	}
+	return nil
+}
+
+func validateSFU(defaultMode string, sfu SFU) error {
+	switch defaultMode {
+	case ModeMesh:
+	case ModeSFU:
+		if !sfu.Enabled {
+			return fmt.Errorf("meeting default_mode %s requires sfu to be enabled", ModeSFU)
+		}
+	default:
+		return fmt.Errorf("invalid meeting default_mode: %s. Use '%s' or '%s'", defaultMode, ModeMesh, ModeSFU)
+	}
+
+	if (sfu.UDPPortMin == 0) != (sfu.UDPPortMax == 0) || sfu.UDPPortMin > sfu.UDPPortMax {
+		return fmt.Errorf("sfu udp_port_min and udp_port_max must be set together and form a valid range")
+	}
+
+	return nil
+}
+
+func validateTURN(turn *TURN) error {
+	if !turn.Enabled {
+		return nil
+	}
+
+	if turn.Secret == "" {
+		return fmt.Errorf("turn secret is required when turn is enabled")
+	}
+	if net.ParseIP(turn.PublicIP) == nil {
+		return fmt.Errorf("turn public_ip must be a valid ip address when turn is enabled")
+	}
+	if turn.Port <= 0 || turn.Port > 65535 {
+		return fmt.Errorf("invalid turn port: %d", turn.Port)
+	}
+	if (turn.RelayPortMin == 0) != (turn.RelayPortMax == 0) || turn.RelayPortMin > turn.RelayPortMax {
+		return fmt.Errorf("turn relay_port_min and relay_port_max must be set together and form a valid range")
+	}
+	if turn.CredentialTTL <= 0 {
+		return fmt.Errorf("turn credential_ttl must be positive")
+	}
+	for _, cidr := range turn.AllowedPeers {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid turn allowed_peers entry %q: %w", cidr, err)
+		}
+	}
+
+	if turn.Host == "" {
+		turn.Host = turn.PublicIP
+	}
+
+	return nil
+}
+
+// validateWebhooks - переносит URLs в Subscriptions и подставляет общий секрет
+func validateWebhooks(webhooks *Webhooks) error {
+	if !webhooks.Enabled {
+		webhooks.Subscriptions = nil
+		return nil
+	}
+
+	if webhooks.Timeout <= 0 || webhooks.MaxAttempts <= 0 || webhooks.InitialBackoff <= 0 || webhooks.MaxBackoff < webhooks.InitialBackoff {
+		return fmt.Errorf("webhooks timeout, max_attempts and initial_backoff must be positive and max_backoff must not be less than initial_backoff")
+	}
+	if webhooks.Workers <= 0 || webhooks.QueueSize <= 0 || webhooks.LogSize <= 0 || webhooks.DeadLetterSize <= 0 {
+		return fmt.Errorf("webhooks workers, queue_size, log_size and dead_letter_size must be positive")
+	}
+
+	for _, rawURL := range webhooks.URLs {
+		webhooks.Subscriptions = append(webhooks.Subscriptions, WebhookSubscription{URL: rawURL})
+	}
+	webhooks.URLs = nil
+
+	for i := range webhooks.Subscriptions {
+		subscription := &webhooks.Subscriptions[i]
+
+		parsed, err := url.Parse(subscription.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook url: %q. Use an absolute http or https url", subscription.URL)
+		}
+
+		if subscription.Secret == "" {
+			subscription.Secret = webhooks.Secret
+		}
+		if subscription.Secret == "" {
+			return fmt.Errorf("webhook %s has no secret, set it or webhooks secret", subscription.URL)
+		}
+
+		for _, event := range subscription.Events {
+			if !slices.Contains(WebhookEvents, event) {
+				return fmt.Errorf("invalid webhook event %q for %s. Use one of %s", event, subscription.URL, strings.Join(WebhookEvents, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateStorage(storage *Storage, pg PG) error {
+	storage.Type = strings.ToLower(storage.Type)
+
+	switch storage.Type {
+	case StorageMemory:
+		return nil
+	case StoragePostgres:
+		if pg.URL == "" {
+			return fmt.Errorf("postgres url is required for storage type %s", storage.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid storage type: %s. Use '%s' or '%s'", storage.Type, StorageMemory, StoragePostgres)
+	}
+}
+
+func validateBroker(broker *Broker, redis Redis) error {
+	broker.Type = strings.ToLower(broker.Type)
+
+	switch broker.Type {
+	case BrokerMemory:
+		return nil
+	case BrokerRedis:
+		if redis.Addr == "" || redis.Channel == "" {
+			return fmt.Errorf("redis addr and channel are required for broker type %s", broker.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid broker type: %s. Use '%s' or '%s'", broker.Type, BrokerMemory, BrokerRedis)
+	}
+}
@@ -1,17 +1,30 @@
 package app
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/AlexandrKudryavtsev/zvonim/config"
 	v1 "github.com/AlexandrKudryavtsev/zvonim/internal/controller/http/v1"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
 	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/auth"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/broker"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/metrics"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/ratelimit"
 	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/sfu"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/turn"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/webhook"
+	"github.com/AlexandrKudryavtsev/zvonim/migrations"
 	"github.com/AlexandrKudryavtsev/zvonim/pkg/httpserver"
 	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/postgres"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/redis"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func Run(cfg *config.Config) {
@@ -23,17 +36,202 @@ func Run(cfg *config.Config) {
 
 	handler := gin.New()
 	handler.Use(gin.Recovery())
+	if err := handler.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		log.Fatal("can't set trusted proxies: %s", err)
+	}
+
+	var (
+		meetingRepo usecase.MeetingRepo
+		webhookRepo usecase.WebhookRepo
+	)
+
+	switch cfg.Storage.Type {
+	case config.StoragePostgres:
+		pg, err := postgres.New(cfg.PG.URL, postgres.MaxPoolSize(cfg.PG.PoolMax))
+		if err != nil {
+			log.Fatal("can't init postgres: %s", err)
+		}
+		defer pg.Close()
+
+		if err := pg.Migrate(migrations.FS); err != nil {
+			log.Fatal("can't migrate postgres: %s", err)
+		}
+		log.Info("Postgres initialized")
+
+		meetingRepo = repo.NewPostgresMeetingRepository(pg)
+		webhookRepo = repo.NewPostgresWebhookRepository(pg, cfg.Webhooks.LogSize, cfg.Webhooks.DeadLetterSize)
+	default:
+		meetingRepo = repo.NewMemoryMeetingRepository()
+		webhookRepo = repo.NewMemoryWebhookRepository(cfg.Webhooks.LogSize, cfg.Webhooks.DeadLetterSize)
+	}
+	log.Info("Repositories initialized", "storage", cfg.Storage.Type)
+
+	var signalingBroker usecase.SignalingBroker
+
+	switch cfg.Broker.Type {
+	case config.BrokerRedis:
+		rdb, err := redis.New(cfg.Redis.Addr, redis.Password(cfg.Redis.Password), redis.DB(cfg.Redis.DB))
+		if err != nil {
+			log.Fatal("can't init redis: %s", err)
+		}
+		defer rdb.Close()
+		log.Info("Redis initialized")
+
+		signalingBroker = broker.NewRedisBroker(rdb, cfg.Redis.Channel)
+	default:
+		signalingBroker = broker.NewMemoryBroker()
+	}
+	log.Info("Signaling broker initialized", "broker", cfg.Broker.Type)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serverMetrics := metrics.NewPrometheus()
+	// Маршрут регистрируется до middleware роутера, чтобы сбор метрик не попадал в гистограмму
+	handler.GET("/metrics", gin.WrapH(serverMetrics.Handler()))
+
+	chatRepo := repo.NewMemoryChatRepository(cfg.Chat.MaxMessages)
+
+	// Медиасервер нужен и для режима sfu, и для записи встреч
+	var mediaServer usecase.SFU
+	var mediaGateway usecase.MediaGateway
+	var recorder usecase.Recorder
+	if cfg.SFU.Enabled || cfg.Recording.Enabled {
+		server, err := sfu.New(signalingBroker, sfu.Config{
+			PublicIP:    cfg.SFU.PublicIP,
+			UDPPortMin:  cfg.SFU.UDPPortMin,
+			UDPPortMax:  cfg.SFU.UDPPortMax,
+			STUNServers: cfg.SFU.STUNServers,
+		}, log)
+		if err != nil {
+			log.Fatal("can't init sfu: %s", err)
+		}
+		defer server.Close()
+
+		if cfg.SFU.Enabled {
+			mediaServer = server
+			mediaGateway = server
+		}
+		if cfg.Recording.Enabled {
+			recorder = server
+		}
+		log.Info("SFU initialized", "sfu", cfg.SFU.Enabled, "recording", cfg.Recording.Enabled)
+	}
 
-	meetingRepo := repo.NewMemoryMeetingRepository()
-	log.Info("Meeting repository initialized")
+	var turnCredentials usecase.TURNCredentials
+	if cfg.TURN.Enabled {
+		credentials := turn.NewCredentials(cfg.TURN.Secret, cfg.TURN.CredentialTTL)
 
-	meetingUC := usecase.NewMeetingService(meetingRepo)
+		turnServer, err := turn.New(turn.Config{
+			PublicIP:     cfg.TURN.PublicIP,
+			Port:         cfg.TURN.Port,
+			Realm:        cfg.TURN.Realm,
+			RelayPortMin: cfg.TURN.RelayPortMin,
+			RelayPortMax: cfg.TURN.RelayPortMax,
+			AllowedPeers: cfg.TURN.AllowedPeers,
+		}, credentials, log)
+		if err != nil {
+			log.Fatal("can't start turn server: %s", err)
+		}
+		defer turnServer.Close()
+
+		turnCredentials = credentials
+		log.Info("TURN server started", "port", cfg.TURN.Port, "public_ip", cfg.TURN.PublicIP)
+	}
+
+	iceUC := usecase.NewICEService(turnCredentials, usecase.ICEConfig{
+		STUNServers: cfg.ICE.STUNServers,
+		TURNHost:    cfg.TURN.Host,
+		TURNPort:    cfg.TURN.Port,
+	})
+
+	// Шина доменных событий: usecase публикуют изменения встреч, WebSocket сервис
+	// рассылает их участникам, вебхуки - внешним системам
+	events := usecase.NewEventBus()
+
+	recordingUC := usecase.NewRecordingService(
+		meetingRepo,
+		repo.NewFileRecordingRepository(cfg.Recording.Dir),
+		recorder,
+		events,
+		usecase.SystemClock(),
+		cfg.Recording.Dir,
+	)
+	log.Info("Recording service initialized", "enabled", cfg.Recording.Enabled, "dir", cfg.Recording.Dir)
+
+	subscriptions := make([]entity.WebhookSubscription, 0, len(cfg.Webhooks.Subscriptions))
+	for _, subscription := range cfg.Webhooks.Subscriptions {
+		subscriptions = append(subscriptions, entity.WebhookSubscription{
+			URL:    subscription.URL,
+			Secret: subscription.Secret,
+			Events: subscription.Events,
+		})
+	}
+
+	webhookUC := usecase.NewWebhookService(
+		webhookRepo,
+		webhook.NewHTTPSender(cfg.Webhooks.Timeout),
+		events,
+		usecase.SystemClock(),
+		usecase.WebhookConfig{
+			Subscriptions:  subscriptions,
+			Workers:        cfg.Webhooks.Workers,
+			QueueSize:      cfg.Webhooks.QueueSize,
+			MaxAttempts:    cfg.Webhooks.MaxAttempts,
+			InitialBackoff: cfg.Webhooks.InitialBackoff,
+			MaxBackoff:     cfg.Webhooks.MaxBackoff,
+		},
+	)
+	webhookUC.Start(ctx)
+	log.Info("Webhook service started", "enabled", cfg.Webhooks.Enabled, "subscriptions", len(subscriptions))
+
+	meetingUC := usecase.NewMeetingService(
+		meetingRepo,
+		chatRepo,
+		recordingUC,
+		auth.NewJWTManager(cfg.Auth.Secret, cfg.Auth.TokenTTL),
+		auth.NewBcryptHasher(bcrypt.DefaultCost),
+		ratelimit.NewMemoryAttemptLimiter(usecase.SystemClock(), cfg.Meeting.PasscodeMaxAttempts, cfg.Meeting.PasscodeLockout),
+		ratelimit.NewMemoryAttemptLimiter(usecase.SystemClock(), cfg.Meeting.PasscodeMeetingMaxAttempts, cfg.Meeting.PasscodeLockout),
+		signalingBroker,
+		events,
+		usecase.SystemClock(),
+		serverMetrics,
+		usecase.MeetingConfig{
+			IdleTTL:       cfg.Meeting.IdleTTL,
+			GhostTTL:      cfg.Meeting.GhostTTL,
+			AttendanceTTL: cfg.Meeting.AttendanceTTL,
+			DefaultMode:   cfg.Meeting.DefaultMode,
+			SFUEnabled:    cfg.SFU.Enabled,
+		},
+	)
 	log.Info("Meeting service initialized")
 
-	wsUC := usecase.NewWebSocketService(meetingRepo, cfg.WS.UserJoinDelay)
+	go usecase.NewMeetingJanitor(meetingUC, usecase.SystemClock(), cfg.Meeting.JanitorInterval, log).Run(ctx)
+	log.Info("Meeting janitor started", "interval", cfg.Meeting.JanitorInterval)
+
+	chatUC := usecase.NewChatService(meetingRepo, chatRepo, events, usecase.SystemClock(), cfg.Chat.HistorySize, cfg.Chat.MaxLength)
+	log.Info("Chat service initialized")
+
+	wsUC := usecase.NewWebSocketService(meetingRepo, meetingUC, chatUC, recordingUC, signalingBroker, mediaServer, events, usecase.SystemClock(), serverMetrics, usecase.SessionConfig{
+		UserJoinDelay:   cfg.WS.UserJoinDelay,
+		ResumeGrace:     cfg.WS.ResumeGrace,
+		ResumeQueueSize: cfg.WS.ResumeQueueSize,
+		SendQueueSize:   cfg.WS.SendQueueSize,
+		OverflowPolicy:  usecase.OverflowPolicy(cfg.WS.SendQueuePolicy),
+	})
+	if err := wsUC.Start(ctx); err != nil {
+		log.Fatal("can't start websocket service: %s", err)
+	}
 	log.Info("WebSocket service initialized")
 
-	v1.NewRouter(handler, log, meetingUC, wsUC)
+	mediaUC := usecase.NewMediaSessionService(meetingRepo, meetingUC, mediaGateway, signalingBroker, events, usecase.SystemClock(), serverMetrics)
+	if err := mediaUC.Start(ctx); err != nil {
+		log.Fatal("can't start media session service: %s", err)
+	}
+	log.Info("Media session service initialized", "enabled", cfg.SFU.Enabled)
+
+	v1.NewRouter(handler, log, cfg.WS, cfg.Webhooks, usecase.SystemClock(), serverMetrics, meetingUC, chatUC, recordingUC, iceUC, mediaUC, wsUC, webhookUC)
 	log.Info("HTTP routes registered")
 
 	httpServer := httpserver.New(handler, httpserver.Port(cfg.HTTP.Port))
@@ -53,6 +251,13 @@ func Run(cfg *config.Config) {
 
 	log.Info("shutting down...")
 
+	// Записи сохраняются до закрытия соединений, чтобы участники получили recording_stopped
+	recordingUC.Shutdown(context.Background())
+	mediaUC.Shutdown(context.Background())
+	wsUC.Shutdown()
+	// После закрытия соединений, чтобы ушли события participant.left
+	webhookUC.Shutdown()
+
 	if err := httpServer.Shutdown(); err != nil {
 		log.Error("http server shutdown error", "error", err)
 	}
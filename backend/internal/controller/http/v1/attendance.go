@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// Форматы отчета о посещаемости
+const (
+	attendanceFormatJSON = "json"
+	attendanceFormatCSV  = "csv"
+)
+
+var _attendanceCSVHeader = []string{
+	"user_id", "user_name", "first_joined_at", "last_left_at", "total_seconds", "reconnects", "online",
+}
+
+// GetAttendance возвращает отчет о посещаемости встречи
+// @Summary     Get attendance report
+// @Description Per-user totals, first join, last leave and number of reconnects. Available after the meeting has ended
+// @Tags        meetings
+// @Produce     json
+// @Produce     text/csv
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       format query string false "Report format" Enums(json, csv) default(json)
+// @Param       Authorization header string true "Bearer token участника"
+// @Success     200 {object} entity.AttendanceReport
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/attendance [get]
+func (h *MeetingHandler) GetAttendance(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	format := c.DefaultQuery("format", attendanceFormatJSON)
+	if format != attendanceFormatJSON && format != attendanceFormatCSV {
+		_ = c.Error(&entity.ValidationError{Field: "format", Reason: "must be json or csv"})
+		return
+	}
+
+	// Как и записи, отчет доступен любому участнику с токеном встречи, в том числе после ее завершения
+	if _, ok := authenticate(c, h.meetingUC, meetingID, ""); !ok {
+		return
+	}
+
+	report, err := h.meetingUC.GetAttendance(c.Request.Context(), meetingID)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to get attendance: %w", err))
+		return
+	}
+
+	if format == attendanceFormatJSON {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="attendance-%s.csv"`, meetingID))
+	c.Status(http.StatusOK)
+
+	if err := writeAttendanceCSV(c.Writer, report); err != nil {
+		h.logger.Error("failed to write attendance csv", "meeting_id", meetingID, "error", err)
+	}
+}
+
+func writeAttendanceCSV(w http.ResponseWriter, report *entity.AttendanceReport) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(_attendanceCSVHeader); err != nil {
+		return err
+	}
+
+	for _, record := range report.Attendees {
+		lastLeftAt := ""
+		if record.LastLeftAt != nil {
+			lastLeftAt = record.LastLeftAt.UTC().Format(time.RFC3339)
+		}
+
+		err := writer.Write([]string{
+			csvText(record.UserID),
+			csvText(record.UserName),
+			record.FirstJoinedAt.UTC().Format(time.RFC3339),
+			lastLeftAt,
+			strconv.FormatInt(record.TotalSeconds, 10),
+			strconv.Itoa(record.Reconnects),
+			strconv.FormatBool(record.Online),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText - значение, которое ввел пользователь. Ячейку, начинающуюся с символа формулы,
+// табличный редактор исполнит, поэтому перед ней ставится апостроф
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,43 @@
+package v1
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+func TestWriteAttendanceCSVEscapesFormulas(t *testing.T) {
+	names := []string{"=HYPERLINK(\"http://evil\")", "+1", "-2", "@SUM(A1)", "\tname", "\rname", "Алиса", "a=b", ""}
+
+	report := &entity.AttendanceReport{}
+	for _, name := range names {
+		report.Attendees = append(report.Attendees, entity.AttendanceRecord{
+			UserID:        "=" + name,
+			UserName:      name,
+			FirstJoinedAt: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	if err := writeAttendanceCSV(recorder, report); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	rows, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+
+	want := []string{"'=HYPERLINK(\"http://evil\")", "'+1", "'-2", "'@SUM(A1)", "'\tname", "'\rname", "Алиса", "a=b", ""}
+	for i, row := range rows[1:] {
+		if row[0] != "'="+names[i] {
+			t.Fatalf("row %d: got user_id %q, want %q", i, row[0], "'="+names[i])
+		}
+		if row[1] != want[i] {
+			t.Fatalf("row %d: got user_name %q, want %q", i, row[1], want[i])
+		}
+	}
+}
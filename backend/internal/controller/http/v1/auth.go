@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"strings"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// tokenFromRequest - достает токен участника из заголовка Authorization: Bearer
+// или из query параметра token (для WebSocket и sendBeacon, где заголовки недоступны)
+func tokenFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if token, found := strings.CutPrefix(header, "Bearer "); found {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	return c.Query("token")
+}
+
+// authenticate - проверяет токен запроса и пишет ошибку в ответ, если он не подходит
+func authenticate(c *gin.Context, meetingUC usecase.MeetingUseCase, meetingID, userID string) (*entity.TokenClaims, bool) {
+	claims, err := meetingUC.Authenticate(c.Request.Context(), tokenFromRequest(c), meetingID, userID)
+	if err != nil {
+		abortWithError(c, err)
+		return nil, false
+	}
+
+	return claims, true
+}
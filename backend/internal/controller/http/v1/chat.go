@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type ChatHandler struct {
+	chatUC    usecase.ChatUseCase
+	meetingUC usecase.MeetingUseCase
+	logger    logger.Interface
+}
+
+func newChatHandler(chatUC usecase.ChatUseCase, meetingUC usecase.MeetingUseCase, logger logger.Interface) *ChatHandler {
+	return &ChatHandler{
+		chatUC:    chatUC,
+		meetingUC: meetingUC,
+		logger:    logger,
+	}
+}
+
+// GetHistory возвращает историю чата встречи
+// @Summary     Get chat history
+// @Description Get a page of chat messages visible to the participant, oldest first
+// @Tags        chat
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token участника"
+// @Param       limit query int false "Размер страницы, по умолчанию размер истории, не больше 100"
+// @Param       before query string false "ID сообщения, раньше которого нужна страница (next_before из прошлого ответа)"
+// @Success     200 {object} entity.ChatHistory
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/chat [get]
+func (h *ChatHandler) GetHistory(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			_ = c.Error(&entity.ValidationError{Field: "limit", Reason: "must be a positive integer"})
+			return
+		}
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.CheckMembership(c.Request.Context(), meetingID, claims.UserID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	history, err := h.chatUC.GetHistory(c.Request.Context(), meetingID, claims.UserID, c.Query("before"), limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -1,18 +1,117 @@
 package v1
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
+const contentTypeProblem = "application/problem+json"
+
 type response struct {
 	Message string `json:"message,omitempty"`
-	Error   string `json:"error,omitempty"`
 }
 
+// problem - ответ с ошибкой в формате RFC 7807
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code - машиночитаемый вид ошибки, если клиенту нужно различать ошибки с одним статусом
+	Code string `json:"code,omitempty"`
+	// InvalidParams - поля запроса, которые не прошли проверку
+	InvalidParams []entity.ValidationError `json:"invalid_params,omitempty"`
+}
+
+// _kindStatus - код ответа для каждого вида доменной ошибки
+var _kindStatus = map[entity.ErrorKind]int{
+	entity.KindValidation:      http.StatusBadRequest,
+	entity.KindUnauthorized:    http.StatusUnauthorized,
+	entity.KindForbidden:       http.StatusForbidden,
+	entity.KindNotFound:        http.StatusNotFound,
+	entity.KindConflict:        http.StatusConflict,
+	entity.KindGone:            http.StatusGone,
+	entity.KindCapacity:        http.StatusConflict,
+	entity.KindTooManyAttempts: http.StatusTooManyRequests,
+	entity.KindUnavailable:     http.StatusServiceUnavailable,
+}
+
+// errorHandler - отвечает на ошибку, которую обработчик передал через c.Error.
+// Доменная ошибка становится problem+json с кодом по ее виду, остальные - 500
+// без подробностей, они только пишутся в лог
+func errorHandler(logger logger.Interface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		var domainErr entity.DomainError
+		if !errors.As(err, &domainErr) {
+			logger.Error("request failed", "method", c.Request.Method, "path", c.Request.URL.Path, "error", err)
+			writeProblem(c, newProblem(c, http.StatusInternalServerError, "internal server error"))
+			return
+		}
+
+		status, ok := _kindStatus[domainErr.Kind()]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+
+		body := newProblem(c, status, domainErr.Error())
+		body.Code = domainErr.Code()
+
+		var validationErr *entity.ValidationError
+		if errors.As(err, &validationErr) {
+			body.InvalidParams = []entity.ValidationError{*validationErr}
+		}
+
+		var attemptsErr *entity.AttemptsExceededError
+		if errors.As(err, &attemptsErr) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(attemptsErr.RetryAfter.Seconds()))))
+		}
+
+		writeProblem(c, body)
+	}
+}
+
+// abortWithError - передает ошибку errorHandler и останавливает цепочку обработчиков
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// errorResponse - ошибка запроса, которая не связана с предметной областью:
+// неразбираемое тело, неверный Content-Type
 func errorResponse(c *gin.Context, code int, msg string) {
-	c.AbortWithStatusJSON(code, response{Error: msg})
+	writeProblem(c, newProblem(c, code, msg))
 }
 
 func successResponse(c *gin.Context, code int, msg string) {
 	c.JSON(code, response{Message: msg})
 }
+
+func newProblem(c *gin.Context, status int, detail string) *problem {
+	return &problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+	}
+}
+
+func writeProblem(c *gin.Context, body *problem) {
+	c.Header("Content-Type", contentTypeProblem)
+	c.AbortWithStatusJSON(body.Status, body)
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type ICEHandler struct {
+	iceUC     usecase.ICEUseCase
+	meetingUC usecase.MeetingUseCase
+	logger    logger.Interface
+}
+
+func newICEHandler(iceUC usecase.ICEUseCase, meetingUC usecase.MeetingUseCase, logger logger.Interface) *ICEHandler {
+	return &ICEHandler{
+		iceUC:     iceUC,
+		meetingUC: meetingUC,
+		logger:    logger,
+	}
+}
+
+// GetICEServers возвращает STUN/TURN серверы для участника встречи
+// @Summary     Get ICE servers
+// @Description Get STUN/TURN servers for RTCPeerConnection with short-lived TURN credentials bound to the participant
+// @Tags        ice
+// @Produce     json
+// @Param       meeting_id query string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token участника"
+// @Success     200 {object} entity.ICEServers
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /ice-servers [get]
+func (h *ICEHandler) GetICEServers(c *gin.Context) {
+	if c.Query("meeting_id") == "" {
+		_ = c.Error(&entity.ValidationError{Field: "meeting_id", Reason: "is required"})
+		return
+	}
+
+	meetingID, ok := resolveMeetingID(c, h.meetingUC, c.Query("meeting_id"))
+	if !ok {
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.CheckMembership(c.Request.Context(), meetingID, claims.UserID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	servers, err := h.iceUC.GetICEServers(c.Request.Context(), meetingID, claims.UserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Учетные данные персональные, кэшировать ответ нельзя
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, servers)
+}
@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// GetLobby возвращает пользователей, которые ждут в лобби
+// @Summary     Get lobby
+// @Description List users waiting in the lobby in arrival order, host only
+// @Tags        lobby
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Success     200 {array} entity.User
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/lobby [get]
+func (h *MeetingHandler) GetLobby(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	users, err := h.meetingUC.GetLobby(c.Request.Context(), meetingID, claims.UserID)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to get lobby: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// SetLobby включает или выключает лобби
+// @Summary     Toggle lobby
+// @Description Enable or disable the waiting room, host only. Disabling it admits everyone who is waiting, those who do not fit into the meeting capacity are denied
+// @Tags        lobby
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.LobbyRequest true "Lobby state"
+// @Success     200 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/lobby [post]
+func (h *MeetingHandler) SetLobby(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.LobbyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.SetLobbyEnabled(c.Request.Context(), meetingID, claims.UserID, req.Enabled); err != nil {
+		_ = c.Error(fmt.Errorf("failed to set lobby: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
+
+// AdmitUser впускает пользователя из лобби
+// @Summary     Admit user
+// @Description Let a user waiting in the lobby into the meeting, host only
+// @Tags        lobby
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.ModerationRequest true "Waiting user"
+// @Success     200 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/lobby/admit [post]
+func (h *MeetingHandler) AdmitUser(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "user_id", Reason: "is required"})
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.AdmitUser(c.Request.Context(), meetingID, claims.UserID, req.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to admit user: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
+
+// DenyUser отказывает пользователю из лобби
+// @Summary     Deny user
+// @Description Reject a user waiting in the lobby, host only
+// @Tags        lobby
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.ModerationRequest true "Waiting user"
+// @Success     200 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/lobby/deny [post]
+func (h *MeetingHandler) DenyUser(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "user_id", Reason: "is required"})
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.DenyUser(c.Request.Context(), meetingID, claims.UserID, req.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to deny user: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
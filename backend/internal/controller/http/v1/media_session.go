@@ -0,0 +1,280 @@
+package v1
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// _maxSDPSize - ограничение тела запросов WHIP и WHEP
+const _maxSDPSize = 64 << 10
+
+const (
+	contentTypeSDP        = "application/sdp"
+	contentTypeTrickleICE = "application/trickle-ice-sdpfrag"
+)
+
+type MediaSessionHandler struct {
+	mediaUC   usecase.MediaSessionUseCase
+	meetingUC usecase.MeetingUseCase
+	logger    logger.Interface
+}
+
+func newMediaSessionHandler(mediaUC usecase.MediaSessionUseCase, meetingUC usecase.MeetingUseCase, logger logger.Interface) *MediaSessionHandler {
+	return &MediaSessionHandler{
+		mediaUC:   mediaUC,
+		meetingUC: meetingUC,
+		logger:    logger,
+	}
+}
+
+// Ingest принимает поток внешнего кодировщика по WHIP
+// @Summary     WHIP ingest
+// @Description Publish a stream into an sfu meeting with WHIP (RFC 9725). The encoder joins the meeting as a new participant. Returns the SDP answer with all server candidates and the session URL in Location
+// @Tags        media
+// @Accept      application/sdp
+// @Produce     application/sdp
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       name query string false "Participant name, WHIP by default"
+// @Param       Authorization header string true "Bearer token участника"
+// @Param       offer body string true "SDP offer"
+// @Success     201 {string} string "SDP answer"
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     415 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/whip [post]
+func (h *MediaSessionHandler) Ingest(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, offer, ok := h.readOffer(c, meetingID)
+	if !ok {
+		return
+	}
+
+	session, err := h.mediaUC.Ingest(c.Request.Context(), meetingID, claims.UserID, c.Query("name"), offer)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to start whip session: %w", err))
+		return
+	}
+
+	h.created(c, session)
+}
+
+// Watch отдает поток участника внешнему плееру по WHEP
+// @Summary     WHEP playback
+// @Description Play the tracks of a meeting participant with WHEP. Returns the SDP answer with all server candidates and the session URL in Location
+// @Tags        media
+// @Accept      application/sdp
+// @Produce     application/sdp
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       user_id query string true "ID of the participant to watch"
+// @Param       Authorization header string true "Bearer token участника"
+// @Param       offer body string true "SDP offer"
+// @Success     201 {string} string "SDP answer"
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     415 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/whep [post]
+func (h *MediaSessionHandler) Watch(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, offer, ok := h.readOffer(c, meetingID)
+	if !ok {
+		return
+	}
+
+	session, err := h.mediaUC.Watch(c.Request.Context(), meetingID, claims.UserID, c.Query("user_id"), offer)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to start whep session: %w", err))
+		return
+	}
+
+	h.created(c, session)
+}
+
+// AddICECandidates передает серверу кандидатов клиента (trickle ICE)
+// @Summary     Trickle ICE
+// @Description Add client ICE candidates to a WHIP or WHEP session. ICE restarts are not supported
+// @Tags        media
+// @Accept      application/trickle-ice-sdpfrag
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       session_id path string true "Session ID"
+// @Param       Authorization header string true "Bearer token владельца сессии"
+// @Param       fragment body string true "SDP fragment with candidates"
+// @Success     204
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     415 {object} problem
+// @Router      /meeting/{meeting_id}/whip/{session_id} [patch]
+// @Router      /meeting/{meeting_id}/whep/{session_id} [patch]
+func (h *MediaSessionHandler) AddICECandidates(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	body, ok := readBody(c, contentTypeTrickleICE)
+	if !ok {
+		return
+	}
+
+	candidates, err := parseTrickleFragment(body)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.mediaUC.AddICECandidates(c.Request.Context(), meetingID, c.Param("session_id"), claims.UserID, candidates)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to add ice candidates: %w", err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CloseSession закрывает WHIP или WHEP сессию
+// @Summary     Close media session
+// @Description Close a WHIP or WHEP session. The WHIP participant leaves the meeting
+// @Tags        media
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       session_id path string true "Session ID"
+// @Param       Authorization header string true "Bearer token владельца сессии"
+// @Success     200
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/whip/{session_id} [delete]
+// @Router      /meeting/{meeting_id}/whep/{session_id} [delete]
+func (h *MediaSessionHandler) CloseSession(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	err := h.mediaUC.CloseSession(c.Request.Context(), meetingID, c.Param("session_id"), claims.UserID)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to close media session: %w", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// readOffer - проверяет, что запрос пришел от участника встречи, и читает SDP offer
+func (h *MediaSessionHandler) readOffer(c *gin.Context, meetingID string) (*entity.TokenClaims, string, bool) {
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return nil, "", false
+	}
+
+	if err := h.meetingUC.CheckMembership(c.Request.Context(), meetingID, claims.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to check meeting membership: %w", err))
+		return nil, "", false
+	}
+
+	body, ok := readBody(c, contentTypeSDP)
+	if !ok {
+		return nil, "", false
+	}
+
+	return claims, string(body), true
+}
+
+// created - ответ на offer, Location - адрес ресурса сессии для PATCH и DELETE
+func (h *MediaSessionHandler) created(c *gin.Context, session *entity.MediaSession) {
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+session.ID)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusCreated, contentTypeSDP, []byte(session.Answer))
+}
+
+// readBody - читает тело запроса с типом contentType
+func readBody(c *gin.Context, contentType string) ([]byte, bool) {
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil || mediaType != contentType {
+		errorResponse(c, http.StatusUnsupportedMediaType, "content type must be "+contentType)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, _maxSDPSize))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "failed to read request body")
+		return nil, false
+	}
+
+	if len(body) == 0 {
+		errorResponse(c, http.StatusBadRequest, "request body is empty")
+		return nil, false
+	}
+
+	return body, true
+}
+
+// parseTrickleFragment - кандидаты из SDP фрагмента trickle ICE (RFC 8840).
+// Кандидат относится к m= секции, после которой он идет
+func parseTrickleFragment(body []byte) ([]entity.ICECandidate, error) {
+	var candidates []entity.ICECandidate
+	var ufrag, mid *string
+	var mLineIndex *uint16
+
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "a=ice-ufrag:"):
+			value := strings.TrimPrefix(line, "a=ice-ufrag:")
+			ufrag = &value
+		case strings.HasPrefix(line, "m="):
+			index := uint16(0)
+			if mLineIndex != nil {
+				index = *mLineIndex + 1
+			}
+			mLineIndex = &index
+			mid = nil
+		case strings.HasPrefix(line, "a=mid:"):
+			value := strings.TrimPrefix(line, "a=mid:")
+			mid = &value
+		case strings.HasPrefix(line, "a=candidate:"):
+			if mLineIndex == nil {
+				return nil, errors.New("candidate outside of media section")
+			}
+			candidates = append(candidates, entity.ICECandidate{
+				Candidate:        strings.TrimPrefix(line, "a="),
+				SDPMid:           mid,
+				SDPMLineIndex:    mLineIndex,
+				UsernameFragment: ufrag,
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.New("invalid sdp fragment")
+	}
+
+	return candidates, nil
+}
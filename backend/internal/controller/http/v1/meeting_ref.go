@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// meetingRef - подставляет в параметр пути meeting_id ID встречи, если клиент
+// передал ее код, чтобы обработчики работали только с ID
+func meetingRef(meetingUC usecase.MeetingUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for i := range c.Params {
+			if c.Params[i].Key != "meeting_id" {
+				continue
+			}
+
+			meetingID, ok := resolveMeetingID(c, meetingUC, c.Params[i].Value)
+			if !ok {
+				return
+			}
+			c.Params[i].Value = meetingID
+		}
+
+		c.Next()
+	}
+}
+
+// resolveMeetingID - ID встречи по ID или коду, при ошибке прерывает запрос
+func resolveMeetingID(c *gin.Context, meetingUC usecase.MeetingUseCase, meetingRef string) (string, bool) {
+	meetingID, err := meetingUC.ResolveMeetingID(c.Request.Context(), meetingRef)
+	if err != nil {
+		abortWithError(c, err)
+		return "", false
+	}
+
+	return meetingID, true
+}
@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// httpMetrics - замеряет время обработки запроса. Маршрут берется шаблоном,
+// а не фактическим путем, чтобы ID встреч не порождали новые серии
+func httpMetrics(metrics usecase.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
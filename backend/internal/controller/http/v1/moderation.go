@@ -0,0 +1,190 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/gin-gonic/gin"
+)
+
+// KickUser удаляет участника из встречи
+// @Summary     Kick user
+// @Description Remove a participant from the meeting, host only
+// @Tags        moderation
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.ModerationRequest true "Target user"
+// @Success     200 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/kick [post]
+func (h *MeetingHandler) KickUser(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "user_id", Reason: "is required"})
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.KickUser(c.Request.Context(), meetingID, claims.UserID, req.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to kick user: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
+
+// RequestMute просит участника выключить микрофон
+// @Summary     Request mute
+// @Description Ask a participant to mute their microphone, host only
+// @Tags        moderation
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.ModerationRequest true "Target user"
+// @Success     200 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/mute [post]
+func (h *MeetingHandler) RequestMute(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "user_id", Reason: "is required"})
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.RequestMute(c.Request.Context(), meetingID, claims.UserID, req.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to request mute: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
+
+// LockMeeting закрывает или открывает встречу для новых участников
+// @Summary     Lock meeting
+// @Description Lock or unlock the meeting against new joins, host only
+// @Tags        moderation
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.LockMeetingRequest true "Lock state"
+// @Success     200 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/lock [post]
+func (h *MeetingHandler) LockMeeting(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.LockMeetingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.SetMeetingLocked(c.Request.Context(), meetingID, claims.UserID, req.Locked); err != nil {
+		_ = c.Error(fmt.Errorf("failed to lock meeting: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
+
+// TransferHost передает роль ведущего другому участнику
+// @Summary     Transfer host
+// @Description Make another participant the meeting host, host only
+// @Tags        moderation
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.ModerationRequest true "New host"
+// @Success     200 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/host [post]
+func (h *MeetingHandler) TransferHost(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "user_id", Reason: "is required"})
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.TransferHost(c.Request.Context(), meetingID, claims.UserID, req.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to transfer host: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
+
+// EndMeeting завершает встречу для всех участников
+// @Summary     End meeting
+// @Description End the meeting for everyone and disconnect all participants, host only
+// @Tags        moderation
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Success     200 {object} response
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/end [post]
+func (h *MeetingHandler) EndMeeting(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.EndMeeting(c.Request.Context(), meetingID, claims.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to end meeting: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
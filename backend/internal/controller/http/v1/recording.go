@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type RecordingHandler struct {
+	recordingUC usecase.RecordingUseCase
+	meetingUC   usecase.MeetingUseCase
+	logger      logger.Interface
+}
+
+func newRecordingHandler(recordingUC usecase.RecordingUseCase, meetingUC usecase.MeetingUseCase, logger logger.Interface) *RecordingHandler {
+	return &RecordingHandler{
+		recordingUC: recordingUC,
+		meetingUC:   meetingUC,
+		logger:      logger,
+	}
+}
+
+// StartRecording начинает серверную запись встречи
+// @Summary     Start recording
+// @Description Start recording every participant's tracks to files on the server, host only
+// @Tags        recording
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Success     201 {object} entity.Recording
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     500 {object} problem
+// @Failure     503 {object} problem
+// @Router      /meeting/{meeting_id}/recording/start [post]
+func (h *RecordingHandler) StartRecording(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	recording, err := h.recordingUC.StartRecording(c.Request.Context(), meetingID, claims.UserID)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to start recording: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, recording)
+}
+
+// StopRecording останавливает запись и сохраняет ее
+// @Summary     Stop recording
+// @Description Stop the meeting recording and save it, host only
+// @Tags        recording
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Success     200 {object} entity.Recording
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     500 {object} problem
+// @Failure     503 {object} problem
+// @Router      /meeting/{meeting_id}/recording/stop [post]
+func (h *RecordingHandler) StopRecording(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	recording, err := h.recordingUC.StopRecording(c.Request.Context(), meetingID, claims.UserID)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to stop recording: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, recording)
+}
+
+// ListRecordings возвращает законченные записи встречи
+// @Summary     List recordings
+// @Description List finished recordings of the meeting, oldest first. Available after the meeting has ended
+// @Tags        recording
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token участника"
+// @Success     200 {array} entity.Recording
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id}/recordings [get]
+func (h *RecordingHandler) ListRecordings(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	// Членство не проверяется: после завершения встречи ее участников уже нет,
+	// а токен участника остается действительным
+	if _, ok := authenticate(c, h.meetingUC, meetingID, ""); !ok {
+		return
+	}
+
+	recordings, err := h.recordingUC.ListRecordings(c.Request.Context(), meetingID)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to list recordings: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, recordings)
+}
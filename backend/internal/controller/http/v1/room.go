@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
@@ -23,14 +24,21 @@ func newMeetingHandler(meetingUC usecase.MeetingUseCase, logger logger.Interface
 
 // JoinMeeting создает встречу или присоединяет к существующей
 // @Summary     Join or create meeting
-// @Description Create a new meeting or join existing one
+// @Description Create a new meeting, join existing one or rejoin it with user_id and the token of the previous join
 // @Tags        meetings
 // @Accept      json
 // @Produce     json
 // @Param       request body entity.JoinMeetingRequest true "Join meeting request"
+// @Param       Authorization header string false "Bearer token прошлого входа или ведущего из POST /meeting"
 // @Success     200 {object} entity.JoinMeetingResponse
-// @Failure     400 {object} response
-// @Failure     500 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     409 {object} problem
+// @Failure     410 {object} problem
+// @Failure     429 {object} problem
+// @Failure     500 {object} problem
 // @Router      /meeting/join [post]
 func (h *MeetingHandler) JoinMeeting(c *gin.Context) {
 	var req entity.JoinMeetingRequest
@@ -41,14 +49,30 @@ func (h *MeetingHandler) JoinMeeting(c *gin.Context) {
 	}
 
 	if req.UserName == "" {
-		errorResponse(c, http.StatusBadRequest, "user_name is required")
+		_ = c.Error(&entity.ValidationError{Field: "user_name", Reason: "is required"})
 		return
 	}
 
+	req.ClientIP = c.ClientIP()
+
+	// С токеном прошлого входа участник возвращается под своим ID,
+	// ведущий встречи, созданной через POST /meeting, - на свое место
+	if tokenFromRequest(c) != "" && req.MeetingID != "" {
+		meetingID, ok := resolveMeetingID(c, h.meetingUC, req.MeetingID)
+		if !ok {
+			return
+		}
+
+		claims, ok := authenticate(c, h.meetingUC, meetingID, req.UserID)
+		if !ok {
+			return
+		}
+		req.AuthUserID = claims.UserID
+	}
+
 	resp, err := h.meetingUC.JoinMeeting(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("failed to join meeting", "error", err)
-		errorResponse(c, http.StatusInternalServerError, "failed to join meeting")
+		_ = c.Error(fmt.Errorf("failed to join meeting: %w", err))
 		return
 	}
 
@@ -60,28 +84,23 @@ func (h *MeetingHandler) JoinMeeting(c *gin.Context) {
 // @Description Get meeting information and users list
 // @Tags        meetings
 // @Produce     json
-// @Param       meeting_id path string true "Meeting ID"
+// @Param       meeting_id path string true "Meeting ID or code"
 // @Success     200 {object} entity.Meeting
-// @Failure     400 {object} response
-// @Failure     404 {object} response
-// @Failure     500 {object} response
+// @Failure     400 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
 // @Router      /meeting/{meeting_id}/info [get]
 func (h *MeetingHandler) GetMeetingInfo(c *gin.Context) {
 	meetingID := c.Param("meeting_id")
-	if meetingID == "" {
-		errorResponse(c, http.StatusBadRequest, "meeting_id is required")
-		return
-	}
 
 	meeting, err := h.meetingUC.GetMeetingInfo(c.Request.Context(), meetingID)
 	if err != nil {
-		h.logger.Error("failed to get meeting info", "meeting_id", meetingID, "error", err)
-		errorResponse(c, http.StatusInternalServerError, "failed to get meeting info")
+		_ = c.Error(fmt.Errorf("failed to get meeting info: %w", err))
 		return
 	}
 
 	if meeting == nil {
-		errorResponse(c, http.StatusNotFound, "meeting not found")
+		_ = c.Error(entity.ErrMeetingNotFound)
 		return
 	}
 
@@ -95,9 +114,14 @@ func (h *MeetingHandler) GetMeetingInfo(c *gin.Context) {
 // @Accept      json
 // @Produce     json
 // @Param       request body entity.LeaveMeetingRequest true "Leave meeting request"
+// @Param       Authorization header string false "Bearer token из JoinMeeting"
+// @Param       token query string false "Токен из JoinMeeting, если заголовок недоступен"
 // @Success     200 {object} response
-// @Failure     400 {object} response
-// @Failure     500 {object} response
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
 // @Router      /meeting/leave [post]
 func (h *MeetingHandler) LeaveMeeting(c *gin.Context) {
 	var req entity.LeaveMeetingRequest
@@ -107,17 +131,126 @@ func (h *MeetingHandler) LeaveMeeting(c *gin.Context) {
 		return
 	}
 
-	if req.MeetingID == "" || req.UserID == "" {
-		errorResponse(c, http.StatusBadRequest, "meeting_id and user_id are required")
+	if req.MeetingID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "meeting_id", Reason: "is required"})
+		return
+	}
+	if req.UserID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "user_id", Reason: "is required"})
+		return
+	}
+
+	meetingID, ok := resolveMeetingID(c, h.meetingUC, req.MeetingID)
+	if !ok {
+		return
+	}
+	req.MeetingID = meetingID
+
+	if _, ok := authenticate(c, h.meetingUC, req.MeetingID, req.UserID); !ok {
 		return
 	}
 
 	err := h.meetingUC.LeaveMeeting(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Error("failed to leave meeting", "meeting_id", req.MeetingID, "user_id", req.UserID, "error", err)
-		errorResponse(c, http.StatusInternalServerError, "failed to leave meeting")
+		_ = c.Error(fmt.Errorf("failed to leave meeting: %w", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, response{Message: "success"})
 }
+
+// CreateMeeting создает встречу заранее, без входа в нее
+// @Summary     Create meeting
+// @Description Create a meeting with a name, description, capacity, settings and schedule without joining it. The returned token belongs to the host: it updates or deletes the meeting and joins it as host
+// @Tags        meetings
+// @Accept      json
+// @Produce     json
+// @Param       request body entity.CreateMeetingRequest true "Meeting"
+// @Success     201 {object} entity.CreateMeetingResponse
+// @Failure     400 {object} problem
+// @Failure     409 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting [post]
+func (h *MeetingHandler) CreateMeeting(c *gin.Context) {
+	var req entity.CreateMeetingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.meetingUC.CreateMeeting(c.Request.Context(), &req)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to create meeting: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// UpdateMeeting меняет настройки встречи
+// @Summary     Update meeting
+// @Description Change meeting name, description, capacity, schedule, lock, lobby or passcode, host only. Only the fields present in the body change, connected participants receive meeting_updated
+// @Tags        meetings
+// @Accept      json
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Param       request body entity.UpdateMeetingRequest true "Changed fields"
+// @Success     200 {object} entity.Meeting
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id} [patch]
+func (h *MeetingHandler) UpdateMeeting(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	var req entity.UpdateMeetingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	meeting, err := h.meetingUC.UpdateMeeting(c.Request.Context(), meetingID, claims.UserID, &req)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to update meeting: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, meeting)
+}
+
+// DeleteMeeting удаляет встречу
+// @Summary     Delete meeting
+// @Description Delete the meeting, host only. Connected participants receive meeting_ended and are disconnected
+// @Tags        meetings
+// @Produce     json
+// @Param       meeting_id path string true "Meeting ID or code"
+// @Param       Authorization header string true "Bearer token ведущего"
+// @Success     200 {object} response
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     500 {object} problem
+// @Router      /meeting/{meeting_id} [delete]
+func (h *MeetingHandler) DeleteMeeting(c *gin.Context) {
+	meetingID := c.Param("meeting_id")
+
+	claims, ok := authenticate(c, h.meetingUC, meetingID, "")
+	if !ok {
+		return
+	}
+
+	if err := h.meetingUC.DeleteMeeting(c.Request.Context(), meetingID, claims.UserID); err != nil {
+		_ = c.Error(fmt.Errorf("failed to delete meeting: %w", err))
+		return
+	}
+
+	successResponse(c, http.StatusOK, "success")
+}
@@ -1,26 +1,71 @@
 package v1
 
 import (
+	"github.com/AlexandrKudryavtsev/zvonim/config"
 	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
 	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter(handler *gin.Engine, logger logger.Interface, meetingUC usecase.MeetingUseCase, wsUC usecase.WebSocketUseCase) {
+func NewRouter(handler *gin.Engine, logger logger.Interface, wsCfg config.WS, webhookCfg config.Webhooks, clock usecase.Clock, metrics usecase.Metrics, meetingUC usecase.MeetingUseCase, chatUC usecase.ChatUseCase, recordingUC usecase.RecordingUseCase, iceUC usecase.ICEUseCase, mediaUC usecase.MediaSessionUseCase, wsUC usecase.WebSocketUseCase, webhookUC usecase.WebhookUseCase) {
 	handler.Use(gin.Logger())
 	handler.Use(gin.Recovery())
+	handler.Use(httpMetrics(metrics))
+	handler.Use(errorHandler(logger))
 
 	meetingHandler := newMeetingHandler(meetingUC, logger)
-	wsHandler := newWSHandler(wsUC, logger)
+	chatHandler := newChatHandler(chatUC, meetingUC, logger)
+	recordingHandler := newRecordingHandler(recordingUC, meetingUC, logger)
+	iceHandler := newICEHandler(iceUC, meetingUC, logger)
+	mediaHandler := newMediaSessionHandler(mediaUC, meetingUC, logger)
+	wsHandler := newWSHandler(wsUC, meetingUC, logger, wsCfg, clock)
+	webhookHandler := newWebhookHandler(webhookUC, webhookCfg.AdminToken, logger)
 
 	api := handler.Group("/api")
 	{
-		meetings := api.Group("/meeting")
+		meetings := api.Group("/meeting", meetingRef(meetingUC))
 		{
+			meetings.POST("", meetingHandler.CreateMeeting)
+			meetings.PATCH("/:meeting_id", meetingHandler.UpdateMeeting)
+			meetings.DELETE("/:meeting_id", meetingHandler.DeleteMeeting)
 			meetings.POST("/join", meetingHandler.JoinMeeting)
 			meetings.GET("/:meeting_id/info", meetingHandler.GetMeetingInfo)
+			meetings.GET("/:meeting_id/attendance", meetingHandler.GetAttendance)
 			meetings.POST("/leave", meetingHandler.LeaveMeeting)
 			meetings.GET("/:meeting_id/ws", wsHandler.HandleWebSocket)
+
+			meetings.POST("/:meeting_id/kick", meetingHandler.KickUser)
+			meetings.POST("/:meeting_id/mute", meetingHandler.RequestMute)
+			meetings.POST("/:meeting_id/lock", meetingHandler.LockMeeting)
+			meetings.POST("/:meeting_id/host", meetingHandler.TransferHost)
+			meetings.POST("/:meeting_id/end", meetingHandler.EndMeeting)
+
+			meetings.GET("/:meeting_id/lobby", meetingHandler.GetLobby)
+			meetings.POST("/:meeting_id/lobby", meetingHandler.SetLobby)
+			meetings.POST("/:meeting_id/lobby/admit", meetingHandler.AdmitUser)
+			meetings.POST("/:meeting_id/lobby/deny", meetingHandler.DenyUser)
+
+			meetings.GET("/:meeting_id/chat", chatHandler.GetHistory)
+
+			meetings.POST("/:meeting_id/recording/start", recordingHandler.StartRecording)
+			meetings.POST("/:meeting_id/recording/stop", recordingHandler.StopRecording)
+			meetings.GET("/:meeting_id/recordings", recordingHandler.ListRecordings)
+
+			meetings.POST("/:meeting_id/whip", mediaHandler.Ingest)
+			meetings.PATCH("/:meeting_id/whip/:session_id", mediaHandler.AddICECandidates)
+			meetings.DELETE("/:meeting_id/whip/:session_id", mediaHandler.CloseSession)
+			meetings.POST("/:meeting_id/whep", mediaHandler.Watch)
+			meetings.PATCH("/:meeting_id/whep/:session_id", mediaHandler.AddICECandidates)
+			meetings.DELETE("/:meeting_id/whep/:session_id", mediaHandler.CloseSession)
+		}
+
+		api.GET("/ice-servers", iceHandler.GetICEServers)
+
+		webhooks := api.Group("/webhooks", webhookHandler.authorize)
+		{
+			webhooks.GET("/deliveries", webhookHandler.ListDeliveries)
+			webhooks.GET("/dead-letters", webhookHandler.ListDeadLetters)
+			webhooks.POST("/dead-letters/:delivery_id/retry", webhookHandler.RetryDeadLetter)
 		}
 	}
 
@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookUC  usecase.WebhookUseCase
+	adminToken string
+	logger     logger.Interface
+}
+
+func newWebhookHandler(webhookUC usecase.WebhookUseCase, adminToken string, logger logger.Interface) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUC:  webhookUC,
+		adminToken: adminToken,
+		logger:     logger,
+	}
+}
+
+// authorize - журнал доставок доступен только с токеном администратора из конфигурации
+func (h *WebhookHandler) authorize(c *gin.Context) {
+	if h.adminToken == "" {
+		abortWithError(c, entity.ErrWebhookAdminDisabled)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(tokenFromRequest(c)), []byte(h.adminToken)) != 1 {
+		abortWithError(c, entity.ErrInvalidToken)
+		return
+	}
+}
+
+// ListDeliveries возвращает журнал доставок вебхуков
+// @Summary     List webhook deliveries
+// @Description Latest webhook deliveries, newest first, with attempts, last response status and next retry time
+// @Tags        webhooks
+// @Produce     json
+// @Param       Authorization header string true "Bearer токен администратора вебхуков"
+// @Param       status query string false "pending, delivered или dead"
+// @Param       limit query int false "Число доставок, по умолчанию 50, не больше 500"
+// @Success     200 {array} entity.WebhookDelivery
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     500 {object} problem
+// @Router      /webhooks/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			_ = c.Error(&entity.ValidationError{Field: "limit", Reason: "must be a positive integer"})
+			return
+		}
+	}
+
+	deliveries, err := h.webhookUC.ListDeliveries(c.Request.Context(), c.Query("status"), limit)
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to list webhook deliveries: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// ListDeadLetters возвращает события, которые не удалось доставить
+// @Summary     List webhook dead letters
+// @Description Webhook deliveries that ran out of attempts, oldest first, together with their events
+// @Tags        webhooks
+// @Produce     json
+// @Param       Authorization header string true "Bearer токен администратора вебхуков"
+// @Success     200 {array} entity.WebhookDeadLetter
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     500 {object} problem
+// @Router      /webhooks/dead-letters [get]
+func (h *WebhookHandler) ListDeadLetters(c *gin.Context) {
+	letters, err := h.webhookUC.ListDeadLetters(c.Request.Context())
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to list dead letters: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, letters)
+}
+
+// RetryDeadLetter заново отправляет недоставленное событие
+// @Summary     Retry webhook dead letter
+// @Description Remove the event from dead letters and deliver it again as a new delivery with a fresh attempt counter
+// @Tags        webhooks
+// @Produce     json
+// @Param       Authorization header string true "Bearer токен администратора вебхуков"
+// @Param       delivery_id path string true "Delivery ID"
+// @Success     202 {object} entity.WebhookDelivery
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
+// @Failure     410 {object} problem
+// @Failure     500 {object} problem
+// @Router      /webhooks/dead-letters/{delivery_id}/retry [post]
+func (h *WebhookHandler) RetryDeadLetter(c *gin.Context) {
+	delivery, err := h.webhookUC.RetryDeadLetter(c.Request.Context(), c.Param("delivery_id"))
+	if err != nil {
+		_ = c.Error(fmt.Errorf("failed to retry dead letter: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
@@ -2,8 +2,12 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	"github.com/AlexandrKudryavtsev/zvonim/config"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
 	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
 	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -11,43 +15,76 @@ import (
 )
 
 type WSHandler struct {
-	wsUC   usecase.WebSocketUseCase
-	logger logger.Interface
+	wsUC      usecase.WebSocketUseCase
+	meetingUC usecase.MeetingUseCase
+	logger    logger.Interface
+	cfg       config.WS
+	clock     usecase.Clock
+	upgrader  websocket.Upgrader
 }
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // TODO: в production настроить
-	},
-}
-
-func newWSHandler(wsUC usecase.WebSocketUseCase, logger logger.Interface) *WSHandler {
+func newWSHandler(wsUC usecase.WebSocketUseCase, meetingUC usecase.MeetingUseCase, logger logger.Interface, cfg config.WS, clock usecase.Clock) *WSHandler {
 	return &WSHandler{
-		wsUC:   wsUC,
-		logger: logger,
+		wsUC:      wsUC,
+		meetingUC: meetingUC,
+		logger:    logger,
+		cfg:       cfg,
+		clock:     clock,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  cfg.ReadBufferSize,
+			WriteBufferSize: cfg.WriteBufferSize,
+			CheckOrigin: func(r *http.Request) bool {
+				return true // TODO: в production настроить
+			},
+		},
 	}
 }
 
 // HandleWebSocket обрабатывает WebSocket соединения для сигналинга
 // @Summary     WebSocket для сигналинга
-// @Description WebSocket endpoint для обмена WebRTC сигналами
+// @Description WebSocket endpoint для обмена WebRTC сигналами. Пользователь из лобби получает по нему только решение ведущего
 // @Tags        websocket
-// @Param       meeting_id path string true "Meeting ID"
+// @Param       meeting_id path string true "Meeting ID or code"
 // @Param       user_id query string true "User ID"
+// @Param       token query string true "Токен из JoinMeeting"
+// @Param       protocol_version query int false "Версия сигнального протокола, по умолчанию текущая"
+// @Param       resume_token query string false "resume_token из hello, чтобы возобновить сессию после обрыва"
+// @Failure     400 {object} problem
+// @Failure     401 {object} problem
+// @Failure     403 {object} problem
+// @Failure     404 {object} problem
 // @Router      /meeting/{meeting_id}/ws [get]
 func (h *WSHandler) HandleWebSocket(c *gin.Context) {
 	meetingID := c.Param("meeting_id")
 	userID := c.Query("user_id")
 
-	if meetingID == "" || userID == "" {
-		errorResponse(c, http.StatusBadRequest, "meeting_id and user_id are required")
+	if userID == "" {
+		_ = c.Error(&entity.ValidationError{Field: "user_id", Reason: "is required"})
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	protocolVersion, err := protocol.Negotiate(c.Query("protocol_version"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, ok := authenticate(c, h.meetingUC, meetingID, userID); !ok {
+		return
+	}
+
+	// Пользователь из лобби получает соединение, по которому придет решение ведущего
+	err = h.meetingUC.CheckMembership(c.Request.Context(), meetingID, userID)
+	pending := errors.Is(err, entity.ErrNotAdmitted)
+	if err != nil && !pending {
+		_ = c.Error(err)
+		return
+	}
+
+	// При неудаче upgrader сам отвечает клиенту
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		h.logger.Error("failed to upgrade websocket connection", "error", err)
-		errorResponse(c, http.StatusInternalServerError, "failed to establish websocket connection")
 		return
 	}
 
@@ -55,7 +92,19 @@ func (h *WSHandler) HandleWebSocket(c *gin.Context) {
 
 	ctx := context.Background()
 
-	wsConn := newWSConnection(conn)
+	wsConn := newWSConnection(conn, h.cfg, h.clock)
+
+	session := &entity.WSSession{
+		MeetingID:       meetingID,
+		UserID:          userID,
+		ProtocolVersion: protocolVersion,
+		ResumeToken:     c.Query("resume_token"),
+	}
+
+	if pending {
+		go h.wsUC.HandleLobbyConnection(ctx, wsConn, session)
+		return
+	}
 
-	go h.wsUC.HandleConnection(ctx, wsConn, meetingID, userID)
+	go h.wsUC.HandleConnection(ctx, wsConn, session)
 }
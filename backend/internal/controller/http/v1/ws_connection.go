@@ -2,19 +2,53 @@ package v1
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/AlexandrKudryavtsev/zvonim/config"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
 	"github.com/gorilla/websocket"
 )
 
+// _writeWait - время на запись одного сообщения или ping
+const _writeWait = 10 * time.Second
+
 type wsConnection struct {
-	conn *websocket.Conn
-	mu   sync.Mutex
+	conn       *websocket.Conn
+	clock      usecase.Clock
+	mu         sync.Mutex
+	pongWait   time.Duration
+	pingPeriod time.Duration
+	// lastPong - время последнего pong по часам clock в наносекундах Unix
+	lastPong  atomic.Int64
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
-func newWSConnection(conn *websocket.Conn) *wsConnection {
-	return &wsConnection{
-		conn: conn,
+func newWSConnection(conn *websocket.Conn, cfg config.WS, clock usecase.Clock) *wsConnection {
+	w := &wsConnection{
+		conn:       conn,
+		clock:      clock,
+		pongWait:   cfg.PongWait,
+		pingPeriod: cfg.PingPeriod,
+		done:       make(chan struct{}),
 	}
+
+	// Read deadline сетевой, поэтому по настоящему времени. Он снимает клиента,
+	// даже если pingLoop не успел его проверить
+	conn.SetReadLimit(cfg.MaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(w.pongWait))
+	w.lastPong.Store(clock.Now().UnixNano())
+	conn.SetPongHandler(func(string) error {
+		w.lastPong.Store(w.clock.Now().UnixNano())
+		return conn.SetReadDeadline(time.Now().Add(w.pongWait))
+	})
+
+	// Тикер заводится до возврата, чтобы первый период отсчитывался от подключения
+	go w.pingLoop(clock.NewTicker(w.pingPeriod))
+
+	return w
 }
 
 func (w *wsConnection) ReadMessage() ([]byte, error) {
@@ -25,9 +59,48 @@ func (w *wsConnection) ReadMessage() ([]byte, error) {
 func (w *wsConnection) WriteMessage(messageType int, data []byte) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+
+	_ = w.conn.SetWriteDeadline(time.Now().Add(_writeWait))
 	return w.conn.WriteMessage(messageType, data)
 }
 
+func (w *wsConnection) CloseWithReason(code int, reason string) error {
+	_ = w.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(_writeWait))
+	return w.Close()
+}
+
 func (w *wsConnection) Close() error {
-	return w.conn.Close()
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.conn.Close()
+	})
+	return err
+}
+
+// pingLoop - периодически пингует клиента. Если клиент не ответил pong дольше
+// pongWait по часам clock, соединение закрывается: ReadMessage возвращает ошибку,
+// и соединение снимается с регистрации. Молчание проверяется на тиках, а между
+// ними клиента отключает read deadline
+func (w *wsConnection) pingLoop(ticker clock.Ticker) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C():
+			lastPong := time.Unix(0, w.lastPong.Load())
+			if w.clock.Now().Sub(lastPong) > w.pongWait {
+				_ = w.Close()
+				return
+			}
+
+			err := w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(_writeWait))
+			if err != nil {
+				_ = w.Close()
+				return
+			}
+		}
+	}
 }
@@ -0,0 +1,245 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/config"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/broker"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/metrics"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+	"github.com/gorilla/websocket"
+)
+
+func TestSilentPeerIsEvicted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := config.WS{PongWait: 3 * time.Second, PingPeriod: time.Second, MaxMessageSize: 4096}
+	fakeClock := clock.NewFake(time.Now())
+
+	meetingRepo := repo.NewMemoryMeetingRepository()
+	meeting := &entity.Meeting{ID: entity.GenerateMeetingID(), Code: entity.GenerateMeetingCode(), CreatedAt: fakeClock.Now()}
+	if err := meetingRepo.CreateMeeting(ctx, meeting); err != nil {
+		t.Fatalf("create meeting: %v", err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if err := meetingRepo.AddUserToMeeting(ctx, meeting.ID, &entity.User{ID: name, Name: name}); err != nil {
+			t.Fatalf("add user: %v", err)
+		}
+	}
+
+	wsUC := usecase.NewWebSocketService(meetingRepo, stubMeetingUC{}, stubChatUC{}, stubRecordingUC{}, broker.NewMemoryBroker(), nil,
+		usecase.NewEventBus(), fakeClock, metrics.Nop{}, usecase.SessionConfig{SendQueueSize: 16, OverflowPolicy: usecase.OverflowDropOldest})
+	if err := wsUC.Start(ctx); err != nil {
+		t.Fatalf("start websocket service: %v", err)
+	}
+
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		wsUC.HandleConnection(ctx, newWSConnection(conn, cfg, fakeClock), &entity.WSSession{
+			MeetingID:       meeting.ID,
+			UserID:          r.URL.Query().Get("user_id"),
+			ProtocolVersion: protocol.Version,
+		})
+	}))
+	defer server.Close()
+
+	// Боб подключается и молчит: не читает, поэтому и не отвечает на ping
+	bob := dialTestWS(t, server, "bob")
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(2 * time.Second)
+
+	// Алиса подключается позже, и к концу теста ее молчание не превысит pongWait
+	alice := dialTestWS(t, server, "alice")
+	fakeClock.BlockUntil(2)
+	messages := readTestWS(alice)
+
+	fakeClock.Advance(2 * time.Second)
+
+	deadline := time.After(2 * time.Second)
+	for left := false; !left; {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				t.Fatal("alice was disconnected")
+			}
+			left = message.Type == protocol.TypeUserLeft && message.From == "bob"
+		case <-deadline:
+			t.Fatal("alice did not receive user_left for bob")
+		}
+	}
+
+	_ = bob.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := bob.ReadMessage(); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Fatal("bob connection was not closed")
+			}
+			break
+		}
+	}
+
+	online, err := meetingRepo.GetMeetingUsers(ctx, meeting.ID)
+	if err != nil {
+		t.Fatalf("get users: %v", err)
+	}
+	for _, user := range online {
+		if user.IsOnline != (user.ID == "alice") {
+			t.Fatalf("user %s online is %t", user.ID, user.IsOnline)
+		}
+	}
+}
+
+func TestReadDeadline(t *testing.T) {
+	const pongWait = 300 * time.Millisecond
+
+	tests := []struct {
+		name string
+		// pong - отвечает ли клиент pong чаще pongWait
+		pong     bool
+		wantOpen bool
+	}{
+		{name: "silent peer times out", pong: false, wantOpen: false},
+		{name: "pong extends deadline", pong: true, wantOpen: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Часы не двигаются, отключает только сетевой read deadline
+			cfg := config.WS{PongWait: pongWait, PingPeriod: time.Hour, MaxMessageSize: 4096}
+			fakeClock := clock.NewFake(time.Now())
+
+			readErr := make(chan error, 1)
+			var upgrader websocket.Upgrader
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+
+				wsConn := newWSConnection(conn, cfg, fakeClock)
+				defer wsConn.Close()
+
+				_, err = wsConn.ReadMessage()
+				readErr <- err
+			}))
+			t.Cleanup(server.Close)
+
+			client := dialTestWS(t, server, "alice")
+			if tt.pong {
+				stop := make(chan struct{})
+				defer close(stop)
+				go func() {
+					ticker := time.NewTicker(pongWait / 3)
+					defer ticker.Stop()
+					for {
+						select {
+						case <-stop:
+							return
+						case <-ticker.C:
+							_ = client.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second))
+						}
+					}
+				}()
+			}
+
+			select {
+			case err := <-readErr:
+				if tt.wantOpen {
+					t.Fatalf("connection closed with %v despite pongs", err)
+				}
+				var netErr net.Error
+				if !errors.As(err, &netErr) || !netErr.Timeout() {
+					t.Fatalf("got read error %v, want timeout", err)
+				}
+			case <-time.After(3 * pongWait):
+				if !tt.wantOpen {
+					t.Fatal("silent connection was not closed by the read deadline")
+				}
+			}
+		})
+	}
+}
+
+func dialTestWS(t *testing.T, server *httptest.Server, userID string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", userID, err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// readTestWS - читает сообщения клиента, пока соединение не закроется. Чтение
+// заодно отвечает на ping сервера
+func readTestWS(conn *websocket.Conn) <-chan entity.WSMessage {
+	messages := make(chan entity.WSMessage, 16)
+
+	go func() {
+		defer close(messages)
+
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var message entity.WSMessage
+			if json.Unmarshal(data, &message) == nil {
+				messages <- message
+			}
+		}
+	}()
+
+	return messages
+}
+
+// Заглушки сценариев реализуют только то, что вызывает HandleConnection. Общий пакет
+// с ними импортировал бы usecase, и внутренние тесты usecase не смогли бы его подключить
+type stubMeetingUC struct {
+	usecase.MeetingUseCase
+}
+
+func (stubMeetingUC) GetLobby(ctx context.Context, meetingID, hostID string) ([]entity.User, error) {
+	return nil, entity.ErrNotHost
+}
+
+type stubChatUC struct {
+	usecase.ChatUseCase
+}
+
+func (stubChatUC) GetHistory(ctx context.Context, meetingID, viewerID, before string, limit int) (*entity.ChatHistory, error) {
+	return &entity.ChatHistory{}, nil
+}
+
+type stubRecordingUC struct {
+	usecase.RecordingUseCase
+}
+
+func (stubRecordingUC) ActiveRecording(meetingID string) *entity.Recording {
+	return nil
+}
+
+func (stubRecordingUC) ParticipantLeft(meetingID, userID string) {}
@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+// Виды событий журнала посещаемости
+const (
+	AttendanceJoined      = "joined"
+	AttendanceReconnected = "reconnected"
+	AttendanceLeft        = "left"
+)
+
+// AttendanceEvent - запись журнала посещаемости: пользователь подключился к встрече
+// впервые, вернулся после обрыва или отключился
+type AttendanceEvent struct {
+	MeetingID string    `json:"meeting_id"`
+	UserID    string    `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Type      string    `json:"type"`
+	At        time.Time `json:"at"`
+}
+
+// AttendanceReport - итоги посещаемости встречи по участникам в порядке первого входа
+type AttendanceReport struct {
+	MeetingID   string             `json:"meeting_id"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Attendees   []AttendanceRecord `json:"attendees"`
+}
+
+// AttendanceRecord - итоги одного участника. TotalSeconds учитывает и открытую
+// сессию, LastLeftAt пустой, пока участник во встрече
+type AttendanceRecord struct {
+	UserID        string     `json:"user_id"`
+	UserName      string     `json:"user_name"`
+	FirstJoinedAt time.Time  `json:"first_joined_at"`
+	LastLeftAt    *time.Time `json:"last_left_at,omitempty"`
+	TotalSeconds  int64      `json:"total_seconds"`
+	Reconnects    int        `json:"reconnects"`
+	Online        bool       `json:"online"`
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// TokenClaims - данные участника, зашитые в подписанный токен
+type TokenClaims struct {
+	MeetingID string
+	UserID    string
+	ExpiresAt time.Time
+}
+
+var (
+	ErrInvalidToken  = NewError(KindUnauthorized, "invalid_token", "invalid token")
+	ErrTokenExpired  = NewError(KindUnauthorized, "token_expired", "token expired")
+	ErrTokenMismatch = NewError(KindForbidden, "token_mismatch", "token does not match meeting or user")
+)
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChatMessage - сообщение чата встречи. Пустой To означает сообщение всем участникам
+type ChatMessage struct {
+	ID        string    `json:"message_id"`
+	MeetingID string    `json:"-"`
+	From      string    `json:"from"`
+	FromName  string    `json:"from_name"`
+	To        string    `json:"to,omitempty"`
+	Text      string    `json:"text"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// VisibleTo - личные сообщения видны только отправителю и получателю
+func (m *ChatMessage) VisibleTo(userID string) bool {
+	return m.To == "" || m.From == userID || m.To == userID
+}
+
+// ChatHistory - страница истории чата от старых к новым.
+// NextBefore - курсор для запроса более ранних сообщений, пуст на последней странице
+type ChatHistory struct {
+	Messages   []ChatMessage `json:"messages"`
+	NextBefore string        `json:"next_before,omitempty"`
+}
+
+var ErrInvalidCursor = NewError(KindValidation, "invalid_cursor", "chat cursor not found")
+
+func GenerateMessageID() string {
+	return uuid.New().String()
+}
@@ -0,0 +1,99 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrorKind - вид доменной ошибки. По нему транспорт выбирает код ответа,
+// не перечисляя конкретные ошибки
+type ErrorKind string
+
+const (
+	KindValidation      ErrorKind = "validation"
+	KindUnauthorized    ErrorKind = "unauthorized"
+	KindForbidden       ErrorKind = "forbidden"
+	KindNotFound        ErrorKind = "not_found"
+	KindConflict        ErrorKind = "conflict"
+	KindGone            ErrorKind = "gone"
+	KindCapacity        ErrorKind = "capacity_exceeded"
+	KindTooManyAttempts ErrorKind = "too_many_attempts"
+	KindUnavailable     ErrorKind = "unavailable"
+)
+
+// DomainError - ошибка предметной области: вид и машиночитаемый код для клиента.
+// Ошибки, которые не реализуют DomainError, считаются внутренними
+type DomainError interface {
+	error
+	Kind() ErrorKind
+	Code() string
+}
+
+// Error - доменная ошибка без дополнительных данных, из таких объявлены
+// ошибки-значения пакета. Сравниваются через errors.Is
+type Error struct {
+	kind    ErrorKind
+	code    string
+	message string
+}
+
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{kind: kind, code: code, message: message}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+func (e *Error) Kind() ErrorKind {
+	return e.kind
+}
+
+func (e *Error) Code() string {
+	return e.code
+}
+
+// KindOf - вид первой доменной ошибки в цепочке err, пустой, если ее нет
+func KindOf(err error) ErrorKind {
+	var domainErr DomainError
+	if errors.As(err, &domainErr) {
+		return domainErr.Kind()
+	}
+	return ""
+}
+
+type ValidationError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation error: %s %s", e.Field, e.Reason)
+}
+
+func (e *ValidationError) Kind() ErrorKind {
+	return KindValidation
+}
+
+func (e *ValidationError) Code() string {
+	return "validation_failed"
+}
+
+// AttemptsExceededError - слишком много неверных кодов доступа, новые попытки
+// принимаются через RetryAfter
+type AttemptsExceededError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AttemptsExceededError) Error() string {
+	return fmt.Sprintf("too many failed passcode attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *AttemptsExceededError) Kind() ErrorKind {
+	return KindTooManyAttempts
+}
+
+func (e *AttemptsExceededError) Code() string {
+	return "too_many_attempts"
+}
@@ -0,0 +1,134 @@
+package entity
+
+import "time"
+
+// DomainEvent - изменение состояния встречи, которое usecase публикует в шину событий.
+// Подписчики различают события по типу
+type DomainEvent interface {
+	Meta() EventMeta
+}
+
+// EventMeta - встреча, к которой относится событие, и время изменения
+type EventMeta struct {
+	MeetingID string
+	At        time.Time
+}
+
+func (m EventMeta) Meta() EventMeta {
+	return m
+}
+
+// Причины, по которым участник перестал состоять во встрече
+const (
+	RemoveReasonLeft   = "left"
+	RemoveReasonKicked = "kicked"
+	// RemoveReasonGone - janitor удалил участника, который долго не подключался
+	RemoveReasonGone = "gone"
+)
+
+// Чем закончилось ожидание в лобби
+const (
+	LobbyOutcomeLeft     = "left"
+	LobbyOutcomeAdmitted = "admitted"
+	LobbyOutcomeDenied   = "denied"
+)
+
+type (
+	MeetingCreatedEvent struct {
+		EventMeta
+		Meeting *Meeting
+	}
+
+	// MeetingUpdatedEvent - By изменил настройки встречи, Meeting - все текущие значения
+	MeetingUpdatedEvent struct {
+		EventMeta
+		Meeting *Meeting
+		By      string
+	}
+
+	// MeetingEndedEvent - встреча уже удалена. Пустой By - ее завершил сервер
+	MeetingEndedEvent struct {
+		EventMeta
+		Meeting *Meeting
+		Reason  string
+		By      string
+	}
+
+	MeetingLockedEvent struct {
+		EventMeta
+		Locked bool
+		By     string
+	}
+
+	HostChangedEvent struct {
+		EventMeta
+		HostID         string
+		PreviousHostID string
+	}
+
+	// ParticipantAddedEvent - пользователь стал участником встречи: вошел или впущен из лобби
+	ParticipantAddedEvent struct {
+		EventMeta
+		User User
+	}
+
+	// ParticipantRemovedEvent - пользователь больше не участник встречи. By - ведущий,
+	// если участника исключили
+	ParticipantRemovedEvent struct {
+		EventMeta
+		UserID string
+		Reason string
+		By     string
+	}
+
+	// ParticipantConnectedEvent - участник подключился к встрече: открыл WebSocket
+	// без возобновления сессии или начал публикацию через WHIP
+	ParticipantConnectedEvent struct {
+		EventMeta
+		UserID   string
+		UserName string
+	}
+
+	// ParticipantDisconnectedEvent - участник отключился и не вернулся за время
+	// возобновления сессии или закрыл сессию WHIP
+	ParticipantDisconnectedEvent struct {
+		EventMeta
+		UserID   string
+		UserName string
+	}
+
+	// LobbyJoinedEvent - User ждет в лобби решения ведущего HostID
+	LobbyJoinedEvent struct {
+		EventMeta
+		User   User
+		HostID string
+	}
+
+	// LobbyLeftEvent - пользователь больше не ждет в лобби. Outcome - ушел сам,
+	// впущен или получил отказ от By. Reason - причина отказа
+	LobbyLeftEvent struct {
+		EventMeta
+		UserID  string
+		HostID  string
+		Outcome string
+		By      string
+		Reason  string
+	}
+
+	RecordingStartedEvent struct {
+		EventMeta
+		Recording *Recording
+	}
+
+	// RecordingStoppedEvent - пустой By - запись остановил сервер
+	RecordingStoppedEvent struct {
+		EventMeta
+		Recording *Recording
+		By        string
+	}
+
+	ChatMessageSentEvent struct {
+		EventMeta
+		Message *ChatMessage
+	}
+)
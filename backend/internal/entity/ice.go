@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// ICEServer - сервер в формате RTCIceServer, клиент передает его в RTCPeerConnection как есть
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
+// ICEServers - ответ GET /api/ice-servers. ExpiresAt - когда истекают учетные данные TURN,
+// до этого момента клиенту нужно запросить новые
+type ICEServers struct {
+	ICEServers []ICEServer `json:"ice_servers"`
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty"`
+}
+
+// TURNCredentials - временные учетные данные встроенного TURN сервера (TURN REST API)
+type TURNCredentials struct {
+	Username  string
+	Password  string
+	ExpiresAt time.Time
+}
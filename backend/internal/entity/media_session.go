@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды медиасессий: whip - внешний кодировщик публикует поток во встречу,
+// whep - внешний плеер смотрит поток участника
+const (
+	MediaSessionWHIP = "whip"
+	MediaSessionWHEP = "whep"
+)
+
+// MediaSession - WebRTC соединение WHIP или WHEP клиента с медиасервером встречи.
+// OwnerID - участник, чьим токеном создана сессия. UserID - для whip участник,
+// от имени которого публикуется поток, для whep - участник, чей поток смотрят
+type MediaSession struct {
+	ID        string    `json:"session_id"`
+	MeetingID string    `json:"meeting_id"`
+	Kind      string    `json:"kind"`
+	OwnerID   string    `json:"owner_id"`
+	UserID    string    `json:"user_id"`
+	Answer    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var (
+	ErrMediaSessionNotFound = NewError(KindNotFound, "media_session_not_found", "media session not found")
+	ErrNotPublishing        = NewError(KindConflict, "not_publishing", "user is not publishing any tracks")
+)
+
+func GenerateMediaSessionID() string {
+	return uuid.New().String()
+}
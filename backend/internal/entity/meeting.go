@@ -1,7 +1,11 @@
 package entity
 
 import (
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,30 +13,153 @@ import (
 
 type Meeting struct {
 	ID        string    `json:"meeting_id"`
+	Code      string    `json:"meeting_code"`
 	Name      string    `json:"meeting_name"`
+	HostID    string    `json:"host_id"`
+	Locked    bool      `json:"locked"`
+	Lobby     bool      `json:"lobby"`
+	Mode      string    `json:"mode"`
 	Users     []User    `json:"users"`
 	CreatedAt time.Time `json:"created_at"`
+	// Description - описание встречи, Capacity - предел участников, 0 - без ограничения
+	Description string `json:"description"`
+	Capacity    int    `json:"capacity"`
+	// StartsAt и EndsAt - расписание встречи, оба необязательны
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	// PasscodeHash - хеш кода доступа, пустой у встречи без кода
+	PasscodeHash     string `json:"-"`
+	PasscodeRequired bool   `json:"passcode_required"`
 }
 
+// Режимы медиа: mesh - участники обмениваются потоками напрямую,
+// sfu - публикуют поток серверу, а он пересылает его остальным
+const (
+	MeetingModeMesh = "mesh"
+	MeetingModeSFU  = "sfu"
+)
+
+// Причины завершения встречи
+const (
+	EndReasonHost     = "ended_by_host"
+	EndReasonSchedule = "schedule"
+	EndReasonIdle     = "idle"
+	EndReasonDeleted  = "deleted"
+)
+
+// DefaultMeetingName - название встречи, если создатель его не задал
+const DefaultMeetingName = "Untitled Meeting"
+
 type User struct {
-	ID       string `json:"user_id"`
-	Name     string `json:"user_name"`
-	IsOnline bool   `json:"is_online"`
+	ID   string `json:"user_id"`
+	Name string `json:"user_name"`
+	// JoinedAt - вход во встречу, у ожидающих в лобби - когда они попросились войти
+	JoinedAt time.Time `json:"joined_at"`
+	// IsOnline - открыта ли последняя сессия, хранилище выводит его из Sessions
+	IsOnline bool `json:"is_online"`
+	// Sessions - подключения пользователя от старых к новым. История переживает
+	// выход из встречи и продолжается, если пользователь вернется под тем же ID
+	Sessions []UserSession `json:"sessions"`
 }
 
-type ValidationError struct {
-	Field  string `json:"field"`
-	Reason string `json:"reason"`
+// UserSession - подключение пользователя к встрече, LeftAt пустой, пока оно открыто
+type UserSession struct {
+	JoinedAt time.Time  `json:"joined_at"`
+	LeftAt   *time.Time `json:"left_at,omitempty"`
 }
 
-func (e *ValidationError) Error() string {
-	return fmt.Sprintf("validation error: %s %s", e.Field, e.Reason)
+// LastSeen - когда пользователь в последний раз был во встрече: конец последней
+// сессии или вход, если он еще не подключался
+func (u *User) LastSeen() time.Time {
+	if len(u.Sessions) == 0 {
+		return u.JoinedAt
+	}
+
+	last := u.Sessions[len(u.Sessions)-1]
+	if last.LeftAt == nil {
+		return last.JoinedAt
+	}
+	return *last.LeftAt
 }
 
+var (
+	ErrMeetingNotFound   = NewError(KindNotFound, "meeting_not_found", "meeting not found")
+	ErrMeetingExists     = NewError(KindConflict, "meeting_exists", "meeting already exists")
+	ErrNotMember         = NewError(KindForbidden, "not_member", "user is not a member of the meeting")
+	ErrUserNotFound      = NewError(KindNotFound, "user_not_found", "user not found in meeting")
+	ErrUserExists        = NewError(KindConflict, "user_exists", "user already exists in meeting")
+	ErrNotHost           = NewError(KindForbidden, "not_host", "action is allowed only to the meeting host")
+	ErrMeetingLocked     = NewError(KindForbidden, "meeting_locked", "meeting is locked")
+	ErrMeetingNotStarted = NewError(KindForbidden, "meeting_not_started", "meeting has not started yet")
+	ErrMeetingEnded      = NewError(KindGone, "meeting_ended", "meeting has ended")
+	ErrSFUUnavailable    = NewError(KindConflict, "sfu_unavailable", "meeting is not in sfu mode")
+	ErrNotAdmitted       = NewError(KindForbidden, "not_admitted", "user is waiting in the lobby")
+	ErrPasscodeRequired  = NewError(KindForbidden, "passcode_required", "meeting passcode is required")
+	ErrInvalidPasscode   = NewError(KindForbidden, "invalid_passcode", "invalid meeting passcode")
+	ErrMeetingCodeTaken  = NewError(KindConflict, "meeting_code_taken", "meeting code is already taken")
+	ErrMeetingFull       = NewError(KindCapacity, "meeting_full", "meeting is full")
+)
+
 func GenerateUserID() string {
 	return uuid.New().String()
 }
 
+// GenerateResumeToken - непредсказуемый токен возобновления сигнальной сессии
+func GenerateResumeToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// GenerateReplicaID - ID экземпляра сервиса для сообщений между репликами
+func GenerateReplicaID() string {
+	return uuid.New().String()
+}
+
 func GenerateMeetingID() string {
 	return uuid.New().String()
 }
+
+// IsMeetingID - похожа ли строка на ID встречи, а не на ее код
+func IsMeetingID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil
+}
+
+// _meetingCodeAlphabet - только строчные буквы, чтобы код было легко продиктовать
+const _meetingCodeAlphabet = "abcdefghijklmnopqrstuvwxyz"
+
+// GenerateMeetingCode - случайный код вида abc-defg-hij
+func GenerateMeetingCode() string {
+	alphabetSize := big.NewInt(int64(len(_meetingCodeAlphabet)))
+
+	code := make([]byte, 0, 12)
+	for i := 0; i < 10; i++ {
+		if i == 3 || i == 7 {
+			code = append(code, '-')
+		}
+
+		n, _ := rand.Int(rand.Reader, alphabetSize)
+		code = append(code, _meetingCodeAlphabet[n.Int64()])
+	}
+
+	return string(code)
+}
+
+var _vanityCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,30}[a-z0-9]$`)
+
+// NormalizeMeetingCode - приводит код, выбранный пользователем, к нижнему регистру
+// и проверяет его: 3-32 символа, латинские буквы, цифры и дефисы не по краям.
+// Код, похожий на ID встречи (32 hex символа - тоже UUID), запрещен: по нему
+// искалась бы встреча с таким ID, а не с таким кодом
+func NormalizeMeetingCode(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if !_vanityCodePattern.MatchString(code) {
+		return "", &ValidationError{Field: "meeting_code", Reason: "must be 3-32 latin letters, digits or inner hyphens"}
+	}
+	if IsMeetingID(code) {
+		return "", &ValidationError{Field: "meeting_code", Reason: "must not look like a meeting id"}
+	}
+
+	return code, nil
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMeetingCode(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{code: "team-standup", want: "team-standup"},
+		{code: "  Team-Standup ", want: "team-standup"},
+		{code: "abc", want: "abc"},
+		{code: strings.Repeat("z", 32), want: strings.Repeat("z", 32)},
+		{code: "ab", wantErr: true},
+		{code: strings.Repeat("a", 33), wantErr: true},
+		{code: "-abc", wantErr: true},
+		{code: "abc-", wantErr: true},
+		{code: "abc_def", wantErr: true},
+		{code: "комната", wantErr: true},
+		// 32 hex символа - это UUID без дефисов, такой код перепутался бы с ID встречи
+		{code: "550e8400e29b41d4a716446655440000", wantErr: true},
+		{code: "550E8400E29B41D4A716446655440000", wantErr: true},
+		{code: "550e8400e29b41d4a71644665544000", want: "550e8400e29b41d4a71644665544000"},
+		{code: "550e8400e29b41d4a71644665544000g", want: "550e8400e29b41d4a71644665544000g"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, err := NormalizeMeetingCode(tt.code)
+			if tt.wantErr {
+				var validation *ValidationError
+				if !errors.As(err, &validation) || validation.Field != "meeting_code" {
+					t.Fatalf("got %q, %v, want meeting_code validation error", got, err)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Fatalf("got %q, %v, want %q", got, err, tt.want)
+			}
+			if IsMeetingID(got) {
+				t.Fatalf("accepted code %q resolves as a meeting id", got)
+			}
+		})
+	}
+}
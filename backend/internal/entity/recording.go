@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Recording - серверная запись встречи. Mode - режим встречи на момент начала записи:
+// в режиме mesh участники сами отправляют потоки скрытому участнику-рекордеру
+type Recording struct {
+	ID        string          `json:"recording_id"`
+	MeetingID string          `json:"meeting_id"`
+	Mode      string          `json:"mode"`
+	StartedBy string          `json:"started_by"`
+	StartedAt time.Time       `json:"started_at"`
+	StoppedAt *time.Time      `json:"stopped_at,omitempty"`
+	Files     []RecordingFile `json:"files"`
+}
+
+// RecordingFile - дорожка участника, записанная в отдельный файл каталога записи
+type RecordingFile struct {
+	UserID string `json:"user_id"`
+	Kind   string `json:"kind"`
+	Codec  string `json:"codec"`
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+}
+
+var (
+	ErrRecordingDisabled   = NewError(KindUnavailable, "recording_disabled", "recording is disabled on this server")
+	ErrRecordingInProgress = NewError(KindConflict, "recording_in_progress", "meeting is already being recorded")
+	ErrNotRecording        = NewError(KindConflict, "not_recording", "meeting is not being recorded")
+)
+
+func GenerateRecordingID() string {
+	return uuid.New().String()
+}
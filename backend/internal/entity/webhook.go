@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий, которые сервер отправляет подписчикам вебхуков
+const (
+	WebhookMeetingCreated    = "meeting.created"
+	WebhookMeetingEnded      = "meeting.ended"
+	WebhookParticipantJoined = "participant.joined"
+	WebhookParticipantLeft   = "participant.left"
+)
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryPending    = "pending"
+	WebhookDeliveryDelivered  = "delivered"
+	WebhookDeliveryDeadLetter = "dead"
+)
+
+// WebhookEvent - тело запроса к подписчику. Data - WebhookMeeting для событий встречи
+// и WebhookParticipant для событий участника
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	MeetingID string    `json:"meeting_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookMeeting - данные событий meeting.created и meeting.ended.
+// Reason и EndedBy заполняются только при завершении встречи
+type WebhookMeeting struct {
+	Name    string `json:"name"`
+	Code    string `json:"meeting_code"`
+	HostID  string `json:"host_id"`
+	Reason  string `json:"reason,omitempty"`
+	EndedBy string `json:"ended_by,omitempty"`
+}
+
+// WebhookParticipant - данные событий participant.joined и participant.left
+type WebhookParticipant struct {
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name,omitempty"`
+}
+
+// WebhookSubscription - адрес, куда доставляются события перечисленных типов.
+// Пустой Events - все события
+type WebhookSubscription struct {
+	URL    string
+	Secret string
+	Events []string
+}
+
+// Accepts - подписан ли адрес на события типа eventType
+func (s *WebhookSubscription) Accepts(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, event := range s.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery - доставка одного события одному подписчику. Пока попытки
+// не исчерпаны, она ждет в статусе pending, NextAttemptAt - время следующей попытки
+type WebhookDelivery struct {
+	ID             string     `json:"delivery_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	MeetingID      string     `json:"meeting_id"`
+	URL            string     `json:"url"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+}
+
+// WebhookDeadLetter - доставка, исчерпавшая попытки, вместе с событием,
+// чтобы ее можно было отправить повторно
+type WebhookDeadLetter struct {
+	Delivery WebhookDelivery `json:"delivery"`
+	Event    WebhookEvent    `json:"event"`
+}
+
+var (
+	ErrWebhookAdminDisabled = NewError(KindForbidden, "webhook_admin_disabled", "webhook administration is disabled")
+	ErrDeadLetterNotFound   = NewError(KindNotFound, "dead_letter_not_found", "dead letter not found")
+	// ErrWebhookSubscriptionGone - адрес недоставленного события убран из конфигурации
+	ErrWebhookSubscriptionGone = NewError(KindGone, "webhook_subscription_gone", "webhook subscription is no longer configured")
+)
+
+func GenerateWebhookEventID() string {
+	return uuid.New().String()
+}
+
+func GenerateWebhookDeliveryID() string {
+	return uuid.New().String()
+}
@@ -1,5 +1,27 @@
 package entity
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// Коды закрытия WebSocket: 1000-1015 - стандартные (RFC 6455), 4000-4999 - коды приложения
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseInternalError   = 1011
+	CloseSessionRejected = 4000
+	CloseSessionReplaced = 4001
+	CloseKicked          = 4002
+	CloseNotMember       = 4003
+	CloseMeetingNotFound = 4004
+	CloseMeetingEnded    = 4005
+	CloseSlowConsumer    = 4006
+	CloseLobbyAdmitted   = 4007
+	CloseLobbyDenied     = 4008
+	CloseLeft            = 4009
+)
+
 type WSMessage struct {
 	Type string      `json:"type"`
 	Data interface{} `json:"data,omitempty"`
@@ -7,16 +29,66 @@ type WSMessage struct {
 	To   string      `json:"to,omitempty"`
 }
 
+// SignalEnvelope - сообщение для доставки через брокер между репликами.
+// Пустой UserID означает рассылку всем участникам встречи.
+// Ненулевой CloseCode просит закрыть соединение пользователя (или всех участников)
+// вместо отправки сообщения. CloseSessionReplaced с Origin объявляет, что пользователь
+// подключился к реплике Origin, и остальные освобождают его место без user_left
+type SignalEnvelope struct {
+	MeetingID   string          `json:"meeting_id"`
+	UserID      string          `json:"user_id,omitempty"`
+	Message     json.RawMessage `json:"message,omitempty"`
+	CloseCode   int             `json:"close_code,omitempty"`
+	CloseReason string          `json:"close_reason,omitempty"`
+	Origin      string          `json:"origin,omitempty"`
+}
+
+// WSSession - параметры сигнальной сессии, согласованные при подключении
+type WSSession struct {
+	MeetingID       string
+	UserID          string
+	ProtocolVersion int
+	// ResumeToken - токен из hello прошлой сессии, если клиент переподключается
+	ResumeToken string
+}
+
 type JoinMeetingRequest struct {
+	// MeetingID - ID или код встречи, пустой для создания новой
 	MeetingID string `json:"meeting_id"`
 	UserName  string `json:"user_name"`
+	// UserID - ID, под которым пользователь уже входил во встречу. Вместе с ним
+	// нужен его токен, тогда новый участник не создается
+	UserID string `json:"user_id,omitempty"`
+	// Passcode задает код доступа новой встречи и обязателен для входа в защищенную.
+	// StartsAt, EndsAt, Mode, Lobby и MeetingCode учитываются только при создании новой встречи,
+	// MeetingCode - выбранный код вместо случайного
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	Mode     string     `json:"mode,omitempty"`
+	Lobby    bool       `json:"lobby,omitempty"`
+	Passcode string     `json:"passcode,omitempty"`
+
+	MeetingCode string `json:"meeting_code,omitempty"`
+	// ClientIP - адрес клиента для ограничения попыток подбора кода доступа
+	ClientIP string `json:"-"`
+	// AuthUserID - ID из проверенного токена запроса. С ним участник возвращается
+	// на свое место, а ведущий встречи, созданной через CreateMeeting, занимает свое
+	AuthUserID string `json:"-"`
 }
 
 type JoinMeetingResponse struct {
-	MeetingID      string   `json:"meeting_id"`
-	MeetingName    string   `json:"meeting_name"`
-	UserID         string   `json:"user_id"`
-	UsersInMeeting []string `json:"users_in_meeting"`
+	MeetingID      string    `json:"meeting_id"`
+	MeetingCode    string    `json:"meeting_code"`
+	MeetingName    string    `json:"meeting_name"`
+	Mode           string    `json:"mode"`
+	UserID         string    `json:"user_id"`
+	UsersInMeeting []string  `json:"users_in_meeting"`
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	// Pending - пользователь ждет в лобби, пока ведущий его не впустит
+	Pending bool `json:"pending"`
+	// Rejoined - пользователь вернулся под прежним ID
+	Rejoined bool `json:"rejoined"`
 }
 
 type LeaveMeetingRequest struct {
@@ -24,6 +96,51 @@ type LeaveMeetingRequest struct {
 	UserID    string `json:"user_id"`
 }
 
+type ModerationRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type LockMeetingRequest struct {
+	Locked bool `json:"locked"`
+}
+
+type LobbyRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+// CreateMeetingRequest - встреча, созданная заранее, без входа в нее
+type CreateMeetingRequest struct {
+	Name        string     `json:"meeting_name"`
+	Description string     `json:"description,omitempty"`
+	Capacity    int        `json:"capacity,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	Mode        string     `json:"mode,omitempty"`
+	Lobby       bool       `json:"lobby,omitempty"`
+	Passcode    string     `json:"passcode,omitempty"`
+	MeetingCode string     `json:"meeting_code,omitempty"`
+}
+
+// CreateMeetingResponse - Token выдан ведущему: с ним встречу можно изменить,
+// удалить и войти в нее ведущим
+type CreateMeetingResponse struct {
+	Meeting        *Meeting  `json:"meeting"`
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+}
+
+// UpdateMeetingRequest - меняются только переданные поля. Пустой Passcode снимает код доступа
+type UpdateMeetingRequest struct {
+	Name        *string    `json:"meeting_name,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Capacity    *int       `json:"capacity,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	Locked      *bool      `json:"locked,omitempty"`
+	Lobby       *bool      `json:"lobby,omitempty"`
+	Passcode    *string    `json:"passcode,omitempty"`
+}
+
 // WebRTC сигнальные сообщения
 type WebRTCOffer struct {
 	SDP string `json:"sdp"`
@@ -34,5 +151,8 @@ type WebRTCAnswer struct {
 }
 
 type ICECandidate struct {
-	Candidate string `json:"candidate"`
+	Candidate        string  `json:"candidate"`
+	SDPMid           *string `json:"sdpMid,omitempty"`
+	SDPMLineIndex    *uint16 `json:"sdpMLineIndex,omitempty"`
+	UsernameFragment *string `json:"usernameFragment,omitempty"`
 }
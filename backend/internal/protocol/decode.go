@@ -0,0 +1,141 @@
+package protocol
+
+import (
+	"encoding/json"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// Inbound - провалидированное сообщение от клиента.
+// Data хранит исходный JSON, чтобы пересылать его получателю без изменений
+type Inbound struct {
+	Type    MessageType
+	To      string
+	Data    json.RawMessage
+	Payload interface{}
+}
+
+type rawMessage struct {
+	Type MessageType     `json:"type"`
+	Data json.RawMessage `json:"data"`
+	To   string          `json:"to"`
+}
+
+// Decode - разбирает и валидирует сообщение клиента
+func Decode(data []byte) (*Inbound, *Error) {
+	var raw rawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, newError(ErrCodeMalformed, "", "message is not valid JSON")
+	}
+
+	if raw.Type == "" {
+		return nil, newError(ErrCodeMalformed, "", "type is required")
+	}
+
+	msg := &Inbound{Type: raw.Type, To: raw.To, Data: raw.Data}
+
+	switch raw.Type {
+	case TypeOffer:
+		var offer entity.WebRTCOffer
+		if err := decodePayload(raw, &offer); err != nil {
+			return nil, err
+		}
+		if offer.SDP == "" {
+			return nil, newError(ErrCodeInvalidPayload, raw.Type, "sdp is required")
+		}
+		msg.Payload = &offer
+	case TypeAnswer:
+		var answer entity.WebRTCAnswer
+		if err := decodePayload(raw, &answer); err != nil {
+			return nil, err
+		}
+		if answer.SDP == "" {
+			return nil, newError(ErrCodeInvalidPayload, raw.Type, "sdp is required")
+		}
+		msg.Payload = &answer
+	case TypeICECandidate:
+		candidate, err := decodeICECandidate(raw)
+		if err != nil {
+			return nil, err
+		}
+		msg.Payload = candidate
+	case TypeKick, TypeMuteRequest, TypeTransferHost, TypeLobbyAdmit, TypeLobbyDeny:
+		var target TargetPayload
+		if err := decodePayload(raw, &target); err != nil {
+			return nil, err
+		}
+		if target.UserID == "" {
+			return nil, newError(ErrCodeInvalidPayload, raw.Type, "user_id is required")
+		}
+		msg.Payload = &target
+	case TypeLockMeeting:
+		var lock LockPayload
+		if err := decodePayload(raw, &lock); err != nil {
+			return nil, err
+		}
+		msg.Payload = &lock
+	case TypeChatMessage:
+		var chat ChatPayload
+		if err := decodePayload(raw, &chat); err != nil {
+			return nil, err
+		}
+		if chat.Text == "" {
+			return nil, newError(ErrCodeInvalidPayload, raw.Type, "text is required")
+		}
+		msg.Payload = &chat
+	case TypeUserLeft:
+	default:
+		return nil, newError(ErrCodeUnknownType, raw.Type, "unknown message type")
+	}
+
+	if requiresRecipient(raw.Type) && raw.To == "" {
+		return nil, newError(ErrCodeMissingRecipient, raw.Type, "to is required")
+	}
+
+	return msg, nil
+}
+
+func requiresRecipient(t MessageType) bool {
+	return t == TypeOffer || t == TypeAnswer || t == TypeICECandidate
+}
+
+func decodePayload(raw rawMessage, payload interface{}) *Error {
+	if len(raw.Data) == 0 {
+		return newError(ErrCodeInvalidPayload, raw.Type, "data is required")
+	}
+
+	if err := json.Unmarshal(raw.Data, payload); err != nil {
+		return newError(ErrCodeInvalidPayload, raw.Type, "data does not match "+raw.Type+" schema")
+	}
+
+	return nil
+}
+
+// decodeICECandidate - кандидат допускается строкой, как в entity.ICECandidate,
+// или объектом RTCIceCandidateInit, который отдает браузер через candidate.toJSON()
+func decodeICECandidate(raw rawMessage) (*entity.ICECandidate, *Error) {
+	var envelope struct {
+		Candidate json.RawMessage `json:"candidate"`
+	}
+	if err := decodePayload(raw, &envelope); err != nil {
+		return nil, err
+	}
+
+	if len(envelope.Candidate) == 0 || string(envelope.Candidate) == "null" {
+		return nil, newError(ErrCodeInvalidPayload, raw.Type, "candidate is required")
+	}
+
+	var candidate entity.ICECandidate
+	if envelope.Candidate[0] == '"' {
+		if err := json.Unmarshal(envelope.Candidate, &candidate.Candidate); err != nil {
+			return nil, newError(ErrCodeInvalidPayload, raw.Type, "candidate must be a string or an object")
+		}
+		return &candidate, nil
+	}
+
+	if err := json.Unmarshal(envelope.Candidate, &candidate); err != nil {
+		return nil, newError(ErrCodeInvalidPayload, raw.Type, "candidate must be a string or an object")
+	}
+
+	return &candidate, nil
+}
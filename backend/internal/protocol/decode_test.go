@@ -0,0 +1,199 @@
+package protocol_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		code    protocol.ErrorCode
+		refType protocol.MessageType
+	}{
+		{name: "invalid json", data: `{"type":`, code: protocol.ErrCodeMalformed},
+		{name: "not an object", data: `["offer"]`, code: protocol.ErrCodeMalformed},
+		{name: "type is not a string", data: `{"type":1}`, code: protocol.ErrCodeMalformed},
+		{name: "missing type", data: `{"to":"bob","data":{"sdp":"v=0"}}`, code: protocol.ErrCodeMalformed},
+		{name: "unknown type", data: `{"type":"dance"}`, code: protocol.ErrCodeUnknownType, refType: "dance"},
+		{name: "server event from client", data: `{"type":"hello"}`, code: protocol.ErrCodeUnknownType, refType: protocol.TypeHello},
+
+		{name: "offer", data: `{"type":"offer","to":"bob","data":{"sdp":"v=0"}}`},
+		{name: "offer without data", data: `{"type":"offer","to":"bob"}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeOffer},
+		{name: "offer with empty sdp", data: `{"type":"offer","to":"bob","data":{"sdp":""}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeOffer},
+		{name: "offer with wrong schema", data: `{"type":"offer","to":"bob","data":{"sdp":42}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeOffer},
+		{name: "offer without recipient", data: `{"type":"offer","data":{"sdp":"v=0"}}`, code: protocol.ErrCodeMissingRecipient, refType: protocol.TypeOffer},
+		{name: "answer", data: `{"type":"answer","to":"sfu","data":{"sdp":"v=0"}}`},
+		{name: "answer with data as string", data: `{"type":"answer","to":"bob","data":"v=0"}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeAnswer},
+		{name: "answer without recipient", data: `{"type":"answer","data":{"sdp":"v=0"}}`, code: protocol.ErrCodeMissingRecipient, refType: protocol.TypeAnswer},
+
+		{name: "ice candidate as string", data: `{"type":"ice_candidate","to":"bob","data":{"candidate":"candidate:1"}}`},
+		{name: "ice candidate as object", data: `{"type":"ice_candidate","to":"bob","data":{"candidate":{"candidate":"candidate:1","sdpMid":"0"}}}`},
+		{name: "ice candidate null", data: `{"type":"ice_candidate","to":"bob","data":{"candidate":null}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeICECandidate},
+		{name: "ice candidate missing", data: `{"type":"ice_candidate","to":"bob","data":{}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeICECandidate},
+		{name: "ice candidate as number", data: `{"type":"ice_candidate","to":"bob","data":{"candidate":1}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeICECandidate},
+		{name: "ice candidate without recipient", data: `{"type":"ice_candidate","data":{"candidate":"candidate:1"}}`, code: protocol.ErrCodeMissingRecipient, refType: protocol.TypeICECandidate},
+
+		{name: "kick", data: `{"type":"kick","data":{"user_id":"bob"}}`},
+		{name: "kick without user", data: `{"type":"kick","data":{}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeKick},
+		{name: "lobby admit without data", data: `{"type":"lobby_admit"}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeLobbyAdmit},
+		{name: "lock meeting", data: `{"type":"lock_meeting","data":{"locked":true}}`},
+		{name: "lock meeting with wrong schema", data: `{"type":"lock_meeting","data":{"locked":"yes"}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeLockMeeting},
+		{name: "chat message", data: `{"type":"chat_message","data":{"text":"hi"}}`},
+		{name: "chat message without text", data: `{"type":"chat_message","data":{"text":""}}`, code: protocol.ErrCodeInvalidPayload, refType: protocol.TypeChatMessage},
+		{name: "user left without data", data: `{"type":"user_left"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := protocol.Decode([]byte(tt.data))
+
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("decoded %s as %+v, want %s", tt.data, msg, tt.code)
+			}
+			if err.Code != tt.code || err.RefType != tt.refType {
+				t.Fatalf("got error %s with ref type %q, want %s with ref type %q", err.Code, err.RefType, tt.code, tt.refType)
+			}
+		})
+	}
+}
+
+func TestDecodeICECandidateForms(t *testing.T) {
+	msg, err := protocol.Decode([]byte(`{"type":"ice_candidate","to":"bob","data":{"candidate":{"candidate":"candidate:1","sdpMid":"0","sdpMLineIndex":1}}}`))
+	if err != nil {
+		t.Fatalf("decode object candidate: %v", err)
+	}
+	candidate := msg.Payload.(*entity.ICECandidate)
+	if candidate.Candidate != "candidate:1" || candidate.SDPMid == nil || *candidate.SDPMid != "0" ||
+		candidate.SDPMLineIndex == nil || *candidate.SDPMLineIndex != 1 {
+		t.Fatalf("got candidate %+v, want candidate:1 with sdpMid 0 and sdpMLineIndex 1", candidate)
+	}
+
+	msg, err = protocol.Decode([]byte(`{"type":"ice_candidate","to":"bob","data":{"candidate":"candidate:2"}}`))
+	if err != nil {
+		t.Fatalf("decode string candidate: %v", err)
+	}
+	if candidate := msg.Payload.(*entity.ICECandidate); candidate.Candidate != "candidate:2" || candidate.SDPMid != nil {
+		t.Fatalf("got candidate %+v, want candidate:2 without sdpMid", candidate)
+	}
+}
+
+// Размер сообщения ограничивает соединение (ws.max_message_size), Decode
+// принимает большой SDP и пересылает data байт в байт
+func TestDecodeKeepsLargePayload(t *testing.T) {
+	data := `{"sdp":"v=0\r\n` + strings.Repeat(`a=candidate:1 1 udp 1 10.0.0.1 9 typ host\r\n`, 4096) + `","extra":{"kept":true}}`
+
+	msg, err := protocol.Decode([]byte(`{"type":"offer","to":"bob","data":` + data + `}`))
+	if err != nil {
+		t.Fatalf("decode large offer: %v", err)
+	}
+	if string(msg.Data) != data {
+		t.Fatal("data is not kept verbatim")
+	}
+	if offer := msg.Payload.(*entity.WebRTCOffer); !strings.HasPrefix(offer.SDP, "v=0\r\n") || len(offer.SDP) < 4096 {
+		t.Fatalf("got sdp of %d bytes, want the full sdp", len(offer.SDP))
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		requested string
+		version   int
+		wantErr   bool
+	}{
+		{requested: "", version: protocol.Version},
+		{requested: "1", version: 1},
+		{requested: "0", wantErr: true},
+		{requested: "-1", wantErr: true},
+		{requested: "2", wantErr: true},
+		{requested: "1.0", wantErr: true},
+		{requested: "v1", wantErr: true},
+		{requested: " 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			version, err := protocol.Negotiate(tt.requested)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("negotiated version %d, want error", version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v, want version %d", err, tt.version)
+			}
+			if version != tt.version {
+				t.Fatalf("got version %d, want %d", version, tt.version)
+			}
+		})
+	}
+}
+
+func TestNewErrorEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *protocol.Error
+		want string
+	}{
+		{
+			name: "with ref type",
+			err:  &protocol.Error{Code: protocol.ErrCodeMissingRecipient, Message: "to is required", RefType: protocol.TypeOffer},
+			want: `{"type":"error","data":{"code":"missing_recipient","message":"to is required","ref_type":"offer"}}`,
+		},
+		{
+			name: "without ref type",
+			err:  &protocol.Error{Code: protocol.ErrCodeMalformed, Message: "message is not valid JSON"},
+			want: `{"type":"error","data":{"code":"malformed_message","message":"message is not valid JSON"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(protocol.NewError(tt.err))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(encoded) != tt.want {
+				t.Fatalf("got %s, want %s", encoded, tt.want)
+			}
+		})
+	}
+}
+
+// Ошибка разбора доходит до клиента с кодом и типом исходного сообщения
+func TestDecodeErrorReply(t *testing.T) {
+	_, decodeErr := protocol.Decode([]byte(`{"type":"kick","data":{}}`))
+	if decodeErr == nil {
+		t.Fatal("kick without user_id decoded")
+	}
+
+	encoded, err := json.Marshal(protocol.NewError(decodeErr))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var reply struct {
+		Type string                `json:"type"`
+		Data protocol.ErrorPayload `json:"data"`
+	}
+	if err := json.Unmarshal(encoded, &reply); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if reply.Type != protocol.TypeError || reply.Data.Code != protocol.ErrCodeInvalidPayload ||
+		reply.Data.RefType != protocol.TypeKick || reply.Data.Message == "" {
+		t.Fatalf("got reply %+v, want invalid_payload for kick", reply)
+	}
+}
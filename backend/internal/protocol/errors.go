@@ -0,0 +1,28 @@
+package protocol
+
+type ErrorCode string
+
+const (
+	ErrCodeMalformed        ErrorCode = "malformed_message"
+	ErrCodeUnknownType      ErrorCode = "unknown_type"
+	ErrCodeInvalidPayload   ErrorCode = "invalid_payload"
+	ErrCodeMissingRecipient ErrorCode = "missing_recipient"
+	ErrCodeDeliveryFailed   ErrorCode = "delivery_failed"
+	ErrCodeForbidden        ErrorCode = "forbidden"
+	ErrCodeCommandFailed    ErrorCode = "command_failed"
+)
+
+// Error - ошибка разбора или обработки входящего сообщения
+type Error struct {
+	Code    ErrorCode
+	Message string
+	RefType MessageType
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+func newError(code ErrorCode, refType MessageType, message string) *Error {
+	return &Error{Code: code, Message: message, RefType: refType}
+}
@@ -0,0 +1,305 @@
+package protocol
+
+import (
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// HelloPayload - первое сообщение сервера после подключения
+// ResumeToken нужен, чтобы после обрыва связи вернуться в ту же сессию,
+// Resumed - сессия возобновлена и участники не видели выхода
+type HelloPayload struct {
+	ProtocolVersion int    `json:"protocol_version"`
+	MeetingID       string `json:"meeting_id"`
+	UserID          string `json:"user_id"`
+	ResumeToken     string `json:"resume_token"`
+	Resumed         bool   `json:"resumed"`
+}
+
+type UserJoinedPayload struct {
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name"`
+}
+
+type UserLeftPayload struct {
+	UserID string `json:"user_id"`
+}
+
+// TargetPayload - команда ведущего над участником (kick, mute_request, transfer_host)
+// или над пользователем в лобби (lobby_admit, lobby_deny)
+type TargetPayload struct {
+	UserID string `json:"user_id"`
+}
+
+type LockPayload struct {
+	Locked bool `json:"locked"`
+}
+
+// ModerationPayload - событие, вызванное действием ведущего
+type ModerationPayload struct {
+	UserID string `json:"user_id,omitempty"`
+	By     string `json:"by"`
+}
+
+type MeetingLockedPayload struct {
+	Locked bool   `json:"locked"`
+	By     string `json:"by"`
+}
+
+type HostChangedPayload struct {
+	HostID         string `json:"host_id"`
+	PreviousHostID string `json:"previous_host_id,omitempty"`
+}
+
+// MeetingUpdatedPayload - ведущий изменил настройки встречи, приходят все текущие значения
+type MeetingUpdatedPayload struct {
+	Name             string     `json:"meeting_name"`
+	Description      string     `json:"description"`
+	Capacity         int        `json:"capacity"`
+	Locked           bool       `json:"locked"`
+	Lobby            bool       `json:"lobby"`
+	PasscodeRequired bool       `json:"passcode_required"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	By               string     `json:"by"`
+}
+
+// MeetingEndedPayload - встреча завершена, следом сервер закрывает соединение.
+// By заполнен, если встречу завершил ведущий
+type MeetingEndedPayload struct {
+	Reason string `json:"reason"`
+	By     string `json:"by,omitempty"`
+}
+
+// ChatPayload - сообщение чата от клиента, поле to задает личное сообщение
+type ChatPayload struct {
+	Text string `json:"text"`
+}
+
+// ICECandidatePayload - кандидат в формате RTCIceCandidateInit
+type ICECandidatePayload struct {
+	Candidate *entity.ICECandidate `json:"candidate"`
+}
+
+// RecordingPayload - запись начата или остановлена. RecorderID заполнен, если клиенту
+// нужно самому отправить потоки рекордеру (встреча в режиме mesh)
+type RecordingPayload struct {
+	RecordingID string `json:"recording_id"`
+	RecorderID  string `json:"recorder_id,omitempty"`
+	By          string `json:"by,omitempty"`
+}
+
+// ErrorPayload - ответ отправителю на сообщение, которое сервер не смог обработать
+type ErrorPayload struct {
+	Code    ErrorCode   `json:"code"`
+	Message string      `json:"message"`
+	RefType MessageType `json:"ref_type,omitempty"`
+}
+
+func NewHello(version int, meetingID, userID, resumeToken string, resumed bool) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeHello,
+		Data: HelloPayload{
+			ProtocolVersion: version,
+			MeetingID:       meetingID,
+			UserID:          userID,
+			ResumeToken:     resumeToken,
+			Resumed:         resumed,
+		},
+	}
+}
+
+func NewOffer(from, to, sdp string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeOffer,
+		Data: entity.WebRTCOffer{SDP: sdp},
+		From: from,
+		To:   to,
+	}
+}
+
+func NewAnswer(from, to, sdp string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeAnswer,
+		Data: entity.WebRTCAnswer{SDP: sdp},
+		From: from,
+		To:   to,
+	}
+}
+
+func NewICECandidate(from, to string, candidate *entity.ICECandidate) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeICECandidate,
+		Data: ICECandidatePayload{Candidate: candidate},
+		From: from,
+		To:   to,
+	}
+}
+
+func NewUserJoined(userID, userName string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeUserJoined,
+		Data: UserJoinedPayload{UserID: userID, UserName: userName},
+		From: userID,
+	}
+}
+
+func NewUserLeft(userID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeUserLeft,
+		Data: UserLeftPayload{UserID: userID},
+		From: userID,
+	}
+}
+
+func NewKicked(hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeKicked,
+		Data: ModerationPayload{By: hostID},
+		From: hostID,
+	}
+}
+
+func NewUserKicked(userID, hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeUserKicked,
+		Data: ModerationPayload{UserID: userID, By: hostID},
+		From: hostID,
+	}
+}
+
+func NewMuteRequest(userID, hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeMuteRequest,
+		Data: ModerationPayload{UserID: userID, By: hostID},
+		From: hostID,
+		To:   userID,
+	}
+}
+
+func NewMeetingLocked(locked bool, hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeMeetingLocked,
+		Data: MeetingLockedPayload{Locked: locked, By: hostID},
+		From: hostID,
+	}
+}
+
+func NewHostChanged(hostID, previousHostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeHostChanged,
+		Data: HostChangedPayload{HostID: hostID, PreviousHostID: previousHostID},
+		From: previousHostID,
+	}
+}
+
+// NewLobbyRequest - пользователь ждет в лобби, отправляется ведущему
+func NewLobbyRequest(userID, userName string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeLobbyRequest,
+		Data: UserJoinedPayload{UserID: userID, UserName: userName},
+		From: userID,
+	}
+}
+
+// NewLobbyLeft - пользователь больше не ждет в лобби: ушел сам или ведущий решил,
+// впускать ли его. By заполнен, если решение принял ведущий
+func NewLobbyLeft(userID, hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeLobbyLeft,
+		Data: ModerationPayload{UserID: userID, By: hostID},
+		From: userID,
+	}
+}
+
+func NewLobbyAdmitted(hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeLobbyAdmitted,
+		Data: ModerationPayload{By: hostID},
+		From: hostID,
+	}
+}
+
+func NewLobbyDenied(hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeLobbyDenied,
+		Data: ModerationPayload{By: hostID},
+		From: hostID,
+	}
+}
+
+func NewMeetingUpdated(meeting *entity.Meeting, hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeMeetingUpdated,
+		Data: MeetingUpdatedPayload{
+			Name:             meeting.Name,
+			Description:      meeting.Description,
+			Capacity:         meeting.Capacity,
+			Locked:           meeting.Locked,
+			Lobby:            meeting.Lobby,
+			PasscodeRequired: meeting.PasscodeHash != "",
+			StartsAt:         meeting.StartsAt,
+			EndsAt:           meeting.EndsAt,
+			By:               hostID,
+		},
+		From: hostID,
+	}
+}
+
+func NewMeetingEnded(reason, hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeMeetingEnded,
+		Data: MeetingEndedPayload{Reason: reason, By: hostID},
+		From: hostID,
+	}
+}
+
+func NewChatMessage(message *entity.ChatMessage) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeChatMessage,
+		Data: message,
+		From: message.From,
+		To:   message.To,
+	}
+}
+
+func NewChatHistory(history *entity.ChatHistory) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeChatHistory,
+		Data: history,
+	}
+}
+
+func NewRecordingStarted(recording *entity.Recording) *entity.WSMessage {
+	payload := RecordingPayload{RecordingID: recording.ID, By: recording.StartedBy}
+	if recording.Mode == entity.MeetingModeMesh {
+		payload.RecorderID = RecorderPeerID
+	}
+
+	return &entity.WSMessage{
+		Type: TypeRecordingStarted,
+		Data: payload,
+		From: recording.StartedBy,
+	}
+}
+
+// NewRecordingStopped - пустой hostID означает, что запись остановил сервер, например в конце встречи
+func NewRecordingStopped(recording *entity.Recording, hostID string) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeRecordingStopped,
+		Data: RecordingPayload{RecordingID: recording.ID, By: hostID},
+		From: hostID,
+	}
+}
+
+func NewError(err *Error) *entity.WSMessage {
+	return &entity.WSMessage{
+		Type: TypeError,
+		Data: ErrorPayload{
+			Code:    err.Code,
+			Message: err.Message,
+			RefType: err.RefType,
+		},
+	}
+}
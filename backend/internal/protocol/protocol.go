@@ -0,0 +1,87 @@
+// Package protocol описывает сигнальный протокол WebSocket: типы сообщений,
+// их полезную нагрузку, версию протокола и валидацию входящих сообщений
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// Version - текущая версия протокола, сервер отдает ее в hello
+	Version = 1
+	// MinVersion - минимальная версия, которую сервер еще поддерживает
+	MinVersion = 1
+)
+
+// SFUPeerID - адрес медиасервера в полях to и from сигнальных сообщений.
+// Во встрече в режиме sfu клиент обменивается offer, answer и ice_candidate с ним
+const SFUPeerID = "sfu"
+
+// RecorderPeerID - скрытый участник, которому во встрече в режиме mesh
+// клиенты отправляют свои потоки, пока идет запись
+const RecorderPeerID = "recorder"
+
+type MessageType = string
+
+const (
+	TypeHello        MessageType = "hello"
+	TypeOffer        MessageType = "offer"
+	TypeAnswer       MessageType = "answer"
+	TypeICECandidate MessageType = "ice_candidate"
+	TypeUserJoined   MessageType = "user_joined"
+	TypeUserLeft     MessageType = "user_left"
+	TypeError        MessageType = "error"
+
+	// Команды модерации от ведущего
+	TypeKick         MessageType = "kick"
+	TypeMuteRequest  MessageType = "mute_request"
+	TypeLockMeeting  MessageType = "lock_meeting"
+	TypeTransferHost MessageType = "transfer_host"
+	TypeLobbyAdmit   MessageType = "lobby_admit"
+	TypeLobbyDeny    MessageType = "lobby_deny"
+
+	// События модерации от сервера
+	TypeKicked        MessageType = "kicked"
+	TypeUserKicked    MessageType = "user_kicked"
+	TypeMeetingLocked MessageType = "meeting_locked"
+	TypeHostChanged   MessageType = "host_changed"
+
+	// События лобби: lobby_request и lobby_left получает ведущий,
+	// lobby_admitted и lobby_denied - ожидающий пользователь
+	TypeLobbyRequest  MessageType = "lobby_request"
+	TypeLobbyLeft     MessageType = "lobby_left"
+	TypeLobbyAdmitted MessageType = "lobby_admitted"
+	TypeLobbyDenied   MessageType = "lobby_denied"
+
+	// События жизненного цикла встречи
+	TypeMeetingUpdated MessageType = "meeting_updated"
+	TypeMeetingEnded   MessageType = "meeting_ended"
+
+	// Чат: chat_message ходит в обе стороны, chat_history сервер шлет после hello
+	TypeChatMessage MessageType = "chat_message"
+	TypeChatHistory MessageType = "chat_history"
+
+	// События серверной записи
+	TypeRecordingStarted MessageType = "recording_started"
+	TypeRecordingStopped MessageType = "recording_stopped"
+)
+
+// Negotiate - выбирает версию протокола по запросу клиента.
+// Пустой запрос означает текущую версию
+func Negotiate(requested string) (int, error) {
+	if requested == "" {
+		return Version, nil
+	}
+
+	version, err := strconv.Atoi(requested)
+	if err != nil {
+		return 0, fmt.Errorf("invalid protocol version: %s", requested)
+	}
+
+	if version < MinVersion || version > Version {
+		return 0, fmt.Errorf("unsupported protocol version %d, supported %d-%d", version, MinVersion, Version)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// GetAttendance - итоги посещаемости по журналу встречи. Журнал хранится и после
+// завершения встречи, тогда открытых сессий в нем уже нет
+func (uc *meetingService) GetAttendance(ctx context.Context, meetingID string) (*entity.AttendanceReport, error) {
+	events, err := uc.meetingRepo.ListAttendance(ctx, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attendance: %w", err)
+	}
+
+	if len(events) == 0 {
+		meeting, err := uc.meetingRepo.GetMeeting(ctx, meetingID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get meeting: %w", err)
+		}
+		if meeting == nil {
+			return nil, entity.ErrMeetingNotFound
+		}
+	}
+
+	now := uc.clock.Now()
+
+	return &entity.AttendanceReport{
+		MeetingID:   meetingID,
+		GeneratedAt: now,
+		Attendees:   attendanceRecords(events, now),
+	}, nil
+}
+
+// attendanceRecords - сводит события по участникам в порядке их первого входа.
+// Открытая сессия учитывается до now
+func attendanceRecords(events []entity.AttendanceEvent, now time.Time) []entity.AttendanceRecord {
+	records := make([]entity.AttendanceRecord, 0)
+	index := make(map[string]int)
+	openedAt := make(map[string]time.Time)
+
+	for _, event := range events {
+		i, exists := index[event.UserID]
+		if !exists {
+			i = len(records)
+			index[event.UserID] = i
+			records = append(records, entity.AttendanceRecord{
+				UserID:        event.UserID,
+				FirstJoinedAt: event.At,
+			})
+		}
+
+		record := &records[i]
+		record.UserName = event.UserName
+
+		switch event.Type {
+		case entity.AttendanceJoined, entity.AttendanceReconnected:
+			if event.Type == entity.AttendanceReconnected {
+				record.Reconnects++
+			}
+			openedAt[event.UserID] = event.At
+			record.Online = true
+		case entity.AttendanceLeft:
+			if start, ok := openedAt[event.UserID]; ok {
+				record.TotalSeconds += int64(event.At.Sub(start).Seconds())
+				delete(openedAt, event.UserID)
+			}
+			leftAt := event.At
+			record.LastLeftAt = &leftAt
+			record.Online = false
+		}
+	}
+
+	for userID, start := range openedAt {
+		records[index[userID]].TotalSeconds += int64(now.Sub(start).Seconds())
+	}
+
+	return records
+}
+
+// attendeeName - имя из журнала посещаемости: вышедшего через REST, исключенного
+// участника или участника завершенной встречи в самой встрече уже нет
+func attendeeName(ctx context.Context, meetingRepo MeetingRepo, meetingID, userID string) string {
+	events, err := meetingRepo.ListAttendance(ctx, meetingID)
+	if err != nil {
+		return ""
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].UserID == userID {
+			return events[i].UserName
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const _issuer = "zvonim"
+
+type claims struct {
+	MeetingID string `json:"mid"`
+	jwt.RegisteredClaims
+}
+
+// JWTManager - выпускает и проверяет HMAC-подписанные JWT участников встреч
+type JWTManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewJWTManager(secret string, ttl time.Duration) *JWTManager {
+	return &JWTManager{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+func (m *JWTManager) Issue(meetingID, userID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		MeetingID: meetingID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    _issuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+
+	signed, err := token.SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+func (m *JWTManager) Parse(token string) (*entity.TokenClaims, error) {
+	parsed := &claims{}
+
+	_, err := jwt.ParseWithClaims(token, parsed,
+		func(*jwt.Token) (interface{}, error) {
+			return m.secret, nil
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(_issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, entity.ErrTokenExpired
+		}
+		return nil, entity.ErrInvalidToken
+	}
+
+	if parsed.MeetingID == "" || parsed.Subject == "" {
+		return nil, entity.ErrInvalidToken
+	}
+
+	return &entity.TokenClaims{
+		MeetingID: parsed.MeetingID,
+		UserID:    parsed.Subject,
+		ExpiresAt: parsed.ExpiresAt.Time,
+	}, nil
+}
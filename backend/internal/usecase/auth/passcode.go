@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher - хеширует коды доступа встреч bcrypt'ом
+type BcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{
+		cost: cost,
+	}
+}
+
+func (h *BcryptHasher) Hash(passcode string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(passcode), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash passcode: %w", err)
+	}
+
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Compare(hash, passcode string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(passcode)) == nil
+}
@@ -0,0 +1,64 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// ErrNoSubscribers - сообщение не получила ни одна реплика
+var ErrNoSubscribers = errors.New("no subscribers received the message")
+
+// MemoryBroker - брокер в пределах одного процесса, для запуска с одной репликой
+type MemoryBroker struct {
+	handlers map[int]func(*entity.SignalEnvelope)
+	nextID   int
+	mu       sync.RWMutex
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		handlers: make(map[int]func(*entity.SignalEnvelope)),
+	}
+}
+
+// Publish - обработчики вызываются без блокировки, поэтому могут сами подписываться
+// и отписываться
+func (b *MemoryBroker) Publish(ctx context.Context, envelope *entity.SignalEnvelope) error {
+	b.mu.RLock()
+	handlers := make([]func(*entity.SignalEnvelope), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		return ErrNoSubscribers
+	}
+
+	for _, handler := range handlers {
+		handler(envelope)
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, handler func(*entity.SignalEnvelope)) error {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		delete(b.handlers, id)
+		b.mu.Unlock()
+	}()
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+func TestMemoryBrokerNoSubscribers(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	if err := broker.Publish(context.Background(), &entity.SignalEnvelope{MeetingID: "meeting"}); !errors.Is(err, ErrNoSubscribers) {
+		t.Fatalf("got publish error %v without subscribers, want %v", err, ErrNoSubscribers)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan *entity.SignalEnvelope, 1)
+	if err := broker.Subscribe(ctx, func(envelope *entity.SignalEnvelope) { received <- envelope }); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	if err := broker.Publish(context.Background(), &entity.SignalEnvelope{MeetingID: "meeting"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	waitEnvelope(t, received)
+
+	// Подписка снимается асинхронно после отмены
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := broker.Publish(context.Background(), &entity.SignalEnvelope{MeetingID: "meeting"})
+		if errors.Is(err, ErrNoSubscribers) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got publish error %v after cancel, want %v", err, ErrNoSubscribers)
+		}
+		<-received
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMemoryBrokerHandlerSubscribes(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Обработчик подписывает нового слушателя прямо во время рассылки
+	received := make(chan *entity.SignalEnvelope, 1)
+	subscribed := make(chan error, 1)
+	if err := broker.Subscribe(ctx, func(envelope *entity.SignalEnvelope) {
+		select {
+		case subscribed <- broker.Subscribe(ctx, func(envelope *entity.SignalEnvelope) { received <- envelope }):
+		default:
+		}
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	published := make(chan error, 1)
+	go func() {
+		published <- broker.Publish(context.Background(), &entity.SignalEnvelope{MeetingID: "first"})
+	}()
+
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("publish deadlocked on a handler that subscribes")
+	}
+	if err := <-subscribed; err != nil {
+		t.Fatalf("subscribe from handler: %v", err)
+	}
+
+	// Новый слушатель получает следующие сообщения
+	if err := broker.Publish(context.Background(), &entity.SignalEnvelope{MeetingID: "second"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if got := waitEnvelope(t, received); got.MeetingID != "second" {
+		t.Fatalf("got envelope %+v, want the second one", got)
+	}
+}
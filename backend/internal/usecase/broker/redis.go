@@ -0,0 +1,76 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/redis"
+)
+
+// RedisBroker - брокер на Redis pub/sub, все реплики слушают общий канал
+// и доставляют сообщение только своим локальным соединениям
+type RedisBroker struct {
+	redis   *redis.Redis
+	channel string
+}
+
+func NewRedisBroker(rdb *redis.Redis, channel string) *RedisBroker {
+	return &RedisBroker{
+		redis:   rdb,
+		channel: channel,
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, envelope *entity.SignalEnvelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal envelope: %w", err)
+	}
+
+	receivers, err := b.redis.Client.Publish(ctx, b.channel, data).Result()
+	if err != nil {
+		return fmt.Errorf("failed to publish envelope: %w", err)
+	}
+	if receivers == 0 {
+		return ErrNoSubscribers
+	}
+
+	return nil
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, handler func(*entity.SignalEnvelope)) error {
+	pubsub := b.redis.Client.Subscribe(ctx, b.channel)
+
+	// Дожидаемся подтверждения подписки, чтобы не терять первые сообщения
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to %s: %w", b.channel, err)
+	}
+
+	go func() {
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var envelope entity.SignalEnvelope
+				if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+					continue
+				}
+
+				handler(&envelope)
+			}
+		}
+	}()
+
+	return nil
+}
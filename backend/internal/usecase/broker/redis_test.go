@@ -0,0 +1,138 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+)
+
+const _testChannel = "zvonim:signaling"
+
+func TestRedisBrokerPublishSubscribe(t *testing.T) {
+	server := miniredis.RunT(t)
+	broker := newTestRedisBroker(t, server)
+
+	received := subscribe(t, broker)
+
+	sent := &entity.SignalEnvelope{
+		MeetingID: "meeting",
+		UserID:    "user",
+		Message:   json.RawMessage(`{"type":"offer"}`),
+	}
+	if err := broker.Publish(context.Background(), sent); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	got := waitEnvelope(t, received)
+	if got.MeetingID != sent.MeetingID || got.UserID != sent.UserID || string(got.Message) != string(sent.Message) {
+		t.Fatalf("got envelope %+v, want %+v", got, sent)
+	}
+}
+
+func TestRedisBrokerFanOutAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	first := newTestRedisBroker(t, server)
+	second := newTestRedisBroker(t, server)
+
+	firstReceived := subscribe(t, first)
+	secondReceived := subscribe(t, second)
+
+	sent := &entity.SignalEnvelope{
+		MeetingID:   "meeting",
+		UserID:      "user",
+		CloseCode:   4001,
+		CloseReason: "kicked",
+	}
+	if err := first.Publish(context.Background(), sent); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// Сообщение получают все реплики, включая отправителя
+	for name, received := range map[string]<-chan *entity.SignalEnvelope{"first": firstReceived, "second": secondReceived} {
+		got := waitEnvelope(t, received)
+		if got.MeetingID != sent.MeetingID || got.CloseCode != sent.CloseCode || got.CloseReason != sent.CloseReason {
+			t.Fatalf("%s instance got envelope %+v, want %+v", name, got, sent)
+		}
+	}
+}
+
+func TestRedisBrokerStopsOnContextCancel(t *testing.T) {
+	server := miniredis.RunT(t)
+	broker := newTestRedisBroker(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan *entity.SignalEnvelope, 1)
+	if err := broker.Subscribe(ctx, func(envelope *entity.SignalEnvelope) {
+		received <- envelope
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	cancel()
+
+	// Подписка снимается асинхронно, ждем, пока у канала не останется слушателей
+	deadline := time.Now().Add(time.Second)
+	for server.PubSubNumSub(_testChannel)[_testChannel] > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription is still active after cancel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Без слушателей PUBLISH никому не доставил сообщение
+	if err := broker.Publish(context.Background(), &entity.SignalEnvelope{MeetingID: "meeting"}); !errors.Is(err, ErrNoSubscribers) {
+		t.Fatalf("got publish error %v, want %v", err, ErrNoSubscribers)
+	}
+
+	select {
+	case envelope := <-received:
+		t.Fatalf("got envelope %+v after cancel", envelope)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func newTestRedisBroker(t *testing.T, server *miniredis.Miniredis) *RedisBroker {
+	t.Helper()
+
+	rdb, err := redis.New(server.Addr(), redis.ConnAttempts(1))
+	if err != nil {
+		t.Fatalf("connect redis: %v", err)
+	}
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	return NewRedisBroker(rdb, _testChannel)
+}
+
+func subscribe(t *testing.T, broker *RedisBroker) <-chan *entity.SignalEnvelope {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	received := make(chan *entity.SignalEnvelope, 1)
+	if err := broker.Subscribe(ctx, func(envelope *entity.SignalEnvelope) {
+		received <- envelope
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	return received
+}
+
+func waitEnvelope(t *testing.T, received <-chan *entity.SignalEnvelope) *entity.SignalEnvelope {
+	t.Helper()
+
+	select {
+	case envelope := <-received:
+		return envelope
+	case <-time.After(time.Second):
+		t.Fatal("envelope was not delivered")
+		return nil
+	}
+}
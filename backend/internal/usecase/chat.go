@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+const _maxChatPage = 100
+
+type chatService struct {
+	meetingRepo MeetingRepo
+	chatRepo    ChatRepo
+	events      EventBus
+	clock       Clock
+	historySize int
+	maxLength   int
+}
+
+func NewChatService(meetingRepo MeetingRepo, chatRepo ChatRepo, events EventBus, clock Clock, historySize, maxLength int) *chatService {
+	return &chatService{
+		meetingRepo: meetingRepo,
+		chatRepo:    chatRepo,
+		events:      events,
+		clock:       clock,
+		historySize: historySize,
+		maxLength:   maxLength,
+	}
+}
+
+var _ ChatUseCase = (*chatService)(nil)
+
+// SendMessage - сохраняет сообщение, участникам его рассылает подписчик ChatMessageSentEvent
+func (uc *chatService) SendMessage(ctx context.Context, meetingID, fromID, toID, text string) (*entity.ChatMessage, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, &entity.ValidationError{Field: "text", Reason: "is required"}
+	}
+	if utf8.RuneCountInString(text) > uc.maxLength {
+		return nil, &entity.ValidationError{Field: "text", Reason: fmt.Sprintf("must be at most %d characters", uc.maxLength)}
+	}
+
+	meeting, err := uc.meetingRepo.GetMeeting(ctx, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meeting: %w", err)
+	}
+	if meeting == nil {
+		return nil, entity.ErrMeetingNotFound
+	}
+
+	var sender *entity.User
+	for i := range meeting.Users {
+		if meeting.Users[i].ID == fromID {
+			sender = &meeting.Users[i]
+			break
+		}
+	}
+
+	if sender == nil {
+		return nil, entity.ErrNotMember
+	}
+	if toID != "" && !hasUser(meeting, toID) {
+		return nil, entity.ErrUserNotFound
+	}
+
+	message := &entity.ChatMessage{
+		ID:        entity.GenerateMessageID(),
+		MeetingID: meetingID,
+		From:      fromID,
+		FromName:  sender.Name,
+		To:        toID,
+		Text:      text,
+		SentAt:    uc.clock.Now(),
+	}
+
+	if err := uc.chatRepo.SaveMessage(ctx, message); err != nil {
+		return nil, fmt.Errorf("failed to save chat message: %w", err)
+	}
+
+	uc.events.Publish(ctx, &entity.ChatMessageSentEvent{
+		EventMeta: entity.EventMeta{MeetingID: meetingID, At: message.SentAt},
+		Message:   message,
+	})
+
+	return message, nil
+}
+
+func (uc *chatService) GetHistory(ctx context.Context, meetingID, viewerID, before string, limit int) (*entity.ChatHistory, error) {
+	switch {
+	case limit <= 0:
+		limit = uc.historySize
+	case limit > _maxChatPage:
+		limit = _maxChatPage
+	}
+
+	// Лишнее сообщение показывает, есть ли страница старше этой
+	messages, err := uc.chatRepo.ListMessages(ctx, meetingID, viewerID, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chat messages: %w", err)
+	}
+
+	history := &entity.ChatHistory{Messages: messages}
+	if len(messages) > limit {
+		history.Messages = messages[1:]
+		history.NextBefore = history.Messages[0].ID
+	}
+
+	return history, nil
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+func TestChatHistoryPagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages int
+		limit    int
+		// wantPages - размеры страниц от новых к старым
+		wantPages []int
+	}{
+		{name: "single short page", messages: 3, limit: 5, wantPages: []int{3}},
+		{name: "single full page", messages: 5, limit: 5, wantPages: []int{5}},
+		{name: "last page is full", messages: 4, limit: 2, wantPages: []int{2, 2}},
+		{name: "last page is short", messages: 5, limit: 2, wantPages: []int{2, 2, 1}},
+		{name: "empty chat", messages: 0, limit: 2, wantPages: []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fakeClock := clock.NewFake(time.Now())
+			meetingRepo := repo.NewMemoryMeetingRepository()
+			chatUC := NewChatService(meetingRepo, repo.NewMemoryChatRepository(100), NewEventBus(), fakeClock, 50, 1000)
+
+			meeting := &entity.Meeting{}
+			createTestMeeting(t, meetingRepo, meeting, fakeClock.Now(), []string{"alice"})
+
+			for i := 0; i < tt.messages; i++ {
+				if _, err := chatUC.SendMessage(ctx, meeting.ID, "alice", "", fmt.Sprintf("message %d", i)); err != nil {
+					t.Fatalf("send message: %v", err)
+				}
+			}
+
+			before := ""
+			next := tt.messages - 1
+			for page, wantSize := range tt.wantPages {
+				history, err := chatUC.GetHistory(ctx, meeting.ID, "alice", before, tt.limit)
+				if err != nil {
+					t.Fatalf("get history: %v", err)
+				}
+				if len(history.Messages) != wantSize {
+					t.Fatalf("page %d: got %d messages, want %d", page, len(history.Messages), wantSize)
+				}
+
+				// Страница идет от старых к новым и продолжает предыдущую
+				for i := len(history.Messages) - 1; i >= 0; i-- {
+					if want := fmt.Sprintf("message %d", next); history.Messages[i].Text != want {
+						t.Fatalf("page %d: got %q, want %q", page, history.Messages[i].Text, want)
+					}
+					next--
+				}
+
+				last := page == len(tt.wantPages)-1
+				if last != (history.NextBefore == "") {
+					t.Fatalf("page %d: got cursor %q, want it only before the oldest page", page, history.NextBefore)
+				}
+				before = history.NextBefore
+			}
+		})
+	}
+}
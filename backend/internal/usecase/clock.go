@@ -0,0 +1,26 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+type systemClock struct{}
+
+// SystemClock - часы на основе пакета time
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) clock.Ticker {
+	return clock.NewTicker(d)
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) clock.Timer {
+	return clock.AfterFunc(d, f)
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// eventBus - шина доменных событий в пределах процесса. Между репликами события
+// не передаются: подписчики сами рассылают то, что нужно другим репликам, через брокер
+type eventBus struct {
+	handlers map[int]func(context.Context, entity.DomainEvent)
+	nextID   int
+	mu       sync.RWMutex
+}
+
+func NewEventBus() EventBus {
+	return &eventBus{
+		handlers: make(map[int]func(context.Context, entity.DomainEvent)),
+	}
+}
+
+// Publish - обработчики вызываются без блокировки шины, поэтому могут сами публиковать события
+func (b *eventBus) Publish(ctx context.Context, event entity.DomainEvent) {
+	b.mu.RLock()
+	handlers := make([]func(context.Context, entity.DomainEvent), 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
+
+func (b *eventBus) Subscribe(ctx context.Context, handler func(context.Context, entity.DomainEvent)) {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		delete(b.handlers, id)
+		b.mu.Unlock()
+	}()
+}
+
+// eventMeta - встреча события и текущее время
+func eventMeta(clock Clock, meetingID string) entity.EventMeta {
+	return entity.EventMeta{MeetingID: meetingID, At: clock.Now()}
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// eventRecorder - запоминает доменные события, опубликованные в шину
+type eventRecorder struct {
+	events []entity.DomainEvent
+	mu     sync.Mutex
+}
+
+func recordEvents(t *testing.T, bus EventBus) *eventRecorder {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	recorder := &eventRecorder{}
+	bus.Subscribe(ctx, func(ctx context.Context, event entity.DomainEvent) {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+
+		recorder.events = append(recorder.events, event)
+	})
+	return recorder
+}
+
+func (r *eventRecorder) all() []entity.DomainEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]entity.DomainEvent(nil), r.events...)
+}
+
+// createTestMeeting - встреча с участниками, онлайн открывают сессию в момент at
+func createTestMeeting(t *testing.T, meetingRepo MeetingRepo, meeting *entity.Meeting, at time.Time, online []string, offline ...string) {
+	t.Helper()
+
+	ctx := context.Background()
+	if meeting.ID == "" {
+		meeting.ID = entity.GenerateMeetingID()
+	}
+	if meeting.Code == "" {
+		meeting.Code = entity.GenerateMeetingCode()
+	}
+	meeting.CreatedAt = at
+
+	if err := meetingRepo.CreateMeeting(ctx, meeting); err != nil {
+		t.Fatalf("create meeting: %v", err)
+	}
+
+	for _, userID := range append(append([]string(nil), online...), offline...) {
+		if err := meetingRepo.AddUserToMeeting(ctx, meeting.ID, &entity.User{ID: userID, Name: userID, JoinedAt: at}); err != nil {
+			t.Fatalf("add user: %v", err)
+		}
+	}
+	for _, userID := range online {
+		if err := meetingRepo.StartUserSession(ctx, meeting.ID, userID, at); err != nil {
+			t.Fatalf("start session: %v", err)
+		}
+	}
+}
+
+type stubRecordingUC struct {
+	RecordingUseCase
+}
+
+func (stubRecordingUC) FinishRecording(ctx context.Context, meetingID string) error {
+	return nil
+}
+
+func (stubRecordingUC) ActiveRecording(meetingID string) *entity.Recording {
+	return nil
+}
+
+func (stubRecordingUC) ParticipantLeft(meetingID, userID string) {}
+
+type stubMeetingUC struct {
+	MeetingUseCase
+}
+
+func (stubMeetingUC) GetLobby(ctx context.Context, meetingID, hostID string) ([]entity.User, error) {
+	return nil, entity.ErrNotHost
+}
+
+type stubChatUC struct {
+	ChatUseCase
+}
+
+func (stubChatUC) GetHistory(ctx context.Context, meetingID, viewerID, before string, limit int) (*entity.ChatHistory, error) {
+	return &entity.ChatHistory{}, nil
+}
+
+var errFakeConnClosed = errors.New("connection closed")
+
+// fakeConn - соединение клиента в памяти. Все, что сервер пишет клиенту, попадает
+// в written, а входящие сообщения клиент отправляет через send
+type fakeConn struct {
+	incoming  chan []byte
+	written   chan entity.WSMessage
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeCode atomic.Int32
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{
+		incoming: make(chan []byte),
+		written:  make(chan entity.WSMessage, 64),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (c *fakeConn) ReadMessage() ([]byte, error) {
+	select {
+	case message := <-c.incoming:
+		return message, nil
+	case <-c.closed:
+		return nil, errFakeConnClosed
+	}
+}
+
+func (c *fakeConn) WriteMessage(messageType int, data []byte) error {
+	var message entity.WSMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return err
+	}
+
+	select {
+	case <-c.closed:
+		return errFakeConnClosed
+	case c.written <- message:
+		return nil
+	}
+}
+
+func (c *fakeConn) CloseWithReason(code int, reason string) error {
+	c.closeCode.CompareAndSwap(0, int32(code))
+	return c.Close()
+}
+
+func (c *fakeConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+// waitMessage - ждет сообщение типа messageType от from, пропуская остальные
+func (c *fakeConn) waitMessage(t *testing.T, messageType, from string) entity.WSMessage {
+	t.Helper()
+
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case message := <-c.written:
+			if message.Type == messageType && (from == "" || message.From == from) {
+				return message
+			}
+		case <-deadline:
+			t.Fatalf("no %s message from %q", messageType, from)
+		}
+	}
+}
+
+// noMessage - проверяет, что за время wait не пришло сообщение типа messageType
+func (c *fakeConn) noMessage(t *testing.T, messageType string, wait time.Duration) {
+	t.Helper()
+
+	deadline := time.After(wait)
+	for {
+		select {
+		case message := <-c.written:
+			if message.Type == messageType {
+				t.Fatalf("got unexpected %s message %+v", messageType, message)
+			}
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// waitClosed - ждет, пока сервер закроет соединение, и возвращает код закрытия
+func (c *fakeConn) waitClosed(t *testing.T) int {
+	t.Helper()
+
+	select {
+	case <-c.closed:
+		return int(c.closeCode.Load())
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection was not closed")
+		return 0
+	}
+}
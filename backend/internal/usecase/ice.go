@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// ICEConfig - какие ICE серверы получают клиенты
+type ICEConfig struct {
+	// STUNServers - внешние STUN серверы
+	STUNServers []string
+	// TURNHost и TURNPort - адрес встроенного TURN сервера для клиентов
+	TURNHost string
+	TURNPort int
+}
+
+type iceService struct {
+	credentials TURNCredentials
+	cfg         ICEConfig
+}
+
+// NewICEService - credentials может быть nil, если встроенный TURN сервер выключен
+func NewICEService(credentials TURNCredentials, cfg ICEConfig) *iceService {
+	return &iceService{
+		credentials: credentials,
+		cfg:         cfg,
+	}
+}
+
+var _ ICEUseCase = (*iceService)(nil)
+
+func (uc *iceService) GetICEServers(ctx context.Context, meetingID, userID string) (*entity.ICEServers, error) {
+	servers := &entity.ICEServers{ICEServers: []entity.ICEServer{}}
+
+	if len(uc.cfg.STUNServers) > 0 {
+		servers.ICEServers = append(servers.ICEServers, entity.ICEServer{URLs: uc.cfg.STUNServers})
+	}
+
+	if uc.credentials == nil {
+		return servers, nil
+	}
+
+	credentials, err := uc.credentials.Issue(meetingID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue turn credentials: %w", err)
+	}
+
+	address := net.JoinHostPort(uc.cfg.TURNHost, strconv.Itoa(uc.cfg.TURNPort))
+	servers.ICEServers = append(servers.ICEServers,
+		entity.ICEServer{URLs: []string{"stun:" + address}},
+		entity.ICEServer{
+			URLs: []string{
+				"turn:" + address + "?transport=udp",
+				"turn:" + address + "?transport=tcp",
+			},
+			Username:   credentials.Username,
+			Credential: credentials.Password,
+		},
+	)
+	servers.ExpiresAt = &credentials.ExpiresAt
+
+	return servers, nil
+}
@@ -2,38 +2,279 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
 )
 
 type (
 	// MeetingUseCase - управление встречами и пользователями
 	MeetingUseCase interface {
 		JoinMeeting(ctx context.Context, req *entity.JoinMeetingRequest) (*entity.JoinMeetingResponse, error)
+		// CreateMeeting - создает встречу без входа в нее, ведущий получает токен
+		CreateMeeting(ctx context.Context, req *entity.CreateMeetingRequest) (*entity.CreateMeetingResponse, error)
+		UpdateMeeting(ctx context.Context, meetingID, hostID string, req *entity.UpdateMeetingRequest) (*entity.Meeting, error)
+		DeleteMeeting(ctx context.Context, meetingID, hostID string) error
 		GetMeetingInfo(ctx context.Context, meetingID string) (*entity.Meeting, error)
 		LeaveMeeting(ctx context.Context, req *entity.LeaveMeetingRequest) error
 		GetOnlineUsers(ctx context.Context, meetingID string) ([]string, error)
+		Authenticate(ctx context.Context, token, meetingID, userID string) (*entity.TokenClaims, error)
+		CheckMembership(ctx context.Context, meetingID, userID string) error
+		// ResolveMeetingID - ID встречи по ID или коду
+		ResolveMeetingID(ctx context.Context, meetingRef string) (string, error)
+		// GetAttendance - отчет о посещаемости, доступен и после завершения встречи
+		GetAttendance(ctx context.Context, meetingID string) (*entity.AttendanceReport, error)
+
+		KickUser(ctx context.Context, meetingID, hostID, targetID string) error
+		RequestMute(ctx context.Context, meetingID, hostID, targetID string) error
+		SetMeetingLocked(ctx context.Context, meetingID, hostID string, locked bool) error
+		TransferHost(ctx context.Context, meetingID, hostID, targetID string) error
+
+		// Лобби: пользователь, который ждет решения ведущего, получает токен,
+		// но CheckMembership для него возвращает entity.ErrNotAdmitted
+		AdmitUser(ctx context.Context, meetingID, hostID, userID string) error
+		DenyUser(ctx context.Context, meetingID, hostID, userID string) error
+		SetLobbyEnabled(ctx context.Context, meetingID, hostID string, enabled bool) error
+		GetLobby(ctx context.Context, meetingID, hostID string) ([]entity.User, error)
+
+		EndMeeting(ctx context.Context, meetingID, hostID string) error
+		// ExpireMeetings - завершает встречи, у которых прошло время окончания
+		// или которые пустуют дольше idle TTL, и удаляет журналы посещаемости старше
+		// attendance TTL. Возвращает число завершенных встреч
+		ExpireMeetings(ctx context.Context) (int, error)
+	}
+
+	// ChatUseCase - текстовый чат встречи
+	ChatUseCase interface {
+		SendMessage(ctx context.Context, meetingID, fromID, toID, text string) (*entity.ChatMessage, error)
+		// GetHistory - страница истории, видимой участнику. Пустой before - самые новые сообщения
+		GetHistory(ctx context.Context, meetingID, viewerID, before string, limit int) (*entity.ChatHistory, error)
+	}
+
+	// RecordingUseCase - серверная запись встреч. Во встрече в режиме mesh участники
+	// отправляют потоки скрытому рекордеру, сигнальные сообщения для него идут через
+	// HandleOffer и AddICECandidate
+	RecordingUseCase interface {
+		StartRecording(ctx context.Context, meetingID, hostID string) (*entity.Recording, error)
+		StopRecording(ctx context.Context, meetingID, hostID string) (*entity.Recording, error)
+		// FinishRecording - останавливает запись завершающейся встречи, если она идет
+		FinishRecording(ctx context.Context, meetingID string) error
+		// ActiveRecording - идущая запись встречи или nil
+		ActiveRecording(meetingID string) *entity.Recording
+		ListRecordings(ctx context.Context, meetingID string) ([]entity.Recording, error)
+
+		HandleOffer(ctx context.Context, meetingID, userID, sdp string) error
+		AddICECandidate(ctx context.Context, meetingID, userID string, candidate *entity.ICECandidate) error
+		// ParticipantLeft - закрывает соединение ушедшего участника с рекордером
+		ParticipantLeft(meetingID, userID string)
+	}
+
+	// ICEUseCase - STUN/TURN серверы для RTCPeerConnection участника
+	ICEUseCase interface {
+		GetICEServers(ctx context.Context, meetingID, userID string) (*entity.ICEServers, error)
+	}
+
+	// MediaSessionUseCase - WHIP и WHEP сессии встреч в режиме sfu. Ingest подключает
+	// внешний кодировщик как нового участника, Watch отдает плееру поток участника
+	MediaSessionUseCase interface {
+		Ingest(ctx context.Context, meetingID, ownerID, name, offer string) (*entity.MediaSession, error)
+		Watch(ctx context.Context, meetingID, ownerID, publisherID, offer string) (*entity.MediaSession, error)
+		AddICECandidates(ctx context.Context, meetingID, sessionID, ownerID string, candidates []entity.ICECandidate) error
+		CloseSession(ctx context.Context, meetingID, sessionID, ownerID string) error
+	}
+
+	// WebhookUseCase - журнал доставок вебхуков и очередь недоставленных. События
+	// для отправки сервис получает из EventBus
+	WebhookUseCase interface {
+		// ListDeliveries - последние доставки от новых к старым, пустой status - любые
+		ListDeliveries(ctx context.Context, status string, limit int) ([]entity.WebhookDelivery, error)
+		ListDeadLetters(ctx context.Context) ([]entity.WebhookDeadLetter, error)
+		// RetryDeadLetter - заново ставит недоставленное событие в очередь с новым счетчиком попыток
+		RetryDeadLetter(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error)
 	}
 
 	// WebSocketUseCase - управление WebSocket соединениями и сообщениями
 	WebSocketUseCase interface {
-		HandleConnection(ctx context.Context, conn WSConnection, meetingID, userID string)
+		HandleConnection(ctx context.Context, conn WSConnection, session *entity.WSSession)
+		// HandleLobbyConnection - соединение пользователя из лобби, по нему приходит
+		// только решение ведущего, входящие сообщения игнорируются
+		HandleLobbyConnection(ctx context.Context, conn WSConnection, session *entity.WSSession)
 		BroadcastToMeeting(meetingID string, message *entity.WSMessage) error
 		SendToUser(meetingID, userID string, message *entity.WSMessage) error
 	}
 
 	MeetingRepo interface {
+		// CreateMeeting - возвращает entity.ErrMeetingCodeTaken, если код встречи уже занят
 		CreateMeeting(ctx context.Context, meeting *entity.Meeting) error
 		GetMeeting(ctx context.Context, meetingID string) (*entity.Meeting, error)
+		// GetMeetingIDByCode - ID встречи по ее коду, пустая строка, если встречи нет
+		GetMeetingIDByCode(ctx context.Context, code string) (string, error)
 		AddUserToMeeting(ctx context.Context, meetingID string, user *entity.User) error
 		RemoveUserFromMeeting(ctx context.Context, meetingID, userID string) error
-		SetUserOnlineStatus(ctx context.Context, meetingID, userID string, online bool) error
+		// StartUserSession - открывает сессию участника, если открытой еще нет.
+		// Возвращает entity.ErrUserNotFound, если пользователь не состоит во встрече
+		StartUserSession(ctx context.Context, meetingID, userID string, at time.Time) error
+		// EndUserSession - закрывает открытую сессию пользователя, если она есть.
+		// История сессий хранится до удаления встречи, даже если пользователь ее покинул
+		EndUserSession(ctx context.Context, meetingID, userID string, at time.Time) error
+		// ListAttendance - журнал посещаемости встречи от старых событий к новым.
+		// Его пишут StartUserSession и EndUserSession, удаление встречи журнал не трогает
+		ListAttendance(ctx context.Context, meetingID string) ([]entity.AttendanceEvent, error)
+		// DeleteAttendanceBefore - удаляет журналы удаленных встреч, последнее событие
+		// в которых раньше before. Возвращает число удаленных журналов
+		DeleteAttendanceBefore(ctx context.Context, before time.Time) (int, error)
 		GetMeetingUsers(ctx context.Context, meetingID string) ([]entity.User, error)
+		SetMeetingHost(ctx context.Context, meetingID, hostID string) error
+		SetMeetingLocked(ctx context.Context, meetingID string, locked bool) error
+		SetMeetingLobby(ctx context.Context, meetingID string, enabled bool) error
+		// UpdateMeeting - сохраняет название, описание, вместимость, расписание, lock, лобби
+		// и код доступа встречи. Ведущий и участники не меняются
+		UpdateMeeting(ctx context.Context, meeting *entity.Meeting) error
+		DeleteMeeting(ctx context.Context, meetingID string) error
+		ListMeetings(ctx context.Context) ([]entity.Meeting, error)
+
+		// Лобби: пользователи, которые ждут решения ведущего, в порядке прихода.
+		// RemovePendingUser возвращает nil, если пользователь не ждет в лобби
+		AddPendingUser(ctx context.Context, meetingID string, user *entity.User) error
+		RemovePendingUser(ctx context.Context, meetingID, userID string) (*entity.User, error)
+		GetPendingUsers(ctx context.Context, meetingID string) ([]entity.User, error)
+	}
+
+	ChatRepo interface {
+		SaveMessage(ctx context.Context, message *entity.ChatMessage) error
+		// ListMessages - до limit сообщений, видимых viewerID, раньше сообщения before, от старых к новым
+		ListMessages(ctx context.Context, meetingID, viewerID, before string, limit int) ([]entity.ChatMessage, error)
+		DeleteMeetingMessages(ctx context.Context, meetingID string) error
+	}
+
+	// RecordingRepo - описания законченных записей, сами файлы пишет Recorder
+	RecordingRepo interface {
+		SaveRecording(ctx context.Context, recording *entity.Recording) error
+		// ListRecordings - записи встречи от старых к новым
+		ListRecordings(ctx context.Context, meetingID string) ([]entity.Recording, error)
+	}
+
+	// WebhookRepo - журнал доставок вебхуков и недоставленные события
+	WebhookRepo interface {
+		// SaveDelivery - добавляет доставку в журнал или обновляет ее по ID
+		SaveDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+		ListDeliveries(ctx context.Context, status string, limit int) ([]entity.WebhookDelivery, error)
+		SaveDeadLetter(ctx context.Context, letter *entity.WebhookDeadLetter) error
+		// TakeDeadLetter - убирает недоставленное событие из очереди и возвращает его,
+		// entity.ErrDeadLetterNotFound, если его нет
+		TakeDeadLetter(ctx context.Context, deliveryID string) (*entity.WebhookDeadLetter, error)
+		// ListDeadLetters - недоставленные события от старых к новым
+		ListDeadLetters(ctx context.Context) ([]entity.WebhookDeadLetter, error)
+	}
+
+	// WebhookSender - одна попытка доставки события, подписанного секретом подписчика.
+	// Возвращает код ответа и ошибку, если подписчик не ответил 2xx
+	WebhookSender interface {
+		Send(ctx context.Context, url, secret, deliveryID string, event *entity.WebhookEvent) (int, error)
+	}
+
+	// TokenManager - выпуск и проверка токенов участников встреч
+	TokenManager interface {
+		Issue(meetingID, userID string) (string, time.Time, error)
+		Parse(token string) (*entity.TokenClaims, error)
+	}
+
+	// PasscodeHasher - хеширование кодов доступа встреч
+	PasscodeHasher interface {
+		Hash(passcode string) (string, error)
+		Compare(hash, passcode string) bool
+	}
+
+	// AttemptLimiter - ограничение неудачных попыток по ключу. Попытка засчитывается
+	// атомарно до проверки, а удачная возвращается через Release или Reset
+	AttemptLimiter interface {
+		// Allow - засчитывает попытку. Ноль - попытка разрешена, иначе сколько еще ключ заблокирован
+		Allow(key string) time.Duration
+		// Release - возвращает одну засчитанную попытку
+		Release(key string)
+		// Reset - сбрасывает все попытки ключа
+		Reset(key string)
+	}
+
+	// TURNCredentials - выпуск временных учетных данных встроенного TURN сервера
+	TURNCredentials interface {
+		Issue(meetingID, userID string) (*entity.TURNCredentials, error)
+	}
+
+	// EventBus - доменные события между usecase в пределах процесса. Publish вызывает
+	// обработчики синхронно, поэтому они видят события в порядке публикации и не должны
+	// блокироваться. Подписка действует до отмены ctx
+	EventBus interface {
+		Publish(ctx context.Context, event entity.DomainEvent)
+		Subscribe(ctx context.Context, handler func(context.Context, entity.DomainEvent))
+	}
+
+	// SignalingBroker - доставка сигнальных сообщений между репликами бэкенда
+	SignalingBroker interface {
+		// Publish - рассылает сообщение всем репликам. Возвращает broker.ErrNoSubscribers,
+		// если его не получила ни одна
+		Publish(ctx context.Context, envelope *entity.SignalEnvelope) error
+		Subscribe(ctx context.Context, handler func(*entity.SignalEnvelope)) error
+	}
+
+	// SFU - медиасервер для встреч в режиме sfu: участник публикует потоки серверу,
+	// а тот пересылает их остальным. Ответы, встречные offer и ICE кандидаты сервер
+	// шлет участнику сигнальными сообщениями от protocol.SFUPeerID
+	SFU interface {
+		HandleOffer(ctx context.Context, meetingID, userID, sdp string) error
+		HandleAnswer(ctx context.Context, meetingID, userID, sdp string) error
+		AddICECandidate(ctx context.Context, meetingID, userID string, candidate *entity.ICECandidate) error
+		// RemovePeer - закрывает соединение участника и снимает его потоки у остальных
+		RemovePeer(meetingID, userID string)
+		Close()
+	}
+
+	// MediaGateway - соединения WHIP и WHEP клиентов с медиасервером. peerID - ID
+	// публикующего участника для Ingest и ID сессии для Watch
+	MediaGateway interface {
+		Ingest(ctx context.Context, meetingID, userID, sdp string) (string, error)
+		Watch(ctx context.Context, meetingID, viewerID, publisherID, sdp string) (string, error)
+		AddICECandidate(ctx context.Context, meetingID, peerID string, candidate *entity.ICECandidate) error
+		RemovePeer(meetingID, peerID string)
+		OnPeerFailed(handler func(meetingID, peerID string))
+	}
+
+	// Recorder - запись дорожек встречи в файлы каталога dir. Во встрече в режиме sfu
+	// пишутся потоки, которые участники уже публикуют медиасерверу, в режиме mesh
+	// участники подключаются к рекордеру (protocol.RecorderPeerID) отдельным соединением
+	Recorder interface {
+		StartRecording(meetingID, mode, dir string) error
+		StopRecording(meetingID string) ([]entity.RecordingFile, error)
+		HandleRecorderOffer(ctx context.Context, meetingID, userID, sdp string) error
+		AddRecorderICECandidate(ctx context.Context, meetingID, userID string, candidate *entity.ICECandidate) error
+		RemovePeer(meetingID, userID string)
+	}
+
+	// Metrics - счетчики нагрузки сервера
+	Metrics interface {
+		SetActiveMeetings(count int)
+		ConnectionOpened()
+		ConnectionClosed()
+		MessageRelayed(messageType string)
+		MessageDropped(messageType, reason string)
+		UserJoined()
+		UserLeft(reason string)
+		ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+	}
+
+	// Clock - источник текущего времени, в тестах подменяется управляемыми часами
+	Clock interface {
+		Now() time.Time
+		NewTicker(d time.Duration) clock.Ticker
+		AfterFunc(d time.Duration, f func()) clock.Timer
 	}
 
 	WSConnection interface {
 		ReadMessage() ([]byte, error)
 		WriteMessage(messageType int, data []byte) error
+		// CloseWithReason - отправляет клиенту close frame с кодом и причиной и закрывает соединение
+		CloseWithReason(code int, reason string) error
 		Close() error
 	}
 )
@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+)
+
+// MeetingJanitor - периодически завершает истекшие и простаивающие встречи
+type MeetingJanitor struct {
+	meetingUC MeetingUseCase
+	clock     Clock
+	interval  time.Duration
+	logger    logger.Interface
+}
+
+func NewMeetingJanitor(meetingUC MeetingUseCase, clock Clock, interval time.Duration, logger logger.Interface) *MeetingJanitor {
+	return &MeetingJanitor{
+		meetingUC: meetingUC,
+		clock:     clock,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Run - запускает очистку до отмены контекста
+func (j *MeetingJanitor) Run(ctx context.Context) {
+	ticker := j.clock.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			expired, err := j.meetingUC.ExpireMeetings(ctx)
+			if err != nil {
+				j.logger.Error("failed to expire meetings", "error", err)
+			}
+			if expired > 0 {
+				j.logger.Info("expired meetings removed", "count", expired)
+			}
+		}
+	}
+}
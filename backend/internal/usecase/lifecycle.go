@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// EndMeeting - ведущий завершает встречу для всех участников
+func (uc *meetingService) EndMeeting(ctx context.Context, meetingID, hostID string) error {
+	meeting, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, "")
+	if err != nil {
+		return err
+	}
+
+	return uc.endMeeting(ctx, meeting, entity.EndReasonHost, hostID)
+}
+
+func (uc *meetingService) ExpireMeetings(ctx context.Context) (int, error) {
+	meetings, err := uc.meetingRepo.ListMeetings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list meetings: %w", err)
+	}
+
+	now := uc.clock.Now()
+	expired := 0
+	var errs []error
+
+	for i := range meetings {
+		reason, ok := uc.expiryReason(&meetings[i], now)
+		if !ok {
+			if err := uc.removeGhosts(ctx, &meetings[i], now); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if err := uc.endMeeting(ctx, &meetings[i], reason, ""); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		expired++
+	}
+
+	uc.forgetIdle(meetings)
+
+	// Журнал завершенной встречи хранится AttendanceTTL с ее последнего события
+	if uc.cfg.AttendanceTTL > 0 {
+		if _, err := uc.meetingRepo.DeleteAttendanceBefore(ctx, now.Add(-uc.cfg.AttendanceTTL)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete attendance: %w", err))
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// expiryReason - решает, пора ли завершить встречу. Пустая встреча считается
+// простаивающей с момента, когда janitor впервые увидел ее без участников онлайн
+func (uc *meetingService) expiryReason(meeting *entity.Meeting, now time.Time) (string, bool) {
+	if meeting.EndsAt != nil && !now.Before(*meeting.EndsAt) {
+		return entity.EndReasonSchedule, true
+	}
+
+	uc.idleMu.Lock()
+	defer uc.idleMu.Unlock()
+
+	// Запланированная встреча не простаивает, пока не началась
+	notStarted := meeting.StartsAt != nil && now.Before(*meeting.StartsAt)
+	if notStarted || hasOnlineUsers(meeting) {
+		delete(uc.idleSince, meeting.ID)
+		return "", false
+	}
+
+	since, exists := uc.idleSince[meeting.ID]
+	if !exists {
+		uc.idleSince[meeting.ID] = now
+		return "", false
+	}
+
+	if now.Sub(since) < uc.cfg.IdleTTL {
+		return "", false
+	}
+
+	return entity.EndReasonIdle, true
+}
+
+// removeGhosts - удаляет участников, которые дольше GhostTTL не подключены к встрече:
+// закрыли вкладку и не вернулись. Каждое обновление страницы без повторного входа
+// под прежним ID иначе оставляло бы во встрече еще одного участника офлайн
+func (uc *meetingService) removeGhosts(ctx context.Context, meeting *entity.Meeting, now time.Time) error {
+	for _, user := range meeting.Users {
+		if user.IsOnline || now.Sub(user.LastSeen()) < uc.cfg.GhostTTL {
+			continue
+		}
+
+		if err := uc.meetingRepo.RemoveUserFromMeeting(ctx, meeting.ID, user.ID); err != nil {
+			// Пользователь успел выйти сам
+			if errors.Is(err, entity.ErrUserNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to remove user from meeting: %w", err)
+		}
+
+		uc.metrics.UserLeft(leaveReasonGone)
+		uc.events.Publish(ctx, &entity.ParticipantRemovedEvent{
+			EventMeta: entity.EventMeta{MeetingID: meeting.ID, At: now},
+			UserID:    user.ID,
+			Reason:    entity.RemoveReasonGone,
+		})
+
+		if err := uc.handOverHost(ctx, meeting.ID, user.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forgetIdle - убирает из учета простоя встречи, которых больше нет
+func (uc *meetingService) forgetIdle(meetings []entity.Meeting) {
+	existing := make(map[string]struct{}, len(meetings))
+	for i := range meetings {
+		existing[meetings[i].ID] = struct{}{}
+	}
+
+	uc.idleMu.Lock()
+	defer uc.idleMu.Unlock()
+
+	for meetingID := range uc.idleSince {
+		if _, ok := existing[meetingID]; !ok {
+			delete(uc.idleSince, meetingID)
+		}
+	}
+}
+
+// endMeeting - удаляет встречу. По MeetingEndedEvent участники получают meeting_ended,
+// а их соединения закрываются
+func (uc *meetingService) endMeeting(ctx context.Context, meeting *entity.Meeting, reason, hostID string) error {
+	meetingID := meeting.ID
+
+	// Запись сохраняется, пока участники еще подключены и получат recording_stopped
+	if err := uc.recordings.FinishRecording(ctx, meetingID); err != nil {
+		return fmt.Errorf("failed to finish recording: %w", err)
+	}
+
+	// Сессии закрываются до удаления встречи, чтобы журнал посещаемости получил время выхода
+	now := uc.clock.Now()
+	for _, user := range meeting.Users {
+		if user.IsOnline {
+			_ = uc.meetingRepo.EndUserSession(ctx, meetingID, user.ID, now)
+		}
+	}
+
+	if err := uc.meetingRepo.DeleteMeeting(ctx, meetingID); err != nil {
+		return fmt.Errorf("failed to delete meeting: %w", err)
+	}
+
+	uc.idleMu.Lock()
+	delete(uc.idleSince, meetingID)
+	uc.idleMu.Unlock()
+
+	_ = uc.chatRepo.DeleteMeetingMessages(ctx, meetingID)
+
+	for range meeting.Users {
+		uc.metrics.UserLeft(leaveReasonMeetingEnded)
+	}
+
+	// Событие публикует только тот, кто удалил встречу, повторного завершения не будет
+	uc.events.Publish(ctx, &entity.MeetingEndedEvent{
+		EventMeta: entity.EventMeta{MeetingID: meetingID, At: now},
+		Meeting:   meeting,
+		Reason:    reason,
+		By:        hostID,
+	})
+
+	return nil
+}
+
+func hasOnlineUsers(meeting *entity.Meeting) bool {
+	for _, user := range meeting.Users {
+		if user.IsOnline {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/broker"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/metrics"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+)
+
+const (
+	_testIdleTTL       = 10 * time.Minute
+	_testGhostTTL      = 5 * time.Minute
+	_testAttendanceTTL = 24 * time.Hour
+)
+
+type lifecycleFixture struct {
+	clock   *clock.Fake
+	repo    *repo.MemoryMeetingRepository
+	service *meetingService
+	events  *eventRecorder
+}
+
+func newLifecycleFixture(t *testing.T) *lifecycleFixture {
+	t.Helper()
+
+	fakeClock := clock.NewFake(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	meetingRepo := repo.NewMemoryMeetingRepository()
+	events := NewEventBus()
+
+	service := NewMeetingService(meetingRepo, repo.NewMemoryChatRepository(100), stubRecordingUC{}, nil, nil, nil, nil,
+		broker.NewMemoryBroker(), events, fakeClock, metrics.Nop{}, MeetingConfig{
+			IdleTTL:       _testIdleTTL,
+			GhostTTL:      _testGhostTTL,
+			AttendanceTTL: _testAttendanceTTL,
+		})
+
+	return &lifecycleFixture{
+		clock:   fakeClock,
+		repo:    meetingRepo,
+		service: service,
+		events:  recordEvents(t, events),
+	}
+}
+
+// expire - один проход janitor'а, возвращает число завершенных встреч
+func (f *lifecycleFixture) expire(t *testing.T) int {
+	t.Helper()
+
+	expired, err := f.service.ExpireMeetings(context.Background())
+	if err != nil {
+		t.Fatalf("expire meetings: %v", err)
+	}
+	return expired
+}
+
+func (f *lifecycleFixture) exists(t *testing.T, meetingID string) bool {
+	t.Helper()
+
+	meeting, err := f.repo.GetMeeting(context.Background(), meetingID)
+	if err != nil {
+		t.Fatalf("get meeting: %v", err)
+	}
+	return meeting != nil
+}
+
+func (f *lifecycleFixture) endReason(meetingID string) string {
+	for _, event := range f.events.all() {
+		if ended, ok := event.(*entity.MeetingEndedEvent); ok && ended.MeetingID == meetingID {
+			return ended.Reason
+		}
+	}
+	return ""
+}
+
+func TestExpireMeetingsScheduledEnd(t *testing.T) {
+	f := newLifecycleFixture(t)
+
+	endsAt := f.clock.Now().Add(time.Hour)
+	meeting := &entity.Meeting{EndsAt: &endsAt}
+	createTestMeeting(t, f.repo, meeting, f.clock.Now(), []string{"host"})
+
+	f.clock.Advance(time.Hour - time.Second)
+	if expired := f.expire(t); expired != 0 {
+		t.Fatalf("expired %d meetings before the scheduled end", expired)
+	}
+
+	f.clock.Advance(time.Second)
+	if expired := f.expire(t); expired != 1 {
+		t.Fatalf("expired %d meetings at the scheduled end, want 1", expired)
+	}
+
+	if f.exists(t, meeting.ID) {
+		t.Fatal("meeting still exists after the scheduled end")
+	}
+	if reason := f.endReason(meeting.ID); reason != entity.EndReasonSchedule {
+		t.Fatalf("got end reason %q, want %q", reason, entity.EndReasonSchedule)
+	}
+
+	// Время выхода участника онлайн попадает в журнал посещаемости
+	events, err := f.repo.ListAttendance(context.Background(), meeting.ID)
+	if err != nil {
+		t.Fatalf("list attendance: %v", err)
+	}
+	if last := events[len(events)-1]; last.Type != entity.AttendanceLeft || !last.At.Equal(endsAt) {
+		t.Fatalf("got last attendance event %+v, want left at %s", last, endsAt)
+	}
+}
+
+func TestEndMeetingKeepsAttendance(t *testing.T) {
+	f := newLifecycleFixture(t)
+
+	meeting := &entity.Meeting{HostID: "host"}
+	createTestMeeting(t, f.repo, meeting, f.clock.Now(), []string{"host", "guest"})
+
+	f.clock.Advance(time.Hour)
+	endedAt := f.clock.Now()
+	if err := f.service.EndMeeting(context.Background(), meeting.ID, "host"); err != nil {
+		t.Fatalf("end meeting: %v", err)
+	}
+
+	// Отчет доступен после завершения, сессии закрыты временем завершения
+	report, err := f.service.GetAttendance(context.Background(), meeting.ID)
+	if err != nil {
+		t.Fatalf("get attendance after the meeting ended: %v", err)
+	}
+	if len(report.Attendees) != 2 {
+		t.Fatalf("got attendees %+v, want host and guest", report.Attendees)
+	}
+	for _, record := range report.Attendees {
+		if record.Online || record.LastLeftAt == nil || !record.LastLeftAt.Equal(endedAt) || record.TotalSeconds != 3600 {
+			t.Fatalf("got record %+v, want an hour closed at %s", record, endedAt)
+		}
+	}
+
+	// По журналу участник, отключившийся после завершения, получает имя в participant.left
+	if name := attendeeName(context.Background(), f.repo, meeting.ID, "guest"); name != "guest" {
+		t.Fatalf("got attendee name %q after the meeting ended, want guest", name)
+	}
+
+	// Журнал хранится attendance TTL с завершения встречи
+	f.clock.Advance(_testAttendanceTTL)
+	f.expire(t)
+	if _, err := f.service.GetAttendance(context.Background(), meeting.ID); err != nil {
+		t.Fatalf("get attendance before the TTL: %v", err)
+	}
+
+	f.clock.Advance(time.Second)
+	f.expire(t)
+	if _, err := f.service.GetAttendance(context.Background(), meeting.ID); !errors.Is(err, entity.ErrMeetingNotFound) {
+		t.Fatalf("got %v after the TTL, want meeting not found", err)
+	}
+}
+
+func TestExpireMeetingsIdle(t *testing.T) {
+	tests := []struct {
+		name string
+		// online - участники онлайн, их наличие сбрасывает простой
+		online []string
+		// startsIn - через сколько начнется встреча, ноль - без расписания
+		startsIn    time.Duration
+		wantExpired bool
+	}{
+		{name: "empty meeting", wantExpired: true},
+		{name: "meeting with online user", online: []string{"host"}},
+		{name: "scheduled meeting before start", startsIn: 2 * _testIdleTTL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLifecycleFixture(t)
+
+			meeting := &entity.Meeting{}
+			if tt.startsIn > 0 {
+				startsAt := f.clock.Now().Add(tt.startsIn)
+				meeting.StartsAt = &startsAt
+			}
+			createTestMeeting(t, f.repo, meeting, f.clock.Now(), tt.online)
+
+			// Первый проход только замечает простой, TTL отсчитывается от него
+			if expired := f.expire(t); expired != 0 {
+				t.Fatalf("expired %d meetings on the first pass", expired)
+			}
+
+			f.clock.Advance(_testIdleTTL - time.Second)
+			if expired := f.expire(t); expired != 0 {
+				t.Fatalf("expired %d meetings before idle TTL", expired)
+			}
+
+			f.clock.Advance(time.Second)
+			expired := f.expire(t)
+
+			if tt.wantExpired {
+				if expired != 1 || f.exists(t, meeting.ID) {
+					t.Fatalf("expired %d meetings after idle TTL, want the meeting removed", expired)
+				}
+				if reason := f.endReason(meeting.ID); reason != entity.EndReasonIdle {
+					t.Fatalf("got end reason %q, want %q", reason, entity.EndReasonIdle)
+				}
+				return
+			}
+
+			if expired != 0 || !f.exists(t, meeting.ID) {
+				t.Fatalf("expired %d meetings, want the meeting kept", expired)
+			}
+		})
+	}
+}
+
+func TestExpireMeetingsIdleResetsOnReconnect(t *testing.T) {
+	f := newLifecycleFixture(t)
+	ctx := context.Background()
+
+	meeting := &entity.Meeting{}
+	createTestMeeting(t, f.repo, meeting, f.clock.Now(), nil, "host")
+	f.expire(t)
+
+	// Участник ненадолго вернулся, простой считается заново
+	f.clock.Advance(_testIdleTTL / 2)
+	if err := f.repo.StartUserSession(ctx, meeting.ID, "host", f.clock.Now()); err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	f.expire(t)
+	if err := f.repo.EndUserSession(ctx, meeting.ID, "host", f.clock.Now()); err != nil {
+		t.Fatalf("end session: %v", err)
+	}
+	f.expire(t)
+
+	f.clock.Advance(_testIdleTTL - time.Second)
+	if expired := f.expire(t); expired != 0 {
+		t.Fatalf("expired %d meetings, idle time must restart after reconnect", expired)
+	}
+
+	f.clock.Advance(time.Second)
+	if expired := f.expire(t); expired != 1 {
+		t.Fatalf("expired %d meetings after idle TTL, want 1", expired)
+	}
+}
+
+func TestExpireMeetingsRemovesGhosts(t *testing.T) {
+	f := newLifecycleFixture(t)
+	ctx := context.Background()
+
+	meeting := &entity.Meeting{HostID: "ghost"}
+	createTestMeeting(t, f.repo, meeting, f.clock.Now(), []string{"ghost", "online"}, "never-connected")
+
+	f.clock.Advance(time.Minute)
+	if err := f.repo.EndUserSession(ctx, meeting.ID, "ghost", f.clock.Now()); err != nil {
+		t.Fatalf("end session: %v", err)
+	}
+
+	// Для never-connected отсчет идет от входа, для ghost - от конца сессии
+	f.clock.Advance(_testGhostTTL - time.Minute)
+	f.expire(t)
+	assertMembers(t, f.repo, meeting.ID, "ghost", "online")
+
+	f.clock.Advance(time.Minute)
+	f.expire(t)
+	assertMembers(t, f.repo, meeting.ID, "online")
+
+	removed := 0
+	for _, event := range f.events.all() {
+		if e, ok := event.(*entity.ParticipantRemovedEvent); ok {
+			if e.Reason != entity.RemoveReasonGone {
+				t.Fatalf("got remove reason %q, want %q", e.Reason, entity.RemoveReasonGone)
+			}
+			removed++
+		}
+	}
+	if removed != 2 {
+		t.Fatalf("got %d removed events, want 2", removed)
+	}
+
+	// Роль ведущего переходит к участнику онлайн
+	got, err := f.repo.GetMeeting(ctx, meeting.ID)
+	if err != nil {
+		t.Fatalf("get meeting: %v", err)
+	}
+	if got.HostID != "online" {
+		t.Fatalf("got host %q, want online", got.HostID)
+	}
+}
+
+func TestMeetingJanitorRunsOnTicks(t *testing.T) {
+	fakeClock := clock.NewFake(time.Now())
+	meetingUC := &countingMeetingUC{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewMeetingJanitor(meetingUC, fakeClock, time.Minute, logger.Nop{}).Run(ctx)
+	}()
+
+	fakeClock.BlockUntil(1)
+	for i := 1; i <= 3; i++ {
+		fakeClock.Advance(time.Minute)
+		waitFor(t, func() bool { return meetingUC.calls.Load() == int32(i) })
+	}
+
+	cancel()
+	<-done
+}
+
+type countingMeetingUC struct {
+	MeetingUseCase
+	calls atomic.Int32
+}
+
+func (uc *countingMeetingUC) ExpireMeetings(ctx context.Context) (int, error) {
+	uc.calls.Add(1)
+	return 0, nil
+}
+
+func assertMembers(t *testing.T, meetingRepo MeetingRepo, meetingID string, want ...string) {
+	t.Helper()
+
+	users, err := meetingRepo.GetMeetingUsers(context.Background(), meetingID)
+	if err != nil {
+		t.Fatalf("get users: %v", err)
+	}
+
+	got := make([]string, 0, len(users))
+	for _, user := range users {
+		got = append(got, user.ID)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got members %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got members %v, want %v", got, want)
+		}
+	}
+}
+
+// waitFor - ждет условия, которое выполнит другая горутина
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition was not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
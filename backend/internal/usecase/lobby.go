@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// AdmitUser - ведущий впускает пользователя из лобби. Лобби-соединение пользователя
+// закрывается с кодом CloseLobbyAdmitted, после чего он подключается как участник
+func (uc *meetingService) AdmitUser(ctx context.Context, meetingID, hostID, userID string) error {
+	meeting, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, "")
+	if err != nil {
+		return err
+	}
+
+	if meetingFull(meeting, len(meeting.Users)) {
+		return entity.ErrMeetingFull
+	}
+
+	user, err := uc.takePendingUser(ctx, meetingID, userID)
+	if err != nil {
+		return err
+	}
+
+	return uc.admit(ctx, meetingID, hostID, user)
+}
+
+// DenyUser - ведущий отказывает пользователю из лобби
+func (uc *meetingService) DenyUser(ctx context.Context, meetingID, hostID, userID string) error {
+	if _, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, ""); err != nil {
+		return err
+	}
+
+	user, err := uc.takePendingUser(ctx, meetingID, userID)
+	if err != nil {
+		return err
+	}
+
+	uc.deny(ctx, meetingID, hostID, user, "denied by host")
+
+	return nil
+}
+
+// SetLobbyEnabled - ведущий включает или выключает лобби. При выключении
+// все, кто ждет в лобби, входят во встречу
+func (uc *meetingService) SetLobbyEnabled(ctx context.Context, meetingID, hostID string, enabled bool) error {
+	meeting, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, "")
+	if err != nil {
+		return err
+	}
+
+	if err := uc.meetingRepo.SetMeetingLobby(ctx, meetingID, enabled); err != nil {
+		return fmt.Errorf("failed to set meeting lobby: %w", err)
+	}
+
+	meeting.Lobby = enabled
+	meeting.PasscodeRequired = meeting.PasscodeHash != ""
+	uc.events.Publish(ctx, &entity.MeetingUpdatedEvent{
+		EventMeta: eventMeta(uc.clock, meetingID),
+		Meeting:   meeting,
+		By:        hostID,
+	})
+
+	if enabled {
+		return nil
+	}
+
+	return uc.admitPending(ctx, meeting, hostID)
+}
+
+// GetLobby - пользователи, которые ждут в лобби, видны только ведущему
+func (uc *meetingService) GetLobby(ctx context.Context, meetingID, hostID string) ([]entity.User, error) {
+	if _, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, ""); err != nil {
+		return nil, err
+	}
+
+	users, err := uc.meetingRepo.GetPendingUsers(ctx, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending users: %w", err)
+	}
+
+	return users, nil
+}
+
+// waitInLobby - пользователь встает в лобби, ведущий получает lobby_request
+func (uc *meetingService) waitInLobby(ctx context.Context, meeting *entity.Meeting, user *entity.User) error {
+	if err := uc.meetingRepo.AddPendingUser(ctx, meeting.ID, user); err != nil {
+		// Пользователь вернулся в лобби под прежним ID, ведущий о нем уже знает
+		if errors.Is(err, entity.ErrUserExists) {
+			return nil
+		}
+		return fmt.Errorf("failed to add user to lobby: %w", err)
+	}
+
+	uc.events.Publish(ctx, &entity.LobbyJoinedEvent{
+		EventMeta: eventMeta(uc.clock, meeting.ID),
+		User:      *user,
+		HostID:    meeting.HostID,
+	})
+
+	return nil
+}
+
+// leaveLobby - пользователь ушел из лобби сам. Возвращает false, если он не ждал в лобби
+func (uc *meetingService) leaveLobby(ctx context.Context, meetingID, userID string) (bool, error) {
+	user, err := uc.meetingRepo.RemovePendingUser(ctx, meetingID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove pending user: %w", err)
+	}
+	if user == nil {
+		return false, nil
+	}
+
+	if meeting, err := uc.meetingRepo.GetMeeting(ctx, meetingID); err == nil && meeting != nil {
+		uc.events.Publish(ctx, &entity.LobbyLeftEvent{
+			EventMeta: eventMeta(uc.clock, meetingID),
+			UserID:    userID,
+			HostID:    meeting.HostID,
+			Outcome:   entity.LobbyOutcomeLeft,
+		})
+	}
+
+	return true, nil
+}
+
+func (uc *meetingService) takePendingUser(ctx context.Context, meetingID, userID string) (*entity.User, error) {
+	if userID == "" {
+		return nil, &entity.ValidationError{Field: "user_id", Reason: "is required"}
+	}
+
+	user, err := uc.meetingRepo.RemovePendingUser(ctx, meetingID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove pending user: %w", err)
+	}
+	if user == nil {
+		return nil, entity.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+// admitPending - впускает всех, кто ждет в лобби. Кому не хватило мест, получают
+// отказ: без лобби решения ведущего им уже не дождаться
+func (uc *meetingService) admitPending(ctx context.Context, meeting *entity.Meeting, hostID string) error {
+	pending, err := uc.meetingRepo.GetPendingUsers(ctx, meeting.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get pending users: %w", err)
+	}
+
+	seats := len(meeting.Users)
+	for i := range pending {
+		user, err := uc.meetingRepo.RemovePendingUser(ctx, meeting.ID, pending[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to remove pending user: %w", err)
+		}
+		if user == nil {
+			continue
+		}
+
+		if meetingFull(meeting, seats) {
+			uc.deny(ctx, meeting.ID, hostID, user, "meeting is full")
+			continue
+		}
+
+		if err := uc.admit(ctx, meeting.ID, hostID, user); err != nil {
+			return err
+		}
+		seats++
+	}
+
+	return nil
+}
+
+func (uc *meetingService) admit(ctx context.Context, meetingID, hostID string, user *entity.User) error {
+	user.JoinedAt = uc.clock.Now()
+	if err := uc.meetingRepo.AddUserToMeeting(ctx, meetingID, user); err != nil {
+		return fmt.Errorf("failed to add user to meeting: %w", err)
+	}
+
+	uc.metrics.UserJoined()
+
+	uc.events.Publish(ctx, &entity.LobbyLeftEvent{
+		EventMeta: entity.EventMeta{MeetingID: meetingID, At: user.JoinedAt},
+		UserID:    user.ID,
+		HostID:    hostID,
+		Outcome:   entity.LobbyOutcomeAdmitted,
+		By:        hostID,
+	})
+	uc.events.Publish(ctx, &entity.ParticipantAddedEvent{
+		EventMeta: entity.EventMeta{MeetingID: meetingID, At: user.JoinedAt},
+		User:      *user,
+	})
+
+	return nil
+}
+
+func (uc *meetingService) deny(ctx context.Context, meetingID, hostID string, user *entity.User, reason string) {
+	uc.events.Publish(ctx, &entity.LobbyLeftEvent{
+		EventMeta: eventMeta(uc.clock, meetingID),
+		UserID:    user.ID,
+		HostID:    hostID,
+		Outcome:   entity.LobbyOutcomeDenied,
+		By:        hostID,
+		Reason:    reason,
+	})
+}
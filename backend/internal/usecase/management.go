@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// Ограничения настроек встречи
+const (
+	_maxMeetingNameLength = 100
+	_maxDescriptionLength = 1000
+)
+
+// CreateMeeting - создает встречу заранее. Ведущим становится пользователь, которого
+// еще нет во встрече: его ID и токен отдаются создателю, с токеном он входит через JoinMeeting
+func (uc *meetingService) CreateMeeting(ctx context.Context, req *entity.CreateMeetingRequest) (*entity.CreateMeetingResponse, error) {
+	hostID := entity.GenerateUserID()
+
+	meeting, err := uc.newMeeting(ctx, req, hostID, uc.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := uc.tokens.Issue(meeting.ID, hostID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue token: %w", err)
+	}
+
+	meeting.PasscodeRequired = meeting.PasscodeHash != ""
+
+	return &entity.CreateMeetingResponse{
+		Meeting:        meeting,
+		Token:          token,
+		TokenExpiresAt: expiresAt,
+	}, nil
+}
+
+// UpdateMeeting - ведущий меняет настройки встречи, участники получают meeting_updated.
+// Выключение лобби впускает всех, кто ждет, как и SetLobbyEnabled
+func (uc *meetingService) UpdateMeeting(ctx context.Context, meetingID, hostID string, req *entity.UpdateMeetingRequest) (*entity.Meeting, error) {
+	meeting, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	lobbyWas := meeting.Lobby
+
+	if err := uc.applyUpdate(meeting, req); err != nil {
+		return nil, err
+	}
+
+	if err := uc.meetingRepo.UpdateMeeting(ctx, meeting); err != nil {
+		return nil, fmt.Errorf("failed to update meeting: %w", err)
+	}
+
+	meeting.PasscodeRequired = meeting.PasscodeHash != ""
+
+	uc.events.Publish(ctx, &entity.MeetingUpdatedEvent{
+		EventMeta: eventMeta(uc.clock, meetingID),
+		Meeting:   meeting,
+		By:        hostID,
+	})
+
+	if lobbyWas && !meeting.Lobby {
+		if err := uc.admitPending(ctx, meeting, hostID); err != nil {
+			return nil, err
+		}
+	}
+
+	return meeting, nil
+}
+
+// DeleteMeeting - ведущий удаляет встречу, участники отключаются как при EndMeeting
+func (uc *meetingService) DeleteMeeting(ctx context.Context, meetingID, hostID string) error {
+	meeting, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, "")
+	if err != nil {
+		return err
+	}
+
+	return uc.endMeeting(ctx, meeting, entity.EndReasonDeleted, hostID)
+}
+
+// newMeeting - проверяет параметры и сохраняет новую встречу с ведущим hostID
+func (uc *meetingService) newMeeting(ctx context.Context, req *entity.CreateMeetingRequest, hostID string, now time.Time) (*entity.Meeting, error) {
+	name, err := meetingName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = entity.DefaultMeetingName
+	}
+
+	if err := validateDetails(req.Description, req.Capacity); err != nil {
+		return nil, err
+	}
+
+	if err := validateSchedule(req.StartsAt, req.EndsAt, now); err != nil {
+		return nil, err
+	}
+
+	mode, err := uc.meetingMode(req.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	passcodeHash, err := uc.hashPasscode(req.Passcode)
+	if err != nil {
+		return nil, err
+	}
+
+	vanityCode := ""
+	if req.MeetingCode != "" {
+		if vanityCode, err = entity.NormalizeMeetingCode(req.MeetingCode); err != nil {
+			return nil, err
+		}
+	}
+
+	meeting := &entity.Meeting{
+		ID:          entity.GenerateMeetingID(),
+		Name:        name,
+		Description: req.Description,
+		Capacity:    req.Capacity,
+		HostID:      hostID,
+		Lobby:       req.Lobby,
+		Mode:        mode,
+		CreatedAt:   now,
+		StartsAt:    req.StartsAt,
+		EndsAt:      req.EndsAt,
+		Users:       []entity.User{},
+
+		PasscodeHash: passcodeHash,
+	}
+
+	if err := uc.createMeeting(ctx, meeting, vanityCode); err != nil {
+		return nil, err
+	}
+
+	uc.events.Publish(ctx, &entity.MeetingCreatedEvent{
+		EventMeta: entity.EventMeta{MeetingID: meeting.ID, At: now},
+		Meeting:   meeting,
+	})
+
+	return meeting, nil
+}
+
+// applyUpdate - переносит во встречу переданные поля, проверяя их
+func (uc *meetingService) applyUpdate(meeting *entity.Meeting, req *entity.UpdateMeetingRequest) error {
+	if req.Name != nil {
+		name, err := meetingName(*req.Name)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return &entity.ValidationError{Field: "meeting_name", Reason: "must not be empty"}
+		}
+		meeting.Name = name
+	}
+
+	if req.Description != nil {
+		meeting.Description = *req.Description
+	}
+	if req.Capacity != nil {
+		meeting.Capacity = *req.Capacity
+	}
+	if err := validateDetails(meeting.Description, meeting.Capacity); err != nil {
+		return err
+	}
+
+	if req.StartsAt != nil || req.EndsAt != nil {
+		if req.StartsAt != nil {
+			meeting.StartsAt = req.StartsAt
+		}
+		if req.EndsAt != nil {
+			meeting.EndsAt = req.EndsAt
+		}
+		if err := validateSchedule(meeting.StartsAt, meeting.EndsAt, uc.clock.Now()); err != nil {
+			return err
+		}
+	}
+
+	if req.Locked != nil {
+		meeting.Locked = *req.Locked
+	}
+	if req.Lobby != nil {
+		meeting.Lobby = *req.Lobby
+	}
+
+	if req.Passcode != nil {
+		passcodeHash, err := uc.hashPasscode(*req.Passcode)
+		if err != nil {
+			return err
+		}
+		meeting.PasscodeHash = passcodeHash
+	}
+
+	return nil
+}
+
+// meetingName - название без пробелов по краям, пустая строка - название не задано
+func meetingName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > _maxMeetingNameLength {
+		return "", &entity.ValidationError{
+			Field:  "meeting_name",
+			Reason: fmt.Sprintf("must be at most %d characters", _maxMeetingNameLength),
+		}
+	}
+
+	return name, nil
+}
+
+func validateDetails(description string, capacity int) error {
+	if utf8.RuneCountInString(description) > _maxDescriptionLength {
+		return &entity.ValidationError{
+			Field:  "description",
+			Reason: fmt.Sprintf("must be at most %d characters", _maxDescriptionLength),
+		}
+	}
+
+	if capacity < 0 {
+		return &entity.ValidationError{Field: "capacity", Reason: "must not be negative"}
+	}
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// _gatherTimeout - сколько ждать сбора кандидатов сервера перед ответом WHIP/WHEP клиенту
+const _gatherTimeout = 5 * time.Second
+
+// _defaultIngestName - имя участника WHIP, если клиент его не передал
+const _defaultIngestName = "WHIP"
+
+// mediaSessionService - сессии хранятся в памяти реплики, на которой работает их
+// соединение, поэтому PATCH и DELETE должны приходить на ту же реплику
+type mediaSessionService struct {
+	meetingRepo MeetingRepo
+	meetingUC   MeetingUseCase
+	gateway     MediaGateway
+	broker      SignalingBroker
+	events      EventBus
+	clock       Clock
+	metrics     Metrics
+
+	sessions map[string]*entity.MediaSession
+	mu       sync.Mutex
+}
+
+// NewMediaSessionService - gateway может быть nil, если медиасервер выключен
+func NewMediaSessionService(meetingRepo MeetingRepo, meetingUC MeetingUseCase, gateway MediaGateway, broker SignalingBroker, events EventBus, clock Clock, metrics Metrics) *mediaSessionService {
+	uc := &mediaSessionService{
+		meetingRepo: meetingRepo,
+		meetingUC:   meetingUC,
+		gateway:     gateway,
+		broker:      broker,
+		events:      events,
+		clock:       clock,
+		metrics:     metrics,
+		sessions:    make(map[string]*entity.MediaSession),
+	}
+
+	if gateway != nil {
+		gateway.OnPeerFailed(uc.peerFailed)
+	}
+
+	return uc
+}
+
+var _ MediaSessionUseCase = (*mediaSessionService)(nil)
+
+// Ingest - WHIP: кодировщик становится участником встречи с именем name
+// и публикует в нее свои дорожки, как участник в режиме sfu
+func (uc *mediaSessionService) Ingest(ctx context.Context, meetingID, ownerID, name, offer string) (*entity.MediaSession, error) {
+	if _, err := uc.sfuMeeting(ctx, meetingID); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = _defaultIngestName
+	}
+
+	user := &entity.User{
+		ID:       entity.GenerateUserID(),
+		Name:     name,
+		JoinedAt: uc.clock.Now(),
+	}
+
+	if err := uc.meetingRepo.AddUserToMeeting(ctx, meetingID, user); err != nil {
+		return nil, fmt.Errorf("failed to add user to meeting: %w", err)
+	}
+	uc.metrics.UserJoined()
+
+	session := uc.newSession(meetingID, entity.MediaSessionWHIP, ownerID, user.ID)
+
+	gatherCtx, cancel := context.WithTimeout(ctx, _gatherTimeout)
+	defer cancel()
+
+	answer, err := uc.gateway.Ingest(gatherCtx, meetingID, user.ID, offer)
+	if err != nil {
+		_ = uc.removeUser(context.Background(), session)
+		return nil, err
+	}
+	session.Answer = answer
+
+	// Участник WHIP не подключается к WebSocket, поэтому онлайн он сразу
+	if err := uc.meetingRepo.StartUserSession(ctx, meetingID, user.ID, uc.clock.Now()); err != nil {
+		uc.gateway.RemovePeer(meetingID, user.ID)
+		_ = uc.removeUser(context.Background(), session)
+		return nil, fmt.Errorf("failed to set user online: %w", err)
+	}
+
+	uc.add(session)
+	uc.events.Publish(ctx, &entity.ParticipantConnectedEvent{
+		EventMeta: eventMeta(uc.clock, meetingID),
+		UserID:    user.ID,
+		UserName:  user.Name,
+	})
+
+	return session, nil
+}
+
+// Watch - WHEP: плеер получает дорожки участника publisherID. Если участник
+// переопубликует дорожки, плеер переключится на новые без пересогласования
+func (uc *mediaSessionService) Watch(ctx context.Context, meetingID, ownerID, publisherID, offer string) (*entity.MediaSession, error) {
+	if publisherID == "" {
+		return nil, &entity.ValidationError{Field: "user_id", Reason: "is required"}
+	}
+
+	meeting, err := uc.sfuMeeting(ctx, meetingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasUser(meeting, publisherID) {
+		return nil, entity.ErrUserNotFound
+	}
+
+	session := uc.newSession(meetingID, entity.MediaSessionWHEP, ownerID, publisherID)
+
+	gatherCtx, cancel := context.WithTimeout(ctx, _gatherTimeout)
+	defer cancel()
+
+	answer, err := uc.gateway.Watch(gatherCtx, meetingID, session.ID, publisherID, offer)
+	if err != nil {
+		return nil, err
+	}
+	session.Answer = answer
+
+	uc.add(session)
+
+	return session, nil
+}
+
+// AddICECandidates - trickle ICE: кандидаты клиента, найденные после offer
+func (uc *mediaSessionService) AddICECandidates(ctx context.Context, meetingID, sessionID, ownerID string, candidates []entity.ICECandidate) error {
+	session, err := uc.find(meetingID, sessionID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	for i := range candidates {
+		if err := uc.gateway.AddICECandidate(ctx, meetingID, mediaPeerID(session), &candidates[i]); err != nil {
+			return &entity.ValidationError{Field: "candidate", Reason: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// CloseSession - закрывает соединение. Участник WHIP при этом покидает встречу
+func (uc *mediaSessionService) CloseSession(ctx context.Context, meetingID, sessionID, ownerID string) error {
+	session, err := uc.find(meetingID, sessionID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if !uc.remove(session) {
+		return entity.ErrMediaSessionNotFound
+	}
+
+	uc.gateway.RemovePeer(meetingID, mediaPeerID(session))
+	uc.leave(ctx, session)
+
+	return nil
+}
+
+// Start - подписывает сервис на брокер, чтобы закрывать сессии завершенных встреч
+// и исключенных участников
+func (uc *mediaSessionService) Start(ctx context.Context) error {
+	return uc.broker.Subscribe(ctx, uc.onClose)
+}
+
+// Shutdown - закрывает все сессии реплики при остановке сервера. Участники WHIP
+// покидают встречи, чтобы не остаться в них навсегда онлайн
+func (uc *mediaSessionService) Shutdown(ctx context.Context) {
+	uc.mu.Lock()
+	sessions := make([]*entity.MediaSession, 0, len(uc.sessions))
+	for _, session := range uc.sessions {
+		sessions = append(sessions, session)
+	}
+	uc.sessions = make(map[string]*entity.MediaSession)
+	uc.mu.Unlock()
+
+	for _, session := range sessions {
+		uc.gateway.RemovePeer(session.MeetingID, mediaPeerID(session))
+		uc.leave(ctx, session)
+	}
+}
+
+// onClose - встреча завершилась или участника исключили. Встречу и исключенного
+// участника WHIP уже удалили из репозитория, а сессии исключенного владельца
+// закрываются так же, как по DELETE. Брокер вызывает обработчики синхронно,
+// поэтому сессии закрываются в отдельной горутине
+func (uc *mediaSessionService) onClose(envelope *entity.SignalEnvelope) {
+	if envelope.CloseCode == 0 {
+		return
+	}
+	if envelope.UserID != "" && envelope.CloseCode != entity.CloseKicked {
+		return
+	}
+
+	uc.mu.Lock()
+	var closed []*entity.MediaSession
+	for id, session := range uc.sessions {
+		if session.MeetingID != envelope.MeetingID {
+			continue
+		}
+		if envelope.UserID != "" && session.OwnerID != envelope.UserID && mediaPeerID(session) != envelope.UserID {
+			continue
+		}
+
+		delete(uc.sessions, id)
+		closed = append(closed, session)
+	}
+	uc.mu.Unlock()
+
+	if len(closed) == 0 {
+		return
+	}
+
+	go func() {
+		for _, session := range closed {
+			uc.gateway.RemovePeer(session.MeetingID, mediaPeerID(session))
+
+			if envelope.UserID != "" && mediaPeerID(session) != envelope.UserID {
+				uc.leave(context.Background(), session)
+			}
+		}
+	}()
+}
+
+// peerFailed - соединение клиента разорвалось, сессию больше нельзя восстановить
+func (uc *mediaSessionService) peerFailed(meetingID, peerID string) {
+	uc.mu.Lock()
+	var failed *entity.MediaSession
+	for id, session := range uc.sessions {
+		if session.MeetingID == meetingID && mediaPeerID(session) == peerID {
+			delete(uc.sessions, id)
+			failed = session
+			break
+		}
+	}
+	uc.mu.Unlock()
+
+	if failed != nil {
+		uc.leave(context.Background(), failed)
+	}
+}
+
+// sfuMeeting - WHIP и WHEP работают только через медиасервер
+func (uc *mediaSessionService) sfuMeeting(ctx context.Context, meetingID string) (*entity.Meeting, error) {
+	if uc.gateway == nil {
+		return nil, entity.ErrSFUUnavailable
+	}
+
+	meeting, err := uc.meetingRepo.GetMeeting(ctx, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meeting: %w", err)
+	}
+	if meeting == nil {
+		return nil, entity.ErrMeetingNotFound
+	}
+	if meeting.Mode != entity.MeetingModeSFU {
+		return nil, entity.ErrSFUUnavailable
+	}
+
+	return meeting, nil
+}
+
+func (uc *mediaSessionService) newSession(meetingID, kind, ownerID, userID string) *entity.MediaSession {
+	return &entity.MediaSession{
+		ID:        entity.GenerateMediaSessionID(),
+		MeetingID: meetingID,
+		Kind:      kind,
+		OwnerID:   ownerID,
+		UserID:    userID,
+		CreatedAt: uc.clock.Now(),
+	}
+}
+
+func (uc *mediaSessionService) add(session *entity.MediaSession) {
+	uc.mu.Lock()
+	uc.sessions[session.ID] = session
+	uc.mu.Unlock()
+}
+
+// remove - возвращает false, если сессию уже закрыли
+func (uc *mediaSessionService) remove(session *entity.MediaSession) bool {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if uc.sessions[session.ID] != session {
+		return false
+	}
+	delete(uc.sessions, session.ID)
+
+	return true
+}
+
+// find - сессию видит только ее владелец, для остальных ее не существует
+func (uc *mediaSessionService) find(meetingID, sessionID, ownerID string) (*entity.MediaSession, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	session, exists := uc.sessions[sessionID]
+	if !exists || session.MeetingID != meetingID || session.OwnerID != ownerID {
+		return nil, entity.ErrMediaSessionNotFound
+	}
+
+	return session, nil
+}
+
+// leave - участник WHIP покидает встречу вместе с закрытием его сессии
+func (uc *mediaSessionService) leave(ctx context.Context, session *entity.MediaSession) {
+	if session.Kind != entity.MediaSessionWHIP {
+		return
+	}
+
+	if err := uc.removeUser(ctx, session); err != nil {
+		return
+	}
+
+	uc.events.Publish(ctx, &entity.ParticipantDisconnectedEvent{
+		EventMeta: eventMeta(uc.clock, session.MeetingID),
+		UserID:    session.UserID,
+		UserName:  attendeeName(ctx, uc.meetingRepo, session.MeetingID, session.UserID),
+	})
+}
+
+func (uc *mediaSessionService) removeUser(ctx context.Context, session *entity.MediaSession) error {
+	return uc.meetingUC.LeaveMeeting(ctx, &entity.LeaveMeetingRequest{
+		MeetingID: session.MeetingID,
+		UserID:    session.UserID,
+	})
+}
+
+// mediaPeerID - ID соединения сессии в медиасервере
+func mediaPeerID(session *entity.MediaSession) string {
+	if session.Kind == entity.MediaSessionWHIP {
+		return session.UserID
+	}
+	return session.ID
+}
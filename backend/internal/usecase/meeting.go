@@ -2,19 +2,67 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
 )
 
+// MeetingConfig - параметры встреч
+type MeetingConfig struct {
+	// IdleTTL - сколько пустующая встреча живет до автоматического завершения
+	IdleTTL time.Duration
+	// GhostTTL - через сколько без подключения участник удаляется из встречи
+	GhostTTL time.Duration
+	// AttendanceTTL - сколько журнал посещаемости хранится после завершения встречи, 0 - бессрочно
+	AttendanceTTL time.Duration
+	// DefaultMode - режим медиа новой встречи, если клиент его не указал
+	DefaultMode string
+	// SFUEnabled - можно ли создавать встречи в режиме sfu
+	SFUEnabled bool
+}
+
 type meetingService struct {
-	meetingRepo MeetingRepo
+	meetingRepo     MeetingRepo
+	chatRepo        ChatRepo
+	recordings      RecordingUseCase
+	tokens          TokenManager
+	passcodes       PasscodeHasher
+	attempts        AttemptLimiter
+	meetingAttempts AttemptLimiter
+	broker          SignalingBroker
+	events          EventBus
+	clock           Clock
+	metrics         Metrics
+	cfg             MeetingConfig
+
+	// idleSince - с какого момента встреча пустует, ведется janitor'ом
+	idleSince map[string]time.Time
+	idleMu    sync.Mutex
+	// throttledMu - по одному сверяются коды встреч, лимит попыток которых исчерпан
+	throttledMu sync.Mutex
 }
 
-func NewMeetingService(meetingRepo MeetingRepo) *meetingService {
+// NewMeetingService - attempts ограничивает неверные коды доступа по встрече и адресу клиента,
+// meetingAttempts замедляет их проверку по встрече со всех адресов
+func NewMeetingService(meetingRepo MeetingRepo, chatRepo ChatRepo, recordings RecordingUseCase, tokens TokenManager, passcodes PasscodeHasher, attempts, meetingAttempts AttemptLimiter, broker SignalingBroker, events EventBus, clock Clock, metrics Metrics, cfg MeetingConfig) *meetingService {
 	return &meetingService{
-		meetingRepo: meetingRepo,
+		meetingRepo:     meetingRepo,
+		chatRepo:        chatRepo,
+		recordings:      recordings,
+		tokens:          tokens,
+		passcodes:       passcodes,
+		attempts:        attempts,
+		meetingAttempts: meetingAttempts,
+		broker:          broker,
+		events:          events,
+		clock:           clock,
+		metrics:         metrics,
+		cfg:             cfg,
+		idleSince:       make(map[string]time.Time),
 	}
 }
 
@@ -24,42 +72,117 @@ func (uc *meetingService) JoinMeeting(ctx context.Context, req *entity.JoinMeeti
 	if req.UserName == "" {
 		return nil, &entity.ValidationError{Field: "user_name", Reason: "is required"}
 	}
+	if req.UserID != "" && req.MeetingID == "" {
+		return nil, &entity.ValidationError{Field: "meeting_id", Reason: "is required to rejoin"}
+	}
+	// Прежний ID принимается только вместе с токеном этого пользователя
+	if req.UserID != "" && req.UserID != req.AuthUserID {
+		return nil, entity.ErrInvalidToken
+	}
 
 	var meetingID string
 	var meeting *entity.Meeting
 	var err error
 
-	if req.MeetingID == "" {
-		meetingID = entity.GenerateMeetingID()
-		meeting = &entity.Meeting{
-			ID:        meetingID,
-			Name:      "Untitled Meeting",
-			CreatedAt: time.Now(),
-			Users:     []entity.User{},
-		}
+	// Онлайн пользователь становится после подключения к WebSocket,
+	// иначе встреча без подключений никогда не будет считаться пустой
+	now := uc.clock.Now()
 
-		if err := uc.meetingRepo.CreateMeeting(ctx, meeting); err != nil {
-			return nil, fmt.Errorf("failed to create meeting: %w", err)
+	user := &entity.User{
+		ID:       entity.GenerateUserID(),
+		Name:     req.UserName,
+		JoinedAt: now,
+	}
+	rejoined := false
+
+	if req.MeetingID == "" {
+		meeting, err = uc.newMeeting(ctx, &entity.CreateMeetingRequest{
+			StartsAt:    req.StartsAt,
+			EndsAt:      req.EndsAt,
+			Mode:        req.Mode,
+			Lobby:       req.Lobby,
+			Passcode:    req.Passcode,
+			MeetingCode: req.MeetingCode,
+		}, user.ID, now)
+		if err != nil {
+			return nil, err
 		}
+		meetingID = meeting.ID
 	} else {
-		meetingID = req.MeetingID
+		meetingID, err = uc.ResolveMeetingID(ctx, req.MeetingID)
+		if err != nil {
+			return nil, err
+		}
+
 		meeting, err = uc.meetingRepo.GetMeeting(ctx, meetingID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get meeting: %w", err)
 		}
 		if meeting == nil {
-			return nil, fmt.Errorf("meeting not found: %s", meetingID)
+			return nil, entity.ErrMeetingNotFound
+		}
+		if meeting.EndsAt != nil && !now.Before(*meeting.EndsAt) {
+			return nil, entity.ErrMeetingEnded
+		}
+
+		waiting := false
+		if req.AuthUserID != "" {
+			if waiting, err = uc.isPending(ctx, meetingID, req.AuthUserID); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		// Участник вернулся под прежним ID, например после перезагрузки страницы.
+		// Новый пользователь не создается, проверки входа он уже прошел
+		case req.AuthUserID != "" && hasUser(meeting, req.AuthUserID):
+			user = memberByID(meeting, req.AuthUserID)
+			rejoined = true
+		// Ведущий встречи, созданной через CreateMeeting, занимает свое место
+		// без проверок, которые проходят гости. Из лобби возвращаются так же
+		case req.AuthUserID != "" && (req.AuthUserID == meeting.HostID || waiting):
+			user.ID = req.AuthUserID
+		default:
+			if err := uc.checkAdmission(meeting, req, now); err != nil {
+				return nil, err
+			}
+			// Ушедший участник входит заново, но сохраняет ID и историю сессий
+			if req.AuthUserID != "" {
+				user.ID = req.AuthUserID
+			}
 		}
 	}
 
-	user := &entity.User{
-		ID:       entity.GenerateUserID(),
-		Name:     req.UserName,
-		IsOnline: true,
+	// Создатель встречи становится ведущим и в лобби не ждет
+	pending := !rejoined && meeting.Lobby && meeting.HostID != user.ID
+	if pending {
+		if err := uc.waitInLobby(ctx, meeting, user); err != nil {
+			return nil, err
+		}
+
+		token, expiresAt, err := uc.tokens.Issue(meetingID, user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue token: %w", err)
+		}
+
+		// Участников встречи пользователь увидит, только когда его впустят
+		return &entity.JoinMeetingResponse{
+			MeetingID:      meetingID,
+			MeetingCode:    meeting.Code,
+			MeetingName:    meeting.Name,
+			Mode:           meeting.Mode,
+			UserID:         user.ID,
+			UsersInMeeting: []string{},
+			Token:          token,
+			TokenExpiresAt: expiresAt,
+			Pending:        true,
+		}, nil
 	}
 
-	if err := uc.meetingRepo.AddUserToMeeting(ctx, meetingID, user); err != nil {
-		return nil, fmt.Errorf("failed to add user to meeting: %w", err)
+	if !rejoined {
+		if err := uc.meetingRepo.AddUserToMeeting(ctx, meetingID, user); err != nil {
+			return nil, fmt.Errorf("failed to add user to meeting: %w", err)
+		}
 	}
 
 	users, err := uc.meetingRepo.GetMeetingUsers(ctx, meetingID)
@@ -72,11 +195,29 @@ func (uc *meetingService) JoinMeeting(ctx context.Context, req *entity.JoinMeeti
 		userNames = append(userNames, u.Name)
 	}
 
+	token, expiresAt, err := uc.tokens.Issue(meetingID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue token: %w", err)
+	}
+
+	if !rejoined {
+		uc.metrics.UserJoined()
+		uc.events.Publish(ctx, &entity.ParticipantAddedEvent{
+			EventMeta: entity.EventMeta{MeetingID: meetingID, At: now},
+			User:      *user,
+		})
+	}
+
 	response := &entity.JoinMeetingResponse{
 		MeetingID:      meetingID,
+		MeetingCode:    meeting.Code,
 		MeetingName:    meeting.Name,
+		Mode:           meeting.Mode,
 		UserID:         user.ID,
 		UsersInMeeting: userNames,
+		Token:          token,
+		TokenExpiresAt: expiresAt,
+		Rejoined:       rejoined,
 	}
 
 	return response, nil
@@ -91,6 +232,9 @@ func (uc *meetingService) GetMeetingInfo(ctx context.Context, meetingID string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get meeting: %w", err)
 	}
+	if meeting != nil {
+		meeting.PasscodeRequired = meeting.PasscodeHash != ""
+	}
 
 	return meeting, nil
 }
@@ -103,17 +247,89 @@ func (uc *meetingService) LeaveMeeting(ctx context.Context, req *entity.LeaveMee
 		return &entity.ValidationError{Field: "user_id", Reason: "is required"}
 	}
 
-	if err := uc.meetingRepo.SetUserOnlineStatus(ctx, req.MeetingID, req.UserID, false); err != nil {
-		return fmt.Errorf("failed to set user offline: %w", err)
+	left, err := uc.leaveLobby(ctx, req.MeetingID, req.UserID)
+	if err != nil {
+		return err
+	}
+	if left {
+		return nil
+	}
+
+	if err := uc.meetingRepo.EndUserSession(ctx, req.MeetingID, req.UserID, uc.clock.Now()); err != nil {
+		return fmt.Errorf("failed to end user session: %w", err)
 	}
 
 	if err := uc.meetingRepo.RemoveUserFromMeeting(ctx, req.MeetingID, req.UserID); err != nil {
 		return fmt.Errorf("failed to remove user from meeting: %w", err)
 	}
 
+	uc.metrics.UserLeft(leaveReasonLeft)
+	uc.events.Publish(ctx, &entity.ParticipantRemovedEvent{
+		EventMeta: eventMeta(uc.clock, req.MeetingID),
+		UserID:    req.UserID,
+		Reason:    entity.RemoveReasonLeft,
+	})
+
+	return uc.handOverHost(ctx, req.MeetingID, req.UserID)
+}
+
+// validateSchedule - проверяет расписание новой встречи
+func validateSchedule(startsAt, endsAt *time.Time, now time.Time) error {
+	if endsAt == nil {
+		return nil
+	}
+
+	if !endsAt.After(now) {
+		return &entity.ValidationError{Field: "ends_at", Reason: "must be in the future"}
+	}
+
+	if startsAt != nil && !endsAt.After(*startsAt) {
+		return &entity.ValidationError{Field: "ends_at", Reason: "must be after starts_at"}
+	}
+
 	return nil
 }
 
+// meetingMode - режим новой встречи: запрошенный клиентом или режим по умолчанию
+func (uc *meetingService) meetingMode(requested string) (string, error) {
+	switch requested {
+	case "":
+		return uc.cfg.DefaultMode, nil
+	case entity.MeetingModeMesh:
+		return requested, nil
+	case entity.MeetingModeSFU:
+		if !uc.cfg.SFUEnabled {
+			return "", &entity.ValidationError{Field: "mode", Reason: "sfu is disabled on this server"}
+		}
+		return requested, nil
+	default:
+		return "", &entity.ValidationError{Field: "mode", Reason: "must be mesh or sfu"}
+	}
+}
+
+// handOverHost - если встречу покинул ведущий, роль переходит к первому оставшемуся участнику
+func (uc *meetingService) handOverHost(ctx context.Context, meetingID, leftUserID string) error {
+	meeting, err := uc.meetingRepo.GetMeeting(ctx, meetingID)
+	if err != nil {
+		return fmt.Errorf("failed to get meeting: %w", err)
+	}
+
+	if meeting == nil || meeting.HostID != leftUserID || len(meeting.Users) == 0 {
+		return nil
+	}
+
+	// Роль достается участнику онлайн, а не тому, кто закрыл вкладку и еще не удален
+	next := meeting.Users[0].ID
+	for _, user := range meeting.Users {
+		if user.IsOnline {
+			next = user.ID
+			break
+		}
+	}
+
+	return uc.changeHost(ctx, meetingID, leftUserID, next)
+}
+
 func (uc *meetingService) GetOnlineUsers(ctx context.Context, meetingID string) ([]string, error) {
 	if meetingID == "" {
 		return nil, &entity.ValidationError{Field: "meeting_id", Reason: "is required"}
@@ -133,3 +349,199 @@ func (uc *meetingService) GetOnlineUsers(ctx context.Context, meetingID string)
 
 	return onlineUsers, nil
 }
+
+// Authenticate - проверяет токен участника и его привязку к встрече и пользователю.
+// Пустой userID означает, что пользователь берется из токена
+func (uc *meetingService) Authenticate(ctx context.Context, token, meetingID, userID string) (*entity.TokenClaims, error) {
+	if token == "" {
+		return nil, entity.ErrInvalidToken
+	}
+
+	claims, err := uc.tokens.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.MeetingID != meetingID || (userID != "" && claims.UserID != userID) {
+		return nil, entity.ErrTokenMismatch
+	}
+
+	return claims, nil
+}
+
+// CheckMembership - проверяет, что встреча существует и пользователь в ней состоит
+func (uc *meetingService) CheckMembership(ctx context.Context, meetingID, userID string) error {
+	meeting, err := uc.meetingRepo.GetMeeting(ctx, meetingID)
+	if err != nil {
+		return fmt.Errorf("failed to get meeting: %w", err)
+	}
+	if meeting == nil {
+		return entity.ErrMeetingNotFound
+	}
+
+	if hasUser(meeting, userID) {
+		return nil
+	}
+
+	pending, err := uc.isPending(ctx, meetingID, userID)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return entity.ErrNotAdmitted
+	}
+
+	return entity.ErrNotMember
+}
+
+// isPending - ждет ли пользователь в лобби встречи
+func (uc *meetingService) isPending(ctx context.Context, meetingID, userID string) (bool, error) {
+	pending, err := uc.meetingRepo.GetPendingUsers(ctx, meetingID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get pending users: %w", err)
+	}
+
+	for _, user := range pending {
+		if user.ID == userID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Допустимая длина кода доступа, bcrypt учитывает только первые 72 байта
+const (
+	_minPasscodeLength = 4
+	_maxPasscodeLength = 72
+)
+
+// checkAdmission - может ли гость войти во встречу сейчас
+func (uc *meetingService) checkAdmission(meeting *entity.Meeting, req *entity.JoinMeetingRequest, now time.Time) error {
+	if meeting.Locked {
+		return entity.ErrMeetingLocked
+	}
+	if meeting.StartsAt != nil && now.Before(*meeting.StartsAt) {
+		return entity.ErrMeetingNotStarted
+	}
+	if meetingFull(meeting, len(meeting.Users)) {
+		return entity.ErrMeetingFull
+	}
+
+	return uc.checkPasscode(meeting, req.Passcode, req.ClientIP)
+}
+
+// meetingFull - заняты ли все места встречи, если в ней seats участников
+func meetingFull(meeting *entity.Meeting, seats int) bool {
+	return meeting.Capacity > 0 && seats >= meeting.Capacity
+}
+
+// hashPasscode - хеш кода доступа новой встречи, пустой код - встреча без кода
+func (uc *meetingService) hashPasscode(passcode string) (string, error) {
+	if passcode == "" {
+		return "", nil
+	}
+
+	if len(passcode) < _minPasscodeLength || len(passcode) > _maxPasscodeLength {
+		return "", &entity.ValidationError{
+			Field:  "passcode",
+			Reason: fmt.Sprintf("must be %d-%d bytes long", _minPasscodeLength, _maxPasscodeLength),
+		}
+	}
+
+	return uc.passcodes.Hash(passcode)
+}
+
+// checkPasscode - проверяет код доступа. Неверные попытки с одного адреса блокируют
+// его до конца блокировки, даже с верным кодом. Лимит встречи в целом вход не
+// закрывает, а только замедляет перебор с разных адресов: пока он исчерпан, коды
+// сверяются по одному. Попытка засчитывается до сравнения, поэтому параллельные
+// запросы не проверяют кодов больше лимита
+func (uc *meetingService) checkPasscode(meeting *entity.Meeting, passcode, clientIP string) error {
+	if meeting.PasscodeHash == "" {
+		return nil
+	}
+
+	if passcode == "" {
+		return entity.ErrPasscodeRequired
+	}
+
+	// Попытку с адреса засчитывает только прошедший лимит встречи запрос, а попытку
+	// встречи отклоненный по адресу запрос возвращает
+	throttled := uc.meetingAttempts.Allow(meeting.ID) > 0
+	key := meeting.ID + "|" + clientIP
+	if retryAfter := uc.attempts.Allow(key); retryAfter > 0 {
+		if !throttled {
+			uc.meetingAttempts.Release(meeting.ID)
+		}
+		return &entity.AttemptsExceededError{RetryAfter: retryAfter}
+	}
+
+	if !uc.comparePasscode(meeting.PasscodeHash, passcode, throttled) {
+		return entity.ErrInvalidPasscode
+	}
+
+	// Удачная попытка не расходует лимит встречи, иначе вход участников большой
+	// встречи замедлил бы ее
+	uc.attempts.Reset(key)
+	if !throttled {
+		uc.meetingAttempts.Release(meeting.ID)
+	}
+	return nil
+}
+
+// comparePasscode - сравнение хеша дорогое, поэтому коды встреч с исчерпанным
+// лимитом сверяются по одному на весь сервер
+func (uc *meetingService) comparePasscode(hash, passcode string, throttled bool) bool {
+	if throttled {
+		uc.throttledMu.Lock()
+		defer uc.throttledMu.Unlock()
+	}
+	return uc.passcodes.Compare(hash, passcode)
+}
+
+// _codeAttempts - сколько случайных кодов пробовать, если код уже занят
+const _codeAttempts = 5
+
+// createMeeting - сохраняет встречу с выбранным кодом или подбирает свободный случайный
+func (uc *meetingService) createMeeting(ctx context.Context, meeting *entity.Meeting, vanityCode string) error {
+	if vanityCode != "" {
+		meeting.Code = vanityCode
+		if err := uc.meetingRepo.CreateMeeting(ctx, meeting); err != nil {
+			if errors.Is(err, entity.ErrMeetingCodeTaken) {
+				return err
+			}
+			return fmt.Errorf("failed to create meeting: %w", err)
+		}
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		meeting.Code = entity.GenerateMeetingCode()
+
+		err := uc.meetingRepo.CreateMeeting(ctx, meeting)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, entity.ErrMeetingCodeTaken) || attempt == _codeAttempts {
+			return fmt.Errorf("failed to create meeting: %w", err)
+		}
+	}
+}
+
+// ResolveMeetingID - ID встречи по ID или коду. Код сравнивается без учета регистра
+func (uc *meetingService) ResolveMeetingID(ctx context.Context, meetingRef string) (string, error) {
+	if entity.IsMeetingID(meetingRef) {
+		return meetingRef, nil
+	}
+
+	meetingID, err := uc.meetingRepo.GetMeetingIDByCode(ctx, strings.ToLower(strings.TrimSpace(meetingRef)))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve meeting code: %w", err)
+	}
+	if meetingID == "" {
+		return "", entity.ErrMeetingNotFound
+	}
+
+	return meetingID, nil
+}
@@ -0,0 +1,15 @@
+package metrics
+
+import "time"
+
+// Nop - метрики, которые никуда не отдаются. Нужны тестам, где счетчики не проверяются
+type Nop struct{}
+
+func (Nop) SetActiveMeetings(count int)                                                 {}
+func (Nop) ConnectionOpened()                                                           {}
+func (Nop) ConnectionClosed()                                                           {}
+func (Nop) MessageRelayed(messageType string)                                           {}
+func (Nop) MessageDropped(messageType, reason string)                                   {}
+func (Nop) UserJoined()                                                                 {}
+func (Nop) UserLeft(reason string)                                                      {}
+func (Nop) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {}
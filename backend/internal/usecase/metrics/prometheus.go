@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "zvonim"
+
+// Prometheus - метрики сервера в формате Prometheus.
+// Гейджи считаются по соединениям этой реплики
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	activeMeetings    prometheus.Gauge
+	activeConnections prometheus.Gauge
+	messagesRelayed   *prometheus.CounterVec
+	messagesDropped   *prometheus.CounterVec
+	joins             prometheus.Counter
+	leaves            *prometheus.CounterVec
+	httpDuration      *prometheus.HistogramVec
+}
+
+func NewPrometheus() *Prometheus {
+	m := &Prometheus{
+		registry: prometheus.NewRegistry(),
+		activeMeetings: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Name:      "active_meetings",
+			Help:      "Meetings with at least one websocket connection on this replica.",
+		}),
+		activeConnections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Name:      "websocket_connections",
+			Help:      "Open websocket connections on this replica.",
+		}),
+		messagesRelayed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "signaling_messages_relayed_total",
+			Help:      "Signaling messages accepted from clients and relayed, by message type.",
+		}, []string{"type"}),
+		messagesDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "signaling_messages_dropped_total",
+			Help:      "Signaling messages that were rejected or could not be delivered, by message type and reason.",
+		}, []string{"type", "reason"}),
+		joins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "meeting_joins_total",
+			Help:      "Users joined meetings.",
+		}),
+		leaves: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Name:      "meeting_leaves_total",
+			Help:      "Users removed from meetings, by reason.",
+		}, []string{"reason"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP handler latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.activeMeetings,
+		m.activeConnections,
+		m.messagesRelayed,
+		m.messagesDropped,
+		m.joins,
+		m.leaves,
+		m.httpDuration,
+	)
+
+	return m
+}
+
+// Handler - HTTP обработчик для сбора метрик
+func (m *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Prometheus) SetActiveMeetings(count int) {
+	m.activeMeetings.Set(float64(count))
+}
+
+func (m *Prometheus) ConnectionOpened() {
+	m.activeConnections.Inc()
+}
+
+func (m *Prometheus) ConnectionClosed() {
+	m.activeConnections.Dec()
+}
+
+func (m *Prometheus) MessageRelayed(messageType string) {
+	m.messagesRelayed.WithLabelValues(messageType).Inc()
+}
+
+func (m *Prometheus) MessageDropped(messageType, reason string) {
+	m.messagesDropped.WithLabelValues(messageType, reason).Inc()
+}
+
+func (m *Prometheus) UserJoined() {
+	m.joins.Inc()
+}
+
+func (m *Prometheus) UserLeft(reason string) {
+	m.leaves.WithLabelValues(reason).Inc()
+}
+
+func (m *Prometheus) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
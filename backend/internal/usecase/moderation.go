@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+)
+
+// KickUser - ведущий удаляет участника из встречи и разрывает его соединение
+func (uc *meetingService) KickUser(ctx context.Context, meetingID, hostID, targetID string) error {
+	if _, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, targetID); err != nil {
+		return err
+	}
+
+	if targetID == hostID {
+		return &entity.ValidationError{Field: "user_id", Reason: "host can't kick themselves"}
+	}
+
+	if err := uc.meetingRepo.RemoveUserFromMeeting(ctx, meetingID, targetID); err != nil {
+		return fmt.Errorf("failed to remove user from meeting: %w", err)
+	}
+
+	uc.metrics.UserLeft(leaveReasonKicked)
+
+	uc.events.Publish(ctx, &entity.ParticipantRemovedEvent{
+		EventMeta: eventMeta(uc.clock, meetingID),
+		UserID:    targetID,
+		Reason:    entity.RemoveReasonKicked,
+		By:        hostID,
+	})
+
+	return nil
+}
+
+// RequestMute - ведущий просит участника выключить микрофон. Сервер не управляет
+// медиа, решение остается за клиентом. Просьба не меняет состояние встречи,
+// поэтому идет адресату напрямую через брокер, а не событием
+func (uc *meetingService) RequestMute(ctx context.Context, meetingID, hostID, targetID string) error {
+	if _, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, targetID); err != nil {
+		return err
+	}
+
+	if err := publishMessage(ctx, uc.broker, meetingID, targetID, protocol.NewMuteRequest(targetID, hostID)); err != nil {
+		return fmt.Errorf("failed to send mute request: %w", err)
+	}
+
+	return nil
+}
+
+// SetMeetingLocked - ведущий закрывает встречу для новых участников или открывает ее
+func (uc *meetingService) SetMeetingLocked(ctx context.Context, meetingID, hostID string, locked bool) error {
+	if _, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, ""); err != nil {
+		return err
+	}
+
+	if err := uc.meetingRepo.SetMeetingLocked(ctx, meetingID, locked); err != nil {
+		return fmt.Errorf("failed to set meeting lock: %w", err)
+	}
+
+	uc.events.Publish(ctx, &entity.MeetingLockedEvent{
+		EventMeta: eventMeta(uc.clock, meetingID),
+		Locked:    locked,
+		By:        hostID,
+	})
+
+	return nil
+}
+
+// TransferHost - ведущий передает роль другому участнику
+func (uc *meetingService) TransferHost(ctx context.Context, meetingID, hostID, targetID string) error {
+	if _, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, targetID); err != nil {
+		return err
+	}
+
+	if targetID == hostID {
+		return nil
+	}
+
+	return uc.changeHost(ctx, meetingID, hostID, targetID)
+}
+
+func (uc *meetingService) changeHost(ctx context.Context, meetingID, previousHostID, hostID string) error {
+	if err := uc.meetingRepo.SetMeetingHost(ctx, meetingID, hostID); err != nil {
+		return fmt.Errorf("failed to set meeting host: %w", err)
+	}
+
+	uc.events.Publish(ctx, &entity.HostChangedEvent{
+		EventMeta:      eventMeta(uc.clock, meetingID),
+		HostID:         hostID,
+		PreviousHostID: previousHostID,
+	})
+
+	return nil
+}
+
+// hostMeeting - загружает встречу и проверяет, что действие выполняет ведущий,
+// а участник, над которым оно выполняется (если задан), состоит во встрече
+func hostMeeting(ctx context.Context, meetingRepo MeetingRepo, meetingID, hostID, targetID string) (*entity.Meeting, error) {
+	if meetingID == "" {
+		return nil, &entity.ValidationError{Field: "meeting_id", Reason: "is required"}
+	}
+
+	meeting, err := meetingRepo.GetMeeting(ctx, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meeting: %w", err)
+	}
+	if meeting == nil {
+		return nil, entity.ErrMeetingNotFound
+	}
+
+	if meeting.HostID != hostID {
+		return nil, entity.ErrNotHost
+	}
+
+	if targetID != "" && !hasUser(meeting, targetID) {
+		return nil, entity.ErrUserNotFound
+	}
+
+	return meeting, nil
+}
+
+func hasUser(meeting *entity.Meeting, userID string) bool {
+	return memberByID(meeting, userID) != nil
+}
+
+// memberByID - участник встречи, nil, если пользователь в ней не состоит
+func memberByID(meeting *entity.Meeting, userID string) *entity.User {
+	for i := range meeting.Users {
+		if meeting.Users[i].ID == userID {
+			return &meeting.Users[i]
+		}
+	}
+	return nil
+}
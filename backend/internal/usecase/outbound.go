@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// OverflowPolicy - что делать, когда клиент не успевает читать и очередь отправки заполнена
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest - выбросить самое старое сообщение из очереди
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDisconnect - отключить медленного клиента
+	OverflowDisconnect OverflowPolicy = "disconnect"
+)
+
+type closeRequest struct {
+	code   int
+	reason string
+}
+
+// outboundQueue - ограниченная очередь отправки одного соединения.
+// Писатель - отдельная горутина, поэтому медленный клиент не задерживает
+// рассылку остальным и не держит блокировку сервиса
+type outboundQueue struct {
+	conn     WSConnection
+	messages chan []byte
+	closing  chan closeRequest
+	done     chan struct{}
+	stopOnce sync.Once
+	policy   OverflowPolicy
+	metrics  Metrics
+}
+
+func newOutboundQueue(conn WSConnection, size int, policy OverflowPolicy, metrics Metrics) *outboundQueue {
+	q := &outboundQueue{
+		conn:     conn,
+		messages: make(chan []byte, size),
+		closing:  make(chan closeRequest, 1),
+		done:     make(chan struct{}),
+		policy:   policy,
+		metrics:  metrics,
+	}
+
+	go q.run()
+
+	return q
+}
+
+// push - ставит сообщение в очередь не блокируясь. Производитель один - deliver под uc.mu,
+// поэтому между выбросом старого сообщения и записью нового место никто не займет
+func (q *outboundQueue) push(message []byte) {
+	select {
+	case q.messages <- message:
+		return
+	default:
+	}
+
+	if q.policy == OverflowDisconnect {
+		q.metrics.MessageDropped(outboundMessageType, dropReasonSlowConsumer)
+		q.close(entity.CloseSlowConsumer, "send queue overflow")
+		return
+	}
+
+	select {
+	case <-q.messages:
+		q.metrics.MessageDropped(outboundMessageType, dropReasonQueueOverflow)
+	default:
+	}
+
+	select {
+	case q.messages <- message:
+	default:
+		q.metrics.MessageDropped(outboundMessageType, dropReasonQueueOverflow)
+	}
+}
+
+// close - закрывает соединение после отправки уже поставленных в очередь сообщений
+func (q *outboundQueue) close(code int, reason string) {
+	select {
+	case q.closing <- closeRequest{code: code, reason: reason}:
+	default:
+	}
+}
+
+// stop - останавливает писателя, соединение уже закрыто
+func (q *outboundQueue) stop() {
+	q.stopOnce.Do(func() {
+		close(q.done)
+	})
+}
+
+func (q *outboundQueue) run() {
+	for {
+		select {
+		case <-q.done:
+			return
+		case message := <-q.messages:
+			if err := q.conn.WriteMessage(1, message); err != nil {
+				_ = q.conn.Close()
+				return
+			}
+		case req := <-q.closing:
+			q.flush()
+			_ = q.conn.CloseWithReason(req.code, req.reason)
+			return
+		}
+	}
+}
+
+// flush - дописывает то, что уже лежит в очереди
+func (q *outboundQueue) flush() {
+	for {
+		select {
+		case message := <-q.messages:
+			if err := q.conn.WriteMessage(1, message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/broker"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/metrics"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+// slowConn - клиент, который не читает, пока его не отпустят: первая запись
+// блокируется до release, а записанные сообщения копятся в порядке отправки
+type slowConn struct {
+	release   chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeCode int
+	written   [][]byte
+	mu        sync.Mutex
+}
+
+func newSlowConn() *slowConn {
+	return &slowConn{release: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (c *slowConn) ReadMessage() ([]byte, error) {
+	<-c.closed
+	return nil, errFakeConnClosed
+}
+
+func (c *slowConn) WriteMessage(messageType int, data []byte) error {
+	select {
+	case <-c.release:
+	case <-c.closed:
+		return errFakeConnClosed
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.written = append(c.written, data)
+	return nil
+}
+
+func (c *slowConn) CloseWithReason(code int, reason string) error {
+	c.mu.Lock()
+	c.closeCode = code
+	c.mu.Unlock()
+
+	return c.Close()
+}
+
+func (c *slowConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
+
+func (c *slowConn) messages() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages := make([]string, len(c.written))
+	for i, data := range c.written {
+		messages[i] = string(data)
+	}
+	return messages
+}
+
+// fillQueue - ставит в очередь count сообщений, пока писатель завис на первом
+func fillQueue(q *outboundQueue, count int) {
+	q.push([]byte("stuck"))
+	// Писатель забрал первое сообщение и ждет клиента, очередь пуста
+	for len(q.messages) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < count; i++ {
+		q.push([]byte(fmt.Sprintf("m%d", i)))
+	}
+}
+
+func TestOutboundQueueDropOldest(t *testing.T) {
+	conn := newSlowConn()
+	q := newOutboundQueue(conn, 3, OverflowDropOldest, metrics.Nop{})
+	defer q.stop()
+
+	fillQueue(q, 5)
+
+	select {
+	case <-conn.closed:
+		t.Fatal("slow consumer was disconnected under drop_oldest")
+	default:
+	}
+
+	close(conn.release)
+	waitFor(t, func() bool { return len(conn.messages()) == 4 })
+
+	// Самые старые сообщения выброшены, порядок оставшихся сохранен
+	want := []string{"stuck", "m2", "m3", "m4"}
+	got := conn.messages()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got messages %v, want %v", got, want)
+		}
+	}
+}
+
+func TestOutboundQueueDisconnect(t *testing.T) {
+	conn := newSlowConn()
+	q := newOutboundQueue(conn, 3, OverflowDisconnect, metrics.Nop{})
+	defer q.stop()
+
+	fillQueue(q, 4)
+
+	// Писатель закрывает соединение, как только дописывает то, что уже в очереди
+	close(conn.release)
+
+	select {
+	case <-conn.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow consumer was not disconnected")
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.closeCode != entity.CloseSlowConsumer {
+		t.Fatalf("got close code %d, want %d", conn.closeCode, entity.CloseSlowConsumer)
+	}
+	if len(conn.written) != 4 {
+		t.Fatalf("got %d messages written before close, want 4", len(conn.written))
+	}
+}
+
+func TestBroadcastIsNotBlockedBySlowConsumer(t *testing.T) {
+	f := newSessionFixture(t, 1)
+
+	alice, _ := f.connect(t, 0, "alice", "")
+
+	// Боб не читает, его очередь переполняется, но alice получает каждое сообщение
+	slow := newSlowConn()
+	defer slow.Close()
+	go f.replicas[0].HandleConnection(context.Background(), slow, &entity.WSSession{MeetingID: f.meeting.ID, UserID: "bob"})
+	alice.waitMessage(t, "user_joined", "bob")
+
+	for i := 0; i < 40; i++ {
+		if err := f.replicas[0].BroadcastToMeeting(f.meeting.ID, &entity.WSMessage{Type: "ping", From: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("broadcast: %v", err)
+		}
+		alice.waitMessage(t, "ping", fmt.Sprint(i))
+	}
+}
+
+// BenchmarkBroadcast1000Peers - время рассылки одного сообщения 1000 участникам,
+// от публикации до записи в соединение последнего из них
+func BenchmarkBroadcast1000Peers(b *testing.B) {
+	const peers = 1000
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fakeClock := clock.NewFake(time.Now())
+	meetingRepo := repo.NewMemoryMeetingRepository()
+	meeting := &entity.Meeting{ID: entity.GenerateMeetingID(), Code: entity.GenerateMeetingCode(), CreatedAt: fakeClock.Now()}
+	if err := meetingRepo.CreateMeeting(ctx, meeting); err != nil {
+		b.Fatalf("create meeting: %v", err)
+	}
+
+	uc := NewWebSocketService(meetingRepo, stubMeetingUC{}, stubChatUC{}, stubRecordingUC{}, broker.NewMemoryBroker(), nil, NewEventBus(), fakeClock, metrics.Nop{}, SessionConfig{
+		ResumeQueueSize: 16,
+		// Очередь вмещает все сообщения о входе, поэтому сообщения бенчмарка не выбрасываются
+		SendQueueSize:  2 * peers,
+		OverflowPolicy: OverflowDropOldest,
+	})
+	if err := uc.Start(ctx); err != nil {
+		b.Fatalf("start websocket service: %v", err)
+	}
+
+	var delivered sync.WaitGroup
+	conns := make([]*countingConn, peers)
+	for i := range conns {
+		userID := fmt.Sprintf("peer-%d", i)
+		if err := meetingRepo.AddUserToMeeting(ctx, meeting.ID, &entity.User{ID: userID, Name: userID}); err != nil {
+			b.Fatalf("add user: %v", err)
+		}
+
+		conns[i] = &countingConn{closed: make(chan struct{}), delivered: &delivered}
+		go uc.HandleConnection(ctx, conns[i], &entity.WSSession{MeetingID: meeting.ID, UserID: userID})
+	}
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+
+	// Ждем, пока подключатся все участники
+	for waiting := true; waiting; {
+		time.Sleep(10 * time.Millisecond)
+		uc.mu.Lock()
+		waiting = len(uc.sessions[meeting.ID]) < peers
+		uc.mu.Unlock()
+	}
+
+	message := &entity.WSMessage{Type: _benchMessageType}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		delivered.Add(peers)
+		if err := uc.BroadcastToMeeting(meeting.ID, message); err != nil {
+			b.Fatalf("broadcast: %v", err)
+		}
+		delivered.Wait()
+	}
+}
+
+const _benchMessageType = "bench"
+
+// countingConn - клиент, который мгновенно читает. Каждая запись сообщения
+// бенчмарка отмечается в delivered, служебные сообщения о входе не считаются
+type countingConn struct {
+	closed    chan struct{}
+	closeOnce sync.Once
+	delivered *sync.WaitGroup
+}
+
+func (c *countingConn) ReadMessage() ([]byte, error) {
+	<-c.closed
+	return nil, errFakeConnClosed
+}
+
+func (c *countingConn) WriteMessage(messageType int, data []byte) error {
+	if bytes.Contains(data, []byte(`"type":"`+_benchMessageType+`"`)) {
+		c.delivered.Done()
+	}
+	return nil
+}
+
+func (c *countingConn) CloseWithReason(code int, reason string) error {
+	return c.Close()
+}
+
+func (c *countingConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+	return nil
+}
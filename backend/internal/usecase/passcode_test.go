@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/ratelimit"
+)
+
+const _testPasscode = "123456"
+
+// slowHasher - сравнение кода занимает время, как у bcrypt. Считаются все сравнения
+// и наибольшее число одновременных
+type slowHasher struct {
+	PasscodeHasher
+	compares    atomic.Int32
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (h *slowHasher) Compare(hash, passcode string) bool {
+	h.compares.Add(1)
+	inFlight := h.inFlight.Add(1)
+	defer h.inFlight.Add(-1)
+
+	for {
+		top := h.maxInFlight.Load()
+		if inFlight <= top || h.maxInFlight.CompareAndSwap(top, inFlight) {
+			break
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	return passcode == _testPasscode
+}
+
+func newPasscodeService(hasher PasscodeHasher, perClient, perMeeting int) *meetingService {
+	return NewMeetingService(nil, nil, nil, nil, hasher,
+		ratelimit.NewMemoryAttemptLimiter(SystemClock(), perClient, time.Minute),
+		ratelimit.NewMemoryAttemptLimiter(SystemClock(), perMeeting, time.Minute),
+		nil, nil, nil, nil, MeetingConfig{})
+}
+
+// checkConcurrently - проверяет код из requests горутин, clientIP(i) - адрес i-го запроса
+func checkConcurrently(uc *meetingService, meeting *entity.Meeting, requests int, passcode string, clientIP func(i int) string) []error {
+	errs := make([]error, requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = uc.checkPasscode(meeting, passcode, clientIP(i))
+		}()
+	}
+	wg.Wait()
+
+	return errs
+}
+
+func TestCheckPasscodeConcurrentAttempts(t *testing.T) {
+	hasher := &slowHasher{}
+	uc := newPasscodeService(hasher, 5, 100)
+	meeting := &entity.Meeting{ID: "meeting-1", PasscodeHash: "hash"}
+
+	// Параллельные запросы не успевают проверить больше кодов, чем разрешено
+	errs := checkConcurrently(uc, meeting, 50, "000000", func(int) string { return "10.0.0.1" })
+	if compares := hasher.compares.Load(); compares != 5 {
+		t.Fatalf("compared %d passcodes, want 5", compares)
+	}
+
+	invalid, exceeded := countPasscodeErrors(t, errs)
+	if invalid != 5 || exceeded != 45 {
+		t.Fatalf("got %d invalid and %d exceeded, want 5 and 45", invalid, exceeded)
+	}
+
+	if err := uc.checkPasscode(meeting, _testPasscode, "10.0.0.1"); !isAttemptsExceeded(err) {
+		t.Fatalf("got %v for the right passcode while blocked, want attempts exceeded", err)
+	}
+}
+
+func TestCheckPasscodeThrottlesMeetingAcrossAddresses(t *testing.T) {
+	hasher := &slowHasher{}
+	uc := newPasscodeService(hasher, 5, 10)
+	meeting := &entity.Meeting{ID: "meeting-1", PasscodeHash: "hash"}
+
+	// Каждый запрос с нового адреса, лимит по адресу не срабатывает, а лимит встречи
+	// исчерпывается
+	for i := 0; i < 10; i++ {
+		if err := uc.checkPasscode(meeting, "000000", fmt.Sprintf("10.0.0.%d", i)); !errors.Is(err, entity.ErrInvalidPasscode) {
+			t.Fatalf("attempt %d: got %v, want invalid passcode", i, err)
+		}
+	}
+
+	// Дальше коды встречи сверяются по одному, но никто не блокируется
+	hasher.maxInFlight.Store(0)
+	errs := checkConcurrently(uc, meeting, 20, "000000", func(i int) string { return fmt.Sprintf("10.0.1.%d", i) })
+	if invalid, exceeded := countPasscodeErrors(t, errs); invalid != 20 || exceeded != 0 {
+		t.Fatalf("got %d invalid and %d exceeded, want 20 and 0", invalid, exceeded)
+	}
+	if inFlight := hasher.maxInFlight.Load(); inFlight != 1 {
+		t.Fatalf("compared %d passcodes at once while throttled, want 1", inFlight)
+	}
+
+	// Участник с верным кодом входит и во время перебора
+	errs = checkConcurrently(uc, meeting, 10, _testPasscode, func(i int) string { return fmt.Sprintf("192.168.0.%d", i) })
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("participant %d: got %v with the right passcode, want it allowed", i, err)
+		}
+	}
+
+	// Лимит одной встречи не задевает другие
+	other := &entity.Meeting{ID: "meeting-2", PasscodeHash: "hash"}
+	errs = checkConcurrently(uc, other, 5, _testPasscode, func(i int) string { return fmt.Sprintf("192.168.1.%d", i) })
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("participant %d of another meeting: %v", i, err)
+		}
+	}
+}
+
+func TestCheckPasscodeBlockedAddressDoesNotSpendMeetingLimit(t *testing.T) {
+	uc := newPasscodeService(&slowHasher{}, 2, 3)
+	meeting := &entity.Meeting{ID: "meeting-1", PasscodeHash: "hash"}
+
+	for i := 0; i < 10; i++ {
+		_ = uc.checkPasscode(meeting, "000000", "10.0.0.1")
+	}
+
+	// Засчитаны только две попытки, проверенные до блокировки адреса
+	if retryAfter := uc.meetingAttempts.Allow(meeting.ID); retryAfter != 0 {
+		t.Fatalf("meeting limit blocked after two compared attempts, retry after %s", retryAfter)
+	}
+	if retryAfter := uc.meetingAttempts.Allow(meeting.ID); retryAfter == 0 {
+		t.Fatal("meeting limit allows more attempts than configured")
+	}
+}
+
+func TestCheckPasscodeSuccessDoesNotSpendMeetingLimit(t *testing.T) {
+	uc := newPasscodeService(&slowHasher{}, 5, 2)
+	meeting := &entity.Meeting{ID: "meeting-1", PasscodeHash: "hash"}
+
+	errs := checkConcurrently(uc, meeting, 20, _testPasscode, func(i int) string { return fmt.Sprintf("10.0.0.%d", i) })
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+
+	// После входа попытки встречи возвращаются
+	if retryAfter := uc.meetingAttempts.Allow(meeting.ID); retryAfter != 0 {
+		t.Fatalf("meeting limit spent by successful attempts, retry after %s", retryAfter)
+	}
+
+	if err := uc.checkPasscode(meeting, "", "10.0.2.1"); !errors.Is(err, entity.ErrPasscodeRequired) {
+		t.Fatalf("got %v without passcode, want passcode required", err)
+	}
+}
+
+func countPasscodeErrors(t *testing.T, errs []error) (invalid, exceeded int) {
+	t.Helper()
+
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, entity.ErrInvalidPasscode):
+			invalid++
+		case isAttemptsExceeded(err):
+			exceeded++
+		default:
+			t.Fatalf("got unexpected error %v", err)
+		}
+	}
+	return invalid, exceeded
+}
+
+func isAttemptsExceeded(err error) bool {
+	var exceeded *entity.AttemptsExceededError
+	return errors.As(err, &exceeded) && exceeded.RetryAfter > 0
+}
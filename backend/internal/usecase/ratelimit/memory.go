@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - источник текущего времени, в тестах его подменяют управляемыми часами
+type Clock interface {
+	Now() time.Time
+}
+
+// MemoryAttemptLimiter - счетчик попыток в пределах одного процесса. После
+// maxAttempts попыток за window ключ блокируется до конца окна
+type MemoryAttemptLimiter struct {
+	clock       Clock
+	maxAttempts int
+	window      time.Duration
+	attempts    map[string]*attempts
+	lastSweep   time.Time
+	mu          sync.Mutex
+}
+
+type attempts struct {
+	count       int
+	windowStart time.Time
+}
+
+func NewMemoryAttemptLimiter(clock Clock, maxAttempts int, window time.Duration) *MemoryAttemptLimiter {
+	return &MemoryAttemptLimiter{
+		clock:       clock,
+		maxAttempts: maxAttempts,
+		window:      window,
+		attempts:    make(map[string]*attempts),
+		lastSweep:   clock.Now(),
+	}
+}
+
+// Allow - засчитывает попытку до проверки кода, поэтому параллельные запросы
+// не проходят сверх лимита. Ноль - попытка разрешена, иначе сколько еще ключ заблокирован
+func (l *MemoryAttemptLimiter) Allow(key string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	entry, exists := l.attempts[key]
+	if !exists || !now.Before(entry.windowStart.Add(l.window)) {
+		l.attempts[key] = &attempts{count: 1, windowStart: now}
+		return 0
+	}
+
+	if entry.count >= l.maxAttempts {
+		return entry.windowStart.Add(l.window).Sub(now)
+	}
+
+	entry.count++
+	return 0
+}
+
+// Release - возвращает попытку, засчитанную Allow, если она оказалась удачной
+func (l *MemoryAttemptLimiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry, exists := l.attempts[key]; exists && entry.count > 0 {
+		entry.count--
+	}
+}
+
+func (l *MemoryAttemptLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, key)
+}
+
+// sweep - вызывается под l.mu. Раз в окно удаляет истекшие счетчики,
+// чтобы перебор с разных адресов не раздувал память
+func (l *MemoryAttemptLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, entry := range l.attempts {
+		if !now.Before(entry.windowStart.Add(l.window)) {
+			delete(l.attempts, key)
+		}
+	}
+}
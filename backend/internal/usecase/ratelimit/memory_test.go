@@ -0,0 +1,85 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/ratelimit"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+const _testWindow = 15 * time.Minute
+
+func newTestLimiter(maxAttempts int) (*ratelimit.MemoryAttemptLimiter, *clock.Fake) {
+	fakeClock := clock.NewFake(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	return ratelimit.NewMemoryAttemptLimiter(fakeClock, maxAttempts, _testWindow), fakeClock
+}
+
+// spend - делает attempts попыток, каждая должна быть разрешена
+func spend(t *testing.T, limiter *ratelimit.MemoryAttemptLimiter, key string, attempts int) {
+	t.Helper()
+
+	for i := 0; i < attempts; i++ {
+		if retryAfter := limiter.Allow(key); retryAfter != 0 {
+			t.Fatalf("attempt %d blocked for %s, want allowed", i+1, retryAfter)
+		}
+	}
+}
+
+func TestMemoryAttemptLimiterLockExpires(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(3)
+
+	spend(t, limiter, "key", 3)
+
+	// Блокировка длится до конца окна первой попытки
+	fakeClock.Advance(time.Minute)
+	if retryAfter := limiter.Allow("key"); retryAfter != _testWindow-time.Minute {
+		t.Fatalf("got retry after %s, want %s", retryAfter, _testWindow-time.Minute)
+	}
+
+	fakeClock.Advance(_testWindow - time.Minute - time.Second)
+	if retryAfter := limiter.Allow("key"); retryAfter != time.Second {
+		t.Fatalf("got retry after %s a second before the window ends, want 1s", retryAfter)
+	}
+
+	// С концом окна отсчет начинается заново
+	fakeClock.Advance(time.Second)
+	spend(t, limiter, "key", 3)
+	if retryAfter := limiter.Allow("key"); retryAfter != _testWindow {
+		t.Fatalf("got retry after %s in the new window, want %s", retryAfter, _testWindow)
+	}
+}
+
+func TestMemoryAttemptLimiterKeysAreIndependent(t *testing.T) {
+	limiter, fakeClock := newTestLimiter(1)
+
+	spend(t, limiter, "first", 1)
+	if retryAfter := limiter.Allow("first"); retryAfter == 0 {
+		t.Fatal("first key is not blocked")
+	}
+	spend(t, limiter, "second", 1)
+
+	// Окно каждого ключа отсчитывается от его первой попытки
+	fakeClock.Advance(_testWindow - time.Second)
+	spend(t, limiter, "third", 1)
+	fakeClock.Advance(time.Second)
+	spend(t, limiter, "first", 1)
+	if retryAfter := limiter.Allow("third"); retryAfter != _testWindow-time.Second {
+		t.Fatalf("got retry after %s for the third key, want %s", retryAfter, _testWindow-time.Second)
+	}
+}
+
+func TestMemoryAttemptLimiterReleaseAndReset(t *testing.T) {
+	limiter, _ := newTestLimiter(2)
+
+	// Возвращенная попытка не засчитывается
+	spend(t, limiter, "key", 2)
+	limiter.Release("key")
+	spend(t, limiter, "key", 1)
+	if retryAfter := limiter.Allow("key"); retryAfter == 0 {
+		t.Fatal("key is not blocked after the limit")
+	}
+
+	limiter.Reset("key")
+	spend(t, limiter, "key", 2)
+}
@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// recordingService - идущие записи хранятся в памяти реплики, на которой работает рекордер.
+// Файлы записи кладутся в <dir>/<meeting_id>/<recording_id>
+type recordingService struct {
+	meetingRepo   MeetingRepo
+	recordingRepo RecordingRepo
+	recorder      Recorder
+	events        EventBus
+	clock         Clock
+	dir           string
+
+	active map[string]*entity.Recording
+	mu     sync.Mutex
+}
+
+// NewRecordingService - recorder может быть nil, тогда запись выключена
+func NewRecordingService(meetingRepo MeetingRepo, recordingRepo RecordingRepo, recorder Recorder, events EventBus, clock Clock, dir string) *recordingService {
+	return &recordingService{
+		meetingRepo:   meetingRepo,
+		recordingRepo: recordingRepo,
+		recorder:      recorder,
+		events:        events,
+		clock:         clock,
+		dir:           dir,
+		active:        make(map[string]*entity.Recording),
+	}
+}
+
+var _ RecordingUseCase = (*recordingService)(nil)
+
+func (uc *recordingService) StartRecording(ctx context.Context, meetingID, hostID string) (*entity.Recording, error) {
+	if uc.recorder == nil {
+		return nil, entity.ErrRecordingDisabled
+	}
+
+	meeting, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if _, exists := uc.active[meetingID]; exists {
+		return nil, entity.ErrRecordingInProgress
+	}
+
+	recording := &entity.Recording{
+		ID:        entity.GenerateRecordingID(),
+		MeetingID: meetingID,
+		Mode:      meeting.Mode,
+		StartedBy: hostID,
+		StartedAt: uc.clock.Now(),
+		Files:     []entity.RecordingFile{},
+	}
+
+	if err := uc.recorder.StartRecording(meetingID, meeting.Mode, filepath.Join(uc.dir, meetingID, recording.ID)); err != nil {
+		return nil, fmt.Errorf("failed to start recorder: %w", err)
+	}
+	uc.active[meetingID] = recording
+
+	uc.events.Publish(ctx, &entity.RecordingStartedEvent{
+		EventMeta: entity.EventMeta{MeetingID: meetingID, At: recording.StartedAt},
+		Recording: copyRecording(recording),
+	})
+
+	return copyRecording(recording), nil
+}
+
+func (uc *recordingService) StopRecording(ctx context.Context, meetingID, hostID string) (*entity.Recording, error) {
+	if uc.recorder == nil {
+		return nil, entity.ErrRecordingDisabled
+	}
+
+	if _, err := hostMeeting(ctx, uc.meetingRepo, meetingID, hostID, ""); err != nil {
+		return nil, err
+	}
+
+	return uc.finish(ctx, meetingID, hostID)
+}
+
+func (uc *recordingService) FinishRecording(ctx context.Context, meetingID string) error {
+	if uc.recorder == nil {
+		return nil
+	}
+
+	if _, err := uc.finish(ctx, meetingID, ""); err != nil && !errors.Is(err, entity.ErrNotRecording) {
+		return err
+	}
+
+	return nil
+}
+
+func (uc *recordingService) ActiveRecording(meetingID string) *entity.Recording {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	recording, exists := uc.active[meetingID]
+	if !exists {
+		return nil
+	}
+
+	return copyRecording(recording)
+}
+
+func (uc *recordingService) ListRecordings(ctx context.Context, meetingID string) ([]entity.Recording, error) {
+	recordings, err := uc.recordingRepo.ListRecordings(ctx, meetingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recordings: %w", err)
+	}
+
+	return recordings, nil
+}
+
+func (uc *recordingService) HandleOffer(ctx context.Context, meetingID, userID, sdp string) error {
+	if err := uc.checkRecorderPeer(meetingID); err != nil {
+		return err
+	}
+
+	return uc.recorder.HandleRecorderOffer(ctx, meetingID, userID, sdp)
+}
+
+func (uc *recordingService) AddICECandidate(ctx context.Context, meetingID, userID string, candidate *entity.ICECandidate) error {
+	if err := uc.checkRecorderPeer(meetingID); err != nil {
+		return err
+	}
+
+	return uc.recorder.AddRecorderICECandidate(ctx, meetingID, userID, candidate)
+}
+
+func (uc *recordingService) ParticipantLeft(meetingID, userID string) {
+	if uc.recorder != nil {
+		uc.recorder.RemovePeer(meetingID, userID)
+	}
+}
+
+// Shutdown - сохраняет записи, которые шли при остановке сервера
+func (uc *recordingService) Shutdown(ctx context.Context) {
+	uc.mu.Lock()
+	meetingIDs := make([]string, 0, len(uc.active))
+	for meetingID := range uc.active {
+		meetingIDs = append(meetingIDs, meetingID)
+	}
+	uc.mu.Unlock()
+
+	for _, meetingID := range meetingIDs {
+		_ = uc.FinishRecording(ctx, meetingID)
+	}
+}
+
+// checkRecorderPeer - отдельное соединение с рекордером нужно только во встрече в режиме mesh
+func (uc *recordingService) checkRecorderPeer(meetingID string) error {
+	if uc.recorder == nil {
+		return entity.ErrRecordingDisabled
+	}
+
+	recording := uc.ActiveRecording(meetingID)
+	if recording == nil || recording.Mode != entity.MeetingModeMesh {
+		return entity.ErrNotRecording
+	}
+
+	return nil
+}
+
+// finish - закрывает файлы записи, сохраняет ее описание и оповещает участников.
+// Пустой hostID - запись остановил сервер
+func (uc *recordingService) finish(ctx context.Context, meetingID, hostID string) (*entity.Recording, error) {
+	uc.mu.Lock()
+	recording, exists := uc.active[meetingID]
+	delete(uc.active, meetingID)
+	uc.mu.Unlock()
+
+	if !exists {
+		return nil, entity.ErrNotRecording
+	}
+
+	files, err := uc.recorder.StopRecording(meetingID)
+	if err != nil && !errors.Is(err, entity.ErrNotRecording) {
+		return nil, fmt.Errorf("failed to stop recorder: %w", err)
+	}
+
+	stoppedAt := uc.clock.Now()
+	recording.StoppedAt = &stoppedAt
+	if files != nil {
+		recording.Files = files
+	}
+
+	if err := uc.recordingRepo.SaveRecording(ctx, recording); err != nil {
+		return nil, fmt.Errorf("failed to save recording: %w", err)
+	}
+
+	uc.events.Publish(ctx, &entity.RecordingStoppedEvent{
+		EventMeta: entity.EventMeta{MeetingID: meetingID, At: stoppedAt},
+		Recording: recording,
+		By:        hostID,
+	})
+
+	return recording, nil
+}
+
+func copyRecording(recording *entity.Recording) *entity.Recording {
+	c := *recording
+	c.Files = append([]entity.RecordingFile{}, recording.Files...)
+	return &c
+}
@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// MemoryChatRepository - хранит последние maxMessages сообщений каждой встречи
+type MemoryChatRepository struct {
+	messages    map[string][]entity.ChatMessage
+	maxMessages int
+	mu          sync.RWMutex
+}
+
+func NewMemoryChatRepository(maxMessages int) *MemoryChatRepository {
+	return &MemoryChatRepository{
+		messages:    make(map[string][]entity.ChatMessage),
+		maxMessages: maxMessages,
+	}
+}
+
+func (r *MemoryChatRepository) SaveMessage(ctx context.Context, message *entity.ChatMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	messages := append(r.messages[message.MeetingID], *message)
+	if len(messages) > r.maxMessages {
+		messages = append([]entity.ChatMessage(nil), messages[len(messages)-r.maxMessages:]...)
+	}
+
+	r.messages[message.MeetingID] = messages
+	return nil
+}
+
+func (r *MemoryChatRepository) ListMessages(ctx context.Context, meetingID, viewerID, before string, limit int) ([]entity.ChatMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	messages := r.messages[meetingID]
+
+	end := len(messages)
+	if before != "" {
+		end = -1
+		for i := range messages {
+			if messages[i].ID == before {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil, entity.ErrInvalidCursor
+		}
+	}
+
+	// Идем от курсора к началу, собирая видимые зрителю сообщения
+	page := make([]entity.ChatMessage, 0, limit)
+	for i := end - 1; i >= 0 && len(page) < limit; i-- {
+		if messages[i].VisibleTo(viewerID) {
+			page = append(page, messages[i])
+		}
+	}
+
+	for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+		page[i], page[j] = page[j], page[i]
+	}
+
+	return page, nil
+}
+
+func (r *MemoryChatRepository) DeleteMeetingMessages(ctx context.Context, meetingID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.messages, meetingID)
+	return nil
+}
@@ -0,0 +1,431 @@
+package repo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+)
+
+func TestMemoryMeetingRepository(t *testing.T) {
+	runMeetingRepoContract(t, func(t *testing.T) usecase.MeetingRepo {
+		return repo.NewMemoryMeetingRepository()
+	})
+}
+
+// runMeetingRepoContract - общие требования к реализациям MeetingRepo
+func runMeetingRepoContract(t *testing.T, newRepo func(t *testing.T) usecase.MeetingRepo) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting)
+	}{
+		{
+			name: "get created meeting",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				got := mustGetMeeting(t, r, meeting.ID)
+				if got.Name != meeting.Name || got.Code != meeting.Code || got.HostID != meeting.HostID || got.Mode != meeting.Mode {
+					t.Fatalf("got meeting %+v, want %+v", got, meeting)
+				}
+				if !got.CreatedAt.Equal(meeting.CreatedAt) {
+					t.Fatalf("got created_at %s, want %s", got.CreatedAt, meeting.CreatedAt)
+				}
+				if len(got.Users) != 0 {
+					t.Fatalf("got %d users, want none", len(got.Users))
+				}
+			},
+		},
+		{
+			name: "get missing meeting",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				got, err := r.GetMeeting(context.Background(), entity.GenerateMeetingID())
+				if err != nil || got != nil {
+					t.Fatalf("got %v, %v, want nil, nil", got, err)
+				}
+			},
+		},
+		{
+			name: "create duplicate id",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				duplicate := *meeting
+				duplicate.Code = entity.GenerateMeetingCode()
+
+				err := r.CreateMeeting(context.Background(), &duplicate)
+				if !errors.Is(err, entity.ErrMeetingExists) {
+					t.Fatalf("got %v, want %v", err, entity.ErrMeetingExists)
+				}
+			},
+		},
+		{
+			name: "create duplicate code",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				duplicate := newTestMeeting()
+				duplicate.Code = meeting.Code
+
+				err := r.CreateMeeting(context.Background(), duplicate)
+				if !errors.Is(err, entity.ErrMeetingCodeTaken) {
+					t.Fatalf("got %v, want %v", err, entity.ErrMeetingCodeTaken)
+				}
+			},
+		},
+		{
+			name: "resolve code",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+
+				id, err := r.GetMeetingIDByCode(ctx, meeting.Code)
+				if err != nil || id != meeting.ID {
+					t.Fatalf("got %q, %v, want %q", id, err, meeting.ID)
+				}
+
+				id, err = r.GetMeetingIDByCode(ctx, entity.GenerateMeetingCode())
+				if err != nil || id != "" {
+					t.Fatalf("got %q, %v for unknown code, want empty", id, err)
+				}
+			},
+		},
+		{
+			name: "add and remove users",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				first := mustAddUser(t, r, meeting.ID, "first", meeting.CreatedAt.Add(time.Second))
+				second := mustAddUser(t, r, meeting.ID, "second", meeting.CreatedAt.Add(2*time.Second))
+
+				if err := r.AddUserToMeeting(ctx, meeting.ID, first); !errors.Is(err, entity.ErrUserExists) {
+					t.Fatalf("add duplicate user: got %v, want %v", err, entity.ErrUserExists)
+				}
+
+				users, err := r.GetMeetingUsers(ctx, meeting.ID)
+				if err != nil {
+					t.Fatalf("get users: %v", err)
+				}
+				if len(users) != 2 || users[0].ID != first.ID || users[1].ID != second.ID {
+					t.Fatalf("got users %+v, want first and second in join order", users)
+				}
+
+				if err := r.RemoveUserFromMeeting(ctx, meeting.ID, first.ID); err != nil {
+					t.Fatalf("remove user: %v", err)
+				}
+				if err := r.RemoveUserFromMeeting(ctx, meeting.ID, first.ID); !errors.Is(err, entity.ErrUserNotFound) {
+					t.Fatalf("remove missing user: got %v, want %v", err, entity.ErrUserNotFound)
+				}
+
+				got := mustGetMeeting(t, r, meeting.ID)
+				if len(got.Users) != 1 || got.Users[0].ID != second.ID {
+					t.Fatalf("got users %+v, want only second", got.Users)
+				}
+			},
+		},
+		{
+			name: "users of missing meeting",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				missingID := entity.GenerateMeetingID()
+				user := &entity.User{ID: entity.GenerateUserID(), Name: "ghost", JoinedAt: meeting.CreatedAt}
+
+				if err := r.AddUserToMeeting(ctx, missingID, user); !errors.Is(err, entity.ErrMeetingNotFound) {
+					t.Fatalf("add user: got %v, want %v", err, entity.ErrMeetingNotFound)
+				}
+				if err := r.RemoveUserFromMeeting(ctx, missingID, user.ID); !errors.Is(err, entity.ErrMeetingNotFound) {
+					t.Fatalf("remove user: got %v, want %v", err, entity.ErrMeetingNotFound)
+				}
+				if _, err := r.GetMeetingUsers(ctx, missingID); !errors.Is(err, entity.ErrMeetingNotFound) {
+					t.Fatalf("get users: got %v, want %v", err, entity.ErrMeetingNotFound)
+				}
+			},
+		},
+		{
+			name: "user sessions and attendance",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				user := mustAddUser(t, r, meeting.ID, "alice", meeting.CreatedAt)
+				at := meeting.CreatedAt.Add(time.Minute)
+
+				if err := r.StartUserSession(ctx, meeting.ID, entity.GenerateUserID(), at); !errors.Is(err, entity.ErrUserNotFound) {
+					t.Fatalf("start session of stranger: got %v, want %v", err, entity.ErrUserNotFound)
+				}
+
+				mustSession(t, r.StartUserSession(ctx, meeting.ID, user.ID, at))
+				// Повторный старт при открытой сессии ничего не меняет
+				mustSession(t, r.StartUserSession(ctx, meeting.ID, user.ID, at.Add(time.Second)))
+
+				got := mustGetMeeting(t, r, meeting.ID)
+				if !got.Users[0].IsOnline || len(got.Users[0].Sessions) != 1 {
+					t.Fatalf("got user %+v, want one open session", got.Users[0])
+				}
+
+				mustSession(t, r.EndUserSession(ctx, meeting.ID, user.ID, at.Add(time.Minute)))
+				mustSession(t, r.EndUserSession(ctx, meeting.ID, user.ID, at.Add(2*time.Minute)))
+				mustSession(t, r.StartUserSession(ctx, meeting.ID, user.ID, at.Add(3*time.Minute)))
+
+				got = mustGetMeeting(t, r, meeting.ID)
+				sessions := got.Users[0].Sessions
+				if len(sessions) != 2 || sessions[0].LeftAt == nil || !sessions[0].LeftAt.Equal(at.Add(time.Minute)) || sessions[1].LeftAt != nil {
+					t.Fatalf("got sessions %+v, want a closed and an open one", sessions)
+				}
+
+				// История переживает выход из встречи
+				if err := r.RemoveUserFromMeeting(ctx, meeting.ID, user.ID); err != nil {
+					t.Fatalf("remove user: %v", err)
+				}
+				mustSession(t, r.EndUserSession(ctx, meeting.ID, user.ID, at.Add(4*time.Minute)))
+
+				events, err := r.ListAttendance(ctx, meeting.ID)
+				if err != nil {
+					t.Fatalf("list attendance: %v", err)
+				}
+
+				wantTypes := []string{entity.AttendanceJoined, entity.AttendanceLeft, entity.AttendanceReconnected, entity.AttendanceLeft}
+				if len(events) != len(wantTypes) {
+					t.Fatalf("got %d attendance events, want %d", len(events), len(wantTypes))
+				}
+				for i, event := range events {
+					if event.Type != wantTypes[i] || event.UserID != user.ID || event.UserName != user.Name {
+						t.Fatalf("event %d: got %+v, want %s of %s", i, event, wantTypes[i], user.Name)
+					}
+				}
+			},
+		},
+		{
+			name: "moderation flags",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				hostID := entity.GenerateUserID()
+
+				if err := r.SetMeetingHost(ctx, meeting.ID, hostID); err != nil {
+					t.Fatalf("set host: %v", err)
+				}
+				if err := r.SetMeetingLocked(ctx, meeting.ID, true); err != nil {
+					t.Fatalf("set locked: %v", err)
+				}
+				if err := r.SetMeetingLobby(ctx, meeting.ID, true); err != nil {
+					t.Fatalf("set lobby: %v", err)
+				}
+
+				got := mustGetMeeting(t, r, meeting.ID)
+				if got.HostID != hostID || !got.Locked || !got.Lobby {
+					t.Fatalf("got meeting %+v, want new host, locked and lobby", got)
+				}
+
+				if err := r.SetMeetingLocked(ctx, entity.GenerateMeetingID(), true); !errors.Is(err, entity.ErrMeetingNotFound) {
+					t.Fatalf("lock missing meeting: got %v, want %v", err, entity.ErrMeetingNotFound)
+				}
+			},
+		},
+		{
+			name: "update meeting",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				endsAt := meeting.CreatedAt.Add(time.Hour)
+
+				updated := *meeting
+				updated.Name = "Renamed"
+				updated.Description = "Weekly sync"
+				updated.Capacity = 10
+				updated.EndsAt = &endsAt
+				updated.PasscodeHash = "hash"
+				updated.HostID = entity.GenerateUserID()
+
+				if err := r.UpdateMeeting(ctx, &updated); err != nil {
+					t.Fatalf("update meeting: %v", err)
+				}
+
+				got := mustGetMeeting(t, r, meeting.ID)
+				if got.Name != updated.Name || got.Description != updated.Description || got.Capacity != updated.Capacity || got.PasscodeHash != updated.PasscodeHash {
+					t.Fatalf("got meeting %+v, want %+v", got, updated)
+				}
+				if got.EndsAt == nil || !got.EndsAt.Equal(endsAt) || got.StartsAt != nil {
+					t.Fatalf("got schedule %v - %v, want until %s", got.StartsAt, got.EndsAt, endsAt)
+				}
+				if got.HostID != meeting.HostID {
+					t.Fatalf("got host %q, update must not change it", got.HostID)
+				}
+
+				missing := newTestMeeting()
+				if err := r.UpdateMeeting(ctx, missing); !errors.Is(err, entity.ErrMeetingNotFound) {
+					t.Fatalf("update missing meeting: got %v, want %v", err, entity.ErrMeetingNotFound)
+				}
+			},
+		},
+		{
+			name: "pending users",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				first := &entity.User{ID: entity.GenerateUserID(), Name: "first", JoinedAt: meeting.CreatedAt.Add(time.Second)}
+				second := &entity.User{ID: entity.GenerateUserID(), Name: "second", JoinedAt: meeting.CreatedAt.Add(2 * time.Second)}
+
+				for _, user := range []*entity.User{first, second} {
+					if err := r.AddPendingUser(ctx, meeting.ID, user); err != nil {
+						t.Fatalf("add pending user: %v", err)
+					}
+				}
+				if err := r.AddPendingUser(ctx, meeting.ID, first); !errors.Is(err, entity.ErrUserExists) {
+					t.Fatalf("add duplicate pending user: got %v, want %v", err, entity.ErrUserExists)
+				}
+
+				removed, err := r.RemovePendingUser(ctx, meeting.ID, first.ID)
+				if err != nil || removed == nil || removed.ID != first.ID || removed.Name != first.Name {
+					t.Fatalf("remove pending user: got %+v, %v, want %+v", removed, err, first)
+				}
+
+				removed, err = r.RemovePendingUser(ctx, meeting.ID, first.ID)
+				if err != nil || removed != nil {
+					t.Fatalf("remove missing pending user: got %+v, %v, want nil, nil", removed, err)
+				}
+
+				pending, err := r.GetPendingUsers(ctx, meeting.ID)
+				if err != nil || len(pending) != 1 || pending[0].ID != second.ID {
+					t.Fatalf("get pending users: got %+v, %v, want only second", pending, err)
+				}
+			},
+		},
+		{
+			name: "attendance outlives meeting",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				user := mustAddUser(t, r, meeting.ID, "alice", meeting.CreatedAt)
+				leftAt := meeting.CreatedAt.Add(time.Hour)
+				mustSession(t, r.StartUserSession(ctx, meeting.ID, user.ID, meeting.CreatedAt))
+				mustSession(t, r.EndUserSession(ctx, meeting.ID, user.ID, leftAt))
+
+				// Журнал идущей встречи не удаляется, каким бы старым он ни был
+				if _, err := r.DeleteAttendanceBefore(ctx, leftAt.Add(time.Hour)); err != nil {
+					t.Fatalf("delete attendance: %v", err)
+				}
+				if err := r.DeleteMeeting(ctx, meeting.ID); err != nil {
+					t.Fatalf("delete meeting: %v", err)
+				}
+
+				events, err := r.ListAttendance(ctx, meeting.ID)
+				if err != nil || len(events) != 2 {
+					t.Fatalf("got attendance %+v, %v of deleted meeting, want joined and left", events, err)
+				}
+
+				// Журнал удаленной встречи удаляется, когда его последнее событие старше границы
+				if _, err := r.DeleteAttendanceBefore(ctx, leftAt); err != nil {
+					t.Fatalf("delete attendance: %v", err)
+				}
+				if events, _ := r.ListAttendance(ctx, meeting.ID); len(events) != 2 {
+					t.Fatalf("got attendance %+v, want it kept until its last event expires", events)
+				}
+
+				deleted, err := r.DeleteAttendanceBefore(ctx, leftAt.Add(time.Microsecond))
+				if err != nil || deleted < 1 {
+					t.Fatalf("delete attendance: got %d, %v, want the log deleted", deleted, err)
+				}
+				if events, _ := r.ListAttendance(ctx, meeting.ID); len(events) != 0 {
+					t.Fatalf("got attendance %+v after it expired, want none", events)
+				}
+			},
+		},
+		{
+			name: "list and delete meeting",
+			run: func(t *testing.T, r usecase.MeetingRepo, meeting *entity.Meeting) {
+				ctx := context.Background()
+				mustAddUser(t, r, meeting.ID, "alice", meeting.CreatedAt)
+
+				meetings, err := r.ListMeetings(ctx)
+				if err != nil {
+					t.Fatalf("list meetings: %v", err)
+				}
+				if !containsMeeting(meetings, meeting.ID) {
+					t.Fatalf("meeting %s is missing in the list", meeting.ID)
+				}
+
+				if err := r.DeleteMeeting(ctx, meeting.ID); err != nil {
+					t.Fatalf("delete meeting: %v", err)
+				}
+				if err := r.DeleteMeeting(ctx, meeting.ID); !errors.Is(err, entity.ErrMeetingNotFound) {
+					t.Fatalf("delete missing meeting: got %v, want %v", err, entity.ErrMeetingNotFound)
+				}
+
+				got, err := r.GetMeeting(ctx, meeting.ID)
+				if err != nil || got != nil {
+					t.Fatalf("get deleted meeting: got %v, %v, want nil, nil", got, err)
+				}
+
+				id, err := r.GetMeetingIDByCode(ctx, meeting.Code)
+				if err != nil || id != "" {
+					t.Fatalf("code of deleted meeting resolves to %q, %v", id, err)
+				}
+
+				meetings, err = r.ListMeetings(ctx)
+				if err != nil || containsMeeting(meetings, meeting.ID) {
+					t.Fatalf("deleted meeting is still listed, err %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRepo(t)
+
+			meeting := newTestMeeting()
+			if err := r.CreateMeeting(context.Background(), meeting); err != nil {
+				t.Fatalf("create meeting: %v", err)
+			}
+			t.Cleanup(func() {
+				_ = r.DeleteMeeting(context.Background(), meeting.ID)
+			})
+
+			tt.run(t, r, meeting)
+		})
+	}
+}
+
+// newTestMeeting - встреча со своими ID и кодом. Время округлено до микросекунд,
+// с такой точностью его хранит Postgres
+func newTestMeeting() *entity.Meeting {
+	return &entity.Meeting{
+		ID:        entity.GenerateMeetingID(),
+		Code:      entity.GenerateMeetingCode(),
+		Name:      "Contract",
+		HostID:    entity.GenerateUserID(),
+		Mode:      entity.MeetingModeMesh,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func mustGetMeeting(t *testing.T, r usecase.MeetingRepo, meetingID string) *entity.Meeting {
+	t.Helper()
+
+	meeting, err := r.GetMeeting(context.Background(), meetingID)
+	if err != nil {
+		t.Fatalf("get meeting: %v", err)
+	}
+	if meeting == nil {
+		t.Fatalf("meeting %s not found", meetingID)
+	}
+	return meeting
+}
+
+func mustAddUser(t *testing.T, r usecase.MeetingRepo, meetingID, name string, joinedAt time.Time) *entity.User {
+	t.Helper()
+
+	user := &entity.User{ID: entity.GenerateUserID(), Name: name, JoinedAt: joinedAt}
+	if err := r.AddUserToMeeting(context.Background(), meetingID, user); err != nil {
+		t.Fatalf("add user: %v", err)
+	}
+	return user
+}
+
+func mustSession(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("user session: %v", err)
+	}
+}
+
+func containsMeeting(meetings []entity.Meeting, meetingID string) bool {
+	for _, meeting := range meetings {
+		if meeting.ID == meetingID {
+			return true
+		}
+	}
+	return false
+}
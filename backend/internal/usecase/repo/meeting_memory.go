@@ -4,18 +4,32 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
 )
 
 type MemoryMeetingRepository struct {
 	meetings map[string]*entity.Meeting
-	mu       sync.RWMutex
+	// codes - индекс ID встреч по коду
+	codes map[string]string
+	// pending - лобби встреч, удаляется вместе со встречей
+	pending map[string][]entity.User
+	// sessions - история сессий по встрече и пользователю, удаляется вместе со встречей
+	sessions map[string]map[string][]entity.UserSession
+	// attendance - журналы посещаемости, остаются после удаления встречи
+	attendance map[string][]entity.AttendanceEvent
+	mu         sync.RWMutex
 }
 
 func NewMemoryMeetingRepository() *MemoryMeetingRepository {
 	return &MemoryMeetingRepository{
 		meetings: make(map[string]*entity.Meeting),
+		codes:    make(map[string]string),
+		pending:  make(map[string][]entity.User),
+		sessions: make(map[string]map[string][]entity.UserSession),
+
+		attendance: make(map[string][]entity.AttendanceEvent),
 	}
 }
 
@@ -24,13 +38,25 @@ func (r *MemoryMeetingRepository) CreateMeeting(ctx context.Context, meeting *en
 	defer r.mu.Unlock()
 
 	if _, exists := r.meetings[meeting.ID]; exists {
-		return fmt.Errorf("meeting already exists: %s", meeting.ID)
+		return fmt.Errorf("%w: %s", entity.ErrMeetingExists, meeting.ID)
+	}
+
+	if _, exists := r.codes[meeting.Code]; exists {
+		return entity.ErrMeetingCodeTaken
 	}
 
 	r.meetings[meeting.ID] = meeting
+	r.codes[meeting.Code] = meeting.ID
 	return nil
 }
 
+func (r *MemoryMeetingRepository) GetMeetingIDByCode(ctx context.Context, code string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.codes[code], nil
+}
+
 func (r *MemoryMeetingRepository) GetMeeting(ctx context.Context, meetingID string) (*entity.Meeting, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -50,16 +76,17 @@ func (r *MemoryMeetingRepository) AddUserToMeeting(ctx context.Context, meetingI
 
 	meeting, exists := r.meetings[meetingID]
 	if !exists {
-		return fmt.Errorf("meeting not found: %s", meetingID)
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
 	}
 
-	for _, existingUser := range meeting.Users {
-		if existingUser.ID == user.ID {
-			return fmt.Errorf("user already exists in meeting: %s", user.ID)
-		}
+	if hasMember(meeting, user.ID) {
+		return fmt.Errorf("%w: %s", entity.ErrUserExists, user.ID)
 	}
 
-	meeting.Users = append(meeting.Users, *user)
+	stored := *user
+	stored.IsOnline = false
+	stored.Sessions = nil
+	meeting.Users = append(meeting.Users, stored)
 	return nil
 }
 
@@ -69,7 +96,7 @@ func (r *MemoryMeetingRepository) RemoveUserFromMeeting(ctx context.Context, mee
 
 	meeting, exists := r.meetings[meetingID]
 	if !exists {
-		return fmt.Errorf("meeting not found: %s", meetingID)
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
 	}
 
 	for i, user := range meeting.Users {
@@ -79,26 +106,115 @@ func (r *MemoryMeetingRepository) RemoveUserFromMeeting(ctx context.Context, mee
 		}
 	}
 
-	return fmt.Errorf("user not found in meeting: %s", userID)
+	return fmt.Errorf("%w: %s", entity.ErrUserNotFound, userID)
 }
 
-func (r *MemoryMeetingRepository) SetUserOnlineStatus(ctx context.Context, meetingID, userID string, online bool) error {
+func (r *MemoryMeetingRepository) StartUserSession(ctx context.Context, meetingID, userID string, at time.Time) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	meeting, exists := r.meetings[meetingID]
 	if !exists {
-		return fmt.Errorf("meeting not found: %s", meetingID)
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
 	}
 
-	for i := range meeting.Users {
-		if meeting.Users[i].ID == userID {
-			meeting.Users[i].IsOnline = online
-			return nil
+	user := memberByID(meeting, userID)
+	if user == nil {
+		return fmt.Errorf("%w: %s", entity.ErrUserNotFound, userID)
+	}
+
+	if r.sessions[meetingID] == nil {
+		r.sessions[meetingID] = make(map[string][]entity.UserSession)
+	}
+
+	sessions := r.sessions[meetingID][userID]
+	if len(sessions) > 0 && sessions[len(sessions)-1].LeftAt == nil {
+		return nil
+	}
+
+	r.sessions[meetingID][userID] = append(sessions, entity.UserSession{JoinedAt: at})
+
+	eventType := entity.AttendanceJoined
+	if len(sessions) > 0 {
+		eventType = entity.AttendanceReconnected
+	}
+	r.logAttendance(meetingID, user, eventType, at)
+	return nil
+}
+
+func (r *MemoryMeetingRepository) EndUserSession(ctx context.Context, meetingID, userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	meeting, exists := r.meetings[meetingID]
+	if !exists {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	sessions := r.sessions[meetingID][userID]
+	if len(sessions) == 0 || sessions[len(sessions)-1].LeftAt != nil {
+		return nil
+	}
+
+	sessions[len(sessions)-1].LeftAt = &at
+
+	// Пользователь мог уже покинуть встречу, тогда имя берется из журнала
+	user := memberByID(meeting, userID)
+	if user == nil {
+		user = &entity.User{ID: userID, Name: r.attendanceName(meetingID, userID)}
+	}
+	r.logAttendance(meetingID, user, entity.AttendanceLeft, at)
+	return nil
+}
+
+func (r *MemoryMeetingRepository) ListAttendance(ctx context.Context, meetingID string) ([]entity.AttendanceEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]entity.AttendanceEvent, len(r.attendance[meetingID]))
+	copy(events, r.attendance[meetingID])
+	return events, nil
+}
+
+func (r *MemoryMeetingRepository) DeleteAttendanceBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for meetingID, events := range r.attendance {
+		if _, exists := r.meetings[meetingID]; exists {
+			continue
+		}
+		if len(events) > 0 && !events[len(events)-1].At.Before(before) {
+			continue
 		}
+
+		delete(r.attendance, meetingID)
+		deleted++
 	}
+	return deleted, nil
+}
+
+// logAttendance - вызывается под r.mu
+func (r *MemoryMeetingRepository) logAttendance(meetingID string, user *entity.User, eventType string, at time.Time) {
+	r.attendance[meetingID] = append(r.attendance[meetingID], entity.AttendanceEvent{
+		MeetingID: meetingID,
+		UserID:    user.ID,
+		UserName:  user.Name,
+		Type:      eventType,
+		At:        at,
+	})
+}
 
-	return fmt.Errorf("user not found in meeting: %s", userID)
+// attendanceName - последнее имя пользователя в журнале встречи, вызывается под r.mu
+func (r *MemoryMeetingRepository) attendanceName(meetingID, userID string) string {
+	events := r.attendance[meetingID]
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].UserID == userID {
+			return events[i].UserName
+		}
+	}
+	return ""
 }
 
 func (r *MemoryMeetingRepository) GetMeetingUsers(ctx context.Context, meetingID string) ([]entity.User, error) {
@@ -107,11 +223,149 @@ func (r *MemoryMeetingRepository) GetMeetingUsers(ctx context.Context, meetingID
 
 	meeting, exists := r.meetings[meetingID]
 	if !exists {
-		return nil, fmt.Errorf("meeting not found: %s", meetingID)
+		return nil, fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	return r.copyUsers(meetingID, meeting.Users), nil
+}
+
+func (r *MemoryMeetingRepository) SetMeetingHost(ctx context.Context, meetingID, hostID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	meeting, exists := r.meetings[meetingID]
+	if !exists {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	meeting.HostID = hostID
+	return nil
+}
+
+func (r *MemoryMeetingRepository) SetMeetingLocked(ctx context.Context, meetingID string, locked bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	meeting, exists := r.meetings[meetingID]
+	if !exists {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	meeting.Locked = locked
+	return nil
+}
+
+func (r *MemoryMeetingRepository) SetMeetingLobby(ctx context.Context, meetingID string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	meeting, exists := r.meetings[meetingID]
+	if !exists {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	meeting.Lobby = enabled
+	return nil
+}
+
+func (r *MemoryMeetingRepository) UpdateMeeting(ctx context.Context, meeting *entity.Meeting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.meetings[meeting.ID]
+	if !exists {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meeting.ID)
+	}
+
+	existing.Name = meeting.Name
+	existing.Description = meeting.Description
+	existing.Capacity = meeting.Capacity
+	existing.Locked = meeting.Locked
+	existing.Lobby = meeting.Lobby
+	existing.StartsAt = copyTime(meeting.StartsAt)
+	existing.EndsAt = copyTime(meeting.EndsAt)
+	existing.PasscodeHash = meeting.PasscodeHash
+	return nil
+}
+
+func (r *MemoryMeetingRepository) DeleteMeeting(ctx context.Context, meetingID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	meeting, exists := r.meetings[meetingID]
+	if !exists {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	delete(r.meetings, meetingID)
+	delete(r.codes, meeting.Code)
+	delete(r.pending, meetingID)
+	delete(r.sessions, meetingID)
+	return nil
+}
+
+func (r *MemoryMeetingRepository) ListMeetings(ctx context.Context) ([]entity.Meeting, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	meetings := make([]entity.Meeting, 0, len(r.meetings))
+	for _, meeting := range r.meetings {
+		meetings = append(meetings, *r.copyMeeting(meeting))
+	}
+
+	return meetings, nil
+}
+
+func (r *MemoryMeetingRepository) AddPendingUser(ctx context.Context, meetingID string, user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.meetings[meetingID]; !exists {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	for _, existingUser := range r.pending[meetingID] {
+		if existingUser.ID == user.ID {
+			return fmt.Errorf("%w: %s", entity.ErrUserExists, user.ID)
+		}
 	}
 
-	users := make([]entity.User, len(meeting.Users))
-	copy(users, meeting.Users)
+	r.pending[meetingID] = append(r.pending[meetingID], *user)
+	return nil
+}
+
+func (r *MemoryMeetingRepository) RemovePendingUser(ctx context.Context, meetingID, userID string) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.meetings[meetingID]; !exists {
+		return nil, fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	pending := r.pending[meetingID]
+	for i, user := range pending {
+		if user.ID == userID {
+			r.pending[meetingID] = append(pending[:i:i], pending[i+1:]...)
+			if len(r.pending[meetingID]) == 0 {
+				delete(r.pending, meetingID)
+			}
+			return &user, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *MemoryMeetingRepository) GetPendingUsers(ctx context.Context, meetingID string) ([]entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.meetings[meetingID]; !exists {
+		return nil, fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	users := make([]entity.User, len(r.pending[meetingID]))
+	copy(users, r.pending[meetingID])
 	return users, nil
 }
 
@@ -119,11 +373,61 @@ func (r *MemoryMeetingRepository) GetMeetingUsers(ctx context.Context, meetingID
 func (r *MemoryMeetingRepository) copyMeeting(meeting *entity.Meeting) *entity.Meeting {
 	copiedMeeting := &entity.Meeting{
 		ID:        meeting.ID,
+		Code:      meeting.Code,
 		Name:      meeting.Name,
+		HostID:    meeting.HostID,
+		Locked:    meeting.Locked,
+		Lobby:     meeting.Lobby,
+		Mode:      meeting.Mode,
 		CreatedAt: meeting.CreatedAt,
-		Users:     make([]entity.User, len(meeting.Users)),
+		StartsAt:  copyTime(meeting.StartsAt),
+		EndsAt:    copyTime(meeting.EndsAt),
+		Users:     r.copyUsers(meeting.ID, meeting.Users),
+
+		Description:  meeting.Description,
+		Capacity:     meeting.Capacity,
+		PasscodeHash: meeting.PasscodeHash,
 	}
 
-	copy(copiedMeeting.Users, meeting.Users)
 	return copiedMeeting
 }
+
+// copyUsers - копии участников с их сессиями
+func (r *MemoryMeetingRepository) copyUsers(meetingID string, users []entity.User) []entity.User {
+	copied := make([]entity.User, len(users))
+	for i, user := range users {
+		sessions := r.sessions[meetingID][user.ID]
+
+		user.Sessions = make([]entity.UserSession, len(sessions))
+		for j, session := range sessions {
+			user.Sessions[j] = entity.UserSession{JoinedAt: session.JoinedAt, LeftAt: copyTime(session.LeftAt)}
+		}
+		user.IsOnline = len(sessions) > 0 && sessions[len(sessions)-1].LeftAt == nil
+
+		copied[i] = user
+	}
+
+	return copied
+}
+
+func hasMember(meeting *entity.Meeting, userID string) bool {
+	return memberByID(meeting, userID) != nil
+}
+
+func memberByID(meeting *entity.Meeting, userID string) *entity.User {
+	for i := range meeting.Users {
+		if meeting.Users[i].ID == userID {
+			return &meeting.Users[i]
+		}
+	}
+	return nil
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	copied := *t
+	return &copied
+}
@@ -0,0 +1,602 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	_uniqueViolationCode = "23505"
+	// _meetingCodeIndex - уникальный индекс кодов встреч из миграции 000007
+	_meetingCodeIndex = "meetings_code_key"
+
+	_meetingColumns = `id, code, name, host_id, locked, lobby, mode, created_at, starts_at, ends_at, passcode_hash, description, capacity`
+)
+
+type PostgresMeetingRepository struct {
+	pg *postgres.Postgres
+}
+
+func NewPostgresMeetingRepository(pg *postgres.Postgres) *PostgresMeetingRepository {
+	return &PostgresMeetingRepository{
+		pg: pg,
+	}
+}
+
+func (r *PostgresMeetingRepository) CreateMeeting(ctx context.Context, meeting *entity.Meeting) error {
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO meetings (id, code, name, host_id, locked, lobby, mode, created_at, starts_at, ends_at, passcode_hash, description, capacity)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			meeting.ID, meeting.Code, meeting.Name, meeting.HostID, meeting.Locked, meeting.Lobby, meeting.Mode, meeting.CreatedAt, meeting.StartsAt, meeting.EndsAt,
+			meeting.PasscodeHash, meeting.Description, meeting.Capacity,
+		)
+		if err != nil {
+			if isUniqueViolationOf(err, _meetingCodeIndex) {
+				return entity.ErrMeetingCodeTaken
+			}
+			if isUniqueViolation(err) {
+				return fmt.Errorf("%w: %s", entity.ErrMeetingExists, meeting.ID)
+			}
+			return fmt.Errorf("failed to insert meeting: %w", err)
+		}
+
+		for i := range meeting.Users {
+			if err := insertUser(ctx, tx, meeting.ID, &meeting.Users[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresMeetingRepository) GetMeeting(ctx context.Context, meetingID string) (*entity.Meeting, error) {
+	row := r.pg.Pool.QueryRow(ctx,
+		`SELECT `+_meetingColumns+` FROM meetings WHERE id = $1`,
+		meetingID,
+	)
+
+	meeting, err := scanMeeting(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to select meeting: %w", err)
+	}
+
+	meeting.Users, err = selectUsers(ctx, r.pg.Pool, meetingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return meeting, nil
+}
+
+func (r *PostgresMeetingRepository) GetMeetingIDByCode(ctx context.Context, code string) (string, error) {
+	var meetingID string
+
+	err := r.pg.Pool.QueryRow(ctx, `SELECT id FROM meetings WHERE code = $1`, code).Scan(&meetingID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to select meeting by code: %w", err)
+	}
+
+	return meetingID, nil
+}
+
+func (r *PostgresMeetingRepository) AddUserToMeeting(ctx context.Context, meetingID string, user *entity.User) error {
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		if err := lockMeeting(ctx, tx, meetingID); err != nil {
+			return err
+		}
+
+		return insertUser(ctx, tx, meetingID, user)
+	})
+}
+
+func (r *PostgresMeetingRepository) RemoveUserFromMeeting(ctx context.Context, meetingID, userID string) error {
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		if err := lockMeeting(ctx, tx, meetingID); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx,
+			`DELETE FROM users WHERE meeting_id = $1 AND id = $2`,
+			meetingID, userID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: %s", entity.ErrUserNotFound, userID)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresMeetingRepository) StartUserSession(ctx context.Context, meetingID, userID string, at time.Time) error {
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		if err := lockMeeting(ctx, tx, meetingID); err != nil {
+			return err
+		}
+
+		var name string
+		err := tx.QueryRow(ctx,
+			`SELECT name FROM users WHERE meeting_id = $1 AND id = $2`,
+			meetingID, userID,
+		).Scan(&name)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: %s", entity.ErrUserNotFound, userID)
+			}
+			return fmt.Errorf("failed to select user: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO user_sessions (meeting_id, user_id, joined_at) VALUES ($1, $2, $3)
+			ON CONFLICT (meeting_id, user_id) WHERE left_at IS NULL DO NOTHING`,
+			meetingID, userID, at,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert user session: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		var sessions int
+		err = tx.QueryRow(ctx,
+			`SELECT count(*) FROM user_sessions WHERE meeting_id = $1 AND user_id = $2`,
+			meetingID, userID,
+		).Scan(&sessions)
+		if err != nil {
+			return fmt.Errorf("failed to count user sessions: %w", err)
+		}
+
+		eventType := entity.AttendanceJoined
+		if sessions > 1 {
+			eventType = entity.AttendanceReconnected
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO attendance_events (meeting_id, user_id, user_name, type, at) VALUES ($1, $2, $3, $4, $5)`,
+			meetingID, userID, name, eventType, at,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert attendance event: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresMeetingRepository) EndUserSession(ctx context.Context, meetingID, userID string, at time.Time) error {
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		if err := lockMeeting(ctx, tx, meetingID); err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx,
+			`UPDATE user_sessions SET left_at = $3 WHERE meeting_id = $1 AND user_id = $2 AND left_at IS NULL`,
+			meetingID, userID, at,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update user session: %w", err)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return nil
+		}
+
+		// Пользователь мог уже покинуть встречу, тогда имя берется из журнала
+		_, err = tx.Exec(ctx,
+			`INSERT INTO attendance_events (meeting_id, user_id, user_name, type, at)
+			VALUES ($1, $2, COALESCE(
+				(SELECT name FROM users WHERE meeting_id = $1 AND id = $2),
+				(SELECT user_name FROM attendance_events WHERE meeting_id = $1 AND user_id = $2 ORDER BY id DESC LIMIT 1),
+				''
+			), $3, $4)`,
+			meetingID, userID, entity.AttendanceLeft, at,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert attendance event: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresMeetingRepository) ListAttendance(ctx context.Context, meetingID string) ([]entity.AttendanceEvent, error) {
+	rows, err := r.pg.Pool.Query(ctx,
+		`SELECT meeting_id, user_id, user_name, type, at FROM attendance_events WHERE meeting_id = $1 ORDER BY at, id`,
+		meetingID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select attendance events: %w", err)
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.AttendanceEvent, error) {
+		var event entity.AttendanceEvent
+		err := row.Scan(&event.MeetingID, &event.UserID, &event.UserName, &event.Type, &event.At)
+		return event, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan attendance events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *PostgresMeetingRepository) DeleteAttendanceBefore(ctx context.Context, before time.Time) (int, error) {
+	var deleted int
+	err := r.pg.Pool.QueryRow(ctx,
+		`WITH expired AS (
+			SELECT meeting_id FROM attendance_events a
+			WHERE NOT EXISTS (SELECT 1 FROM meetings m WHERE m.id = a.meeting_id)
+			GROUP BY meeting_id
+			HAVING max(at) < $1
+		), deleted AS (
+			DELETE FROM attendance_events WHERE meeting_id IN (SELECT meeting_id FROM expired)
+		)
+		SELECT count(*) FROM expired`,
+		before,
+	).Scan(&deleted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete attendance events: %w", err)
+	}
+
+	return deleted, nil
+}
+
+func (r *PostgresMeetingRepository) GetMeetingUsers(ctx context.Context, meetingID string) ([]entity.User, error) {
+	var exists bool
+
+	err := r.pg.Pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM meetings WHERE id = $1)`,
+		meetingID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select meeting: %w", err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	return selectUsers(ctx, r.pg.Pool, meetingID)
+}
+
+func (r *PostgresMeetingRepository) SetMeetingHost(ctx context.Context, meetingID, hostID string) error {
+	return r.updateMeeting(ctx, meetingID, `UPDATE meetings SET host_id = $2 WHERE id = $1`, hostID)
+}
+
+func (r *PostgresMeetingRepository) SetMeetingLocked(ctx context.Context, meetingID string, locked bool) error {
+	return r.updateMeeting(ctx, meetingID, `UPDATE meetings SET locked = $2 WHERE id = $1`, locked)
+}
+
+func (r *PostgresMeetingRepository) SetMeetingLobby(ctx context.Context, meetingID string, enabled bool) error {
+	return r.updateMeeting(ctx, meetingID, `UPDATE meetings SET lobby = $2 WHERE id = $1`, enabled)
+}
+
+// UpdateMeeting - сохраняет настройки встречи. Ведущий и участники меняются отдельно
+func (r *PostgresMeetingRepository) UpdateMeeting(ctx context.Context, meeting *entity.Meeting) error {
+	return r.updateMeeting(ctx, meeting.ID,
+		`UPDATE meetings
+		SET name = $2, description = $3, capacity = $4, locked = $5, lobby = $6, starts_at = $7, ends_at = $8, passcode_hash = $9
+		WHERE id = $1`,
+		meeting.Name, meeting.Description, meeting.Capacity, meeting.Locked, meeting.Lobby, meeting.StartsAt, meeting.EndsAt, meeting.PasscodeHash,
+	)
+}
+
+func (r *PostgresMeetingRepository) updateMeeting(ctx context.Context, meetingID, query string, args ...any) error {
+	tag, err := r.pg.Pool.Exec(ctx, query, append([]any{meetingID}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to update meeting: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	return nil
+}
+
+func (r *PostgresMeetingRepository) DeleteMeeting(ctx context.Context, meetingID string) error {
+	tag, err := r.pg.Pool.Exec(ctx, `DELETE FROM meetings WHERE id = $1`, meetingID)
+	if err != nil {
+		return fmt.Errorf("failed to delete meeting: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	return nil
+}
+
+func (r *PostgresMeetingRepository) ListMeetings(ctx context.Context) ([]entity.Meeting, error) {
+	rows, err := r.pg.Pool.Query(ctx, `SELECT `+_meetingColumns+` FROM meetings ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select meetings: %w", err)
+	}
+
+	meetings, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.Meeting, error) {
+		meeting, err := scanMeeting(row)
+		if err != nil {
+			return entity.Meeting{}, err
+		}
+		return *meeting, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan meetings: %w", err)
+	}
+
+	userRows, err := r.pg.Pool.Query(ctx,
+		`SELECT meeting_id, id, name, joined_at FROM users ORDER BY joined_at, id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select users: %w", err)
+	}
+
+	var (
+		meetingID string
+		user      entity.User
+	)
+
+	usersByMeeting := make(map[string][]entity.User)
+	_, err = pgx.ForEachRow(userRows, []any{&meetingID, &user.ID, &user.Name, &user.JoinedAt}, func() error {
+		usersByMeeting[meetingID] = append(usersByMeeting[meetingID], user)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan users: %w", err)
+	}
+
+	sessions, err := selectSessions(ctx, r.pg.Pool, `SELECT meeting_id, user_id, joined_at, left_at FROM user_sessions ORDER BY joined_at`)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range meetings {
+		meetings[i].Users = usersByMeeting[meetings[i].ID]
+		if meetings[i].Users == nil {
+			meetings[i].Users = []entity.User{}
+		}
+		attachSessions(meetings[i].Users, sessions[meetings[i].ID])
+	}
+
+	return meetings, nil
+}
+
+func (r *PostgresMeetingRepository) AddPendingUser(ctx context.Context, meetingID string, user *entity.User) error {
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		if err := lockMeeting(ctx, tx, meetingID); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx,
+			`INSERT INTO lobby_users (id, meeting_id, name, requested_at) VALUES ($1, $2, $3, $4)`,
+			user.ID, meetingID, user.Name, user.JoinedAt,
+		)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("%w: %s", entity.ErrUserExists, user.ID)
+			}
+			return fmt.Errorf("failed to insert lobby user: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresMeetingRepository) RemovePendingUser(ctx context.Context, meetingID, userID string) (*entity.User, error) {
+	var user *entity.User
+
+	err := pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		if err := lockMeeting(ctx, tx, meetingID); err != nil {
+			return err
+		}
+
+		removed := entity.User{}
+		err := tx.QueryRow(ctx,
+			`DELETE FROM lobby_users WHERE meeting_id = $1 AND id = $2 RETURNING id, name, requested_at`,
+			meetingID, userID,
+		).Scan(&removed.ID, &removed.Name, &removed.JoinedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("failed to delete lobby user: %w", err)
+		}
+
+		user = &removed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresMeetingRepository) GetPendingUsers(ctx context.Context, meetingID string) ([]entity.User, error) {
+	var exists bool
+
+	err := r.pg.Pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM meetings WHERE id = $1)`,
+		meetingID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select meeting: %w", err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+	}
+
+	rows, err := r.pg.Pool.Query(ctx,
+		`SELECT id, name, requested_at FROM lobby_users WHERE meeting_id = $1 ORDER BY requested_at, id`,
+		meetingID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select lobby users: %w", err)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.User, error) {
+		var user entity.User
+		err := row.Scan(&user.ID, &user.Name, &user.JoinedAt)
+		return user, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan lobby users: %w", err)
+	}
+
+	return users, nil
+}
+
+// lockMeeting - блокирует строку встречи до конца транзакции,
+// чтобы параллельные изменения участников шли последовательно
+func lockMeeting(ctx context.Context, tx pgx.Tx, meetingID string) error {
+	var id string
+
+	err := tx.QueryRow(ctx,
+		`SELECT id FROM meetings WHERE id = $1 FOR UPDATE`,
+		meetingID,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %s", entity.ErrMeetingNotFound, meetingID)
+		}
+		return fmt.Errorf("failed to lock meeting: %w", err)
+	}
+
+	return nil
+}
+
+func insertUser(ctx context.Context, tx pgx.Tx, meetingID string, user *entity.User) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO users (id, meeting_id, name, joined_at) VALUES ($1, $2, $3, $4)`,
+		user.ID, meetingID, user.Name, user.JoinedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: %s", entity.ErrUserExists, user.ID)
+		}
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	return nil
+}
+
+func scanMeeting(row pgx.Row) (*entity.Meeting, error) {
+	meeting := &entity.Meeting{}
+
+	err := row.Scan(
+		&meeting.ID, &meeting.Code, &meeting.Name, &meeting.HostID, &meeting.Locked, &meeting.Lobby, &meeting.Mode,
+		&meeting.CreatedAt, &meeting.StartsAt, &meeting.EndsAt, &meeting.PasscodeHash, &meeting.Description, &meeting.Capacity,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return meeting, nil
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func selectUsers(ctx context.Context, q querier, meetingID string) ([]entity.User, error) {
+	rows, err := q.Query(ctx,
+		`SELECT id, name, joined_at FROM users WHERE meeting_id = $1 ORDER BY joined_at, id`,
+		meetingID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select users: %w", err)
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.User, error) {
+		var user entity.User
+		err := row.Scan(&user.ID, &user.Name, &user.JoinedAt)
+		return user, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan users: %w", err)
+	}
+
+	sessions, err := selectSessions(ctx, q,
+		`SELECT meeting_id, user_id, joined_at, left_at FROM user_sessions WHERE meeting_id = $1 ORDER BY joined_at`,
+		meetingID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attachSessions(users, sessions[meetingID])
+	return users, nil
+}
+
+// selectSessions - сессии по встрече и пользователю, query выбирает
+// meeting_id, user_id, joined_at и left_at
+func selectSessions(ctx context.Context, q querier, query string, args ...any) (map[string]map[string][]entity.UserSession, error) {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select user sessions: %w", err)
+	}
+
+	var (
+		meetingID string
+		userID    string
+		session   entity.UserSession
+	)
+
+	sessions := make(map[string]map[string][]entity.UserSession)
+	_, err = pgx.ForEachRow(rows, []any{&meetingID, &userID, &session.JoinedAt, &session.LeftAt}, func() error {
+		if sessions[meetingID] == nil {
+			sessions[meetingID] = make(map[string][]entity.UserSession)
+		}
+		sessions[meetingID][userID] = append(sessions[meetingID][userID], session)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// attachSessions - заполняет сессии участников и выводит из них IsOnline
+func attachSessions(users []entity.User, sessions map[string][]entity.UserSession) {
+	for i := range users {
+		users[i].Sessions = sessions[users[i].ID]
+		if users[i].Sessions == nil {
+			users[i].Sessions = []entity.UserSession{}
+		}
+
+		last := len(users[i].Sessions) - 1
+		users[i].IsOnline = last >= 0 && users[i].Sessions[last].LeftAt == nil
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == _uniqueViolationCode
+}
+
+func isUniqueViolationOf(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == _uniqueViolationCode && pgErr.ConstraintName == constraint
+}
@@ -0,0 +1,59 @@
+//go:build integration
+
+package repo_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/migrations"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/postgres"
+)
+
+// _testPGURLEnv - DSN базы для интеграционных тестов. Тесты собираются только
+// с тегом integration и без базы падают, а не пропускаются
+const _testPGURLEnv = "TEST_PG_URL"
+
+// newTestPostgres - подключение к базе из TEST_PG_URL с примененными миграциями
+func newTestPostgres(t *testing.T) *postgres.Postgres {
+	t.Helper()
+
+	url := os.Getenv(_testPGURLEnv)
+	if url == "" {
+		t.Fatalf("%s is not set, integration tests need a Postgres database", _testPGURLEnv)
+	}
+
+	pg, err := postgres.New(url, postgres.ConnAttempts(1))
+	if err != nil {
+		t.Fatalf("connect postgres: %v", err)
+	}
+	t.Cleanup(pg.Close)
+
+	if err := pg.Migrate(migrations.FS); err != nil {
+		t.Fatalf("migrate postgres: %v", err)
+	}
+	return pg
+}
+
+func TestPostgresMeetingRepository(t *testing.T) {
+	// Встречи у каждого случая свои, поэтому общая база не мешает случаям
+	meetingRepo := repo.NewPostgresMeetingRepository(newTestPostgres(t))
+	runMeetingRepoContract(t, func(t *testing.T) usecase.MeetingRepo {
+		return meetingRepo
+	})
+}
+
+func TestPostgresWebhookRepository(t *testing.T) {
+	pg := newTestPostgres(t)
+
+	// Журнал общий на таблицу, поэтому каждый случай начинается с пустых таблиц
+	runWebhookRepoContract(t, func(t *testing.T, logSize, deadLetterSize int) usecase.WebhookRepo {
+		if _, err := pg.Pool.Exec(context.Background(), `TRUNCATE webhook_deliveries, webhook_dead_letters`); err != nil {
+			t.Fatalf("truncate webhook tables: %v", err)
+		}
+		return repo.NewPostgresWebhookRepository(pg, logSize, deadLetterSize)
+	})
+}
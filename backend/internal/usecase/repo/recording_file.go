@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// _recordingMetaFile - описание записи рядом с ее файлами
+const _recordingMetaFile = "recording.json"
+
+// FileRecordingRepository - хранит описания записей в каталоге записей:
+// <root>/<meeting_id>/<recording_id>/recording.json
+type FileRecordingRepository struct {
+	root string
+}
+
+func NewFileRecordingRepository(root string) *FileRecordingRepository {
+	return &FileRecordingRepository{root: root}
+}
+
+func (r *FileRecordingRepository) SaveRecording(ctx context.Context, recording *entity.Recording) error {
+	dir, err := r.meetingDir(recording.MeetingID)
+	if err != nil {
+		return err
+	}
+	dir = filepath.Join(dir, recording.ID)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create recording dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recording: %w", err)
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы не оставить обрезанное описание
+	tmp := filepath.Join(dir, _recordingMetaFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(dir, _recordingMetaFile)); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+
+	return nil
+}
+
+func (r *FileRecordingRepository) ListRecordings(ctx context.Context, meetingID string) ([]entity.Recording, error) {
+	dir, err := r.meetingDir(meetingID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []entity.Recording{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recordings dir: %w", err)
+	}
+
+	recordings := make([]entity.Recording, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		// Каталог без описания - запись еще идет или сервер упал во время записи
+		data, err := os.ReadFile(filepath.Join(dir, e.Name(), _recordingMetaFile))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording: %w", err)
+		}
+
+		var recording entity.Recording
+		if err := json.Unmarshal(data, &recording); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal recording %s: %w", e.Name(), err)
+		}
+		recordings = append(recordings, recording)
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.Before(recordings[j].StartedAt)
+	})
+
+	return recordings, nil
+}
+
+// meetingDir - ID встречи приходит из URL, он не должен выводить за пределы каталога записей
+func (r *FileRecordingRepository) meetingDir(meetingID string) (string, error) {
+	if meetingID == "" || meetingID == "." || meetingID == ".." || strings.ContainsAny(meetingID, `/\`) {
+		return "", &entity.ValidationError{Field: "meeting_id", Reason: "is invalid"}
+	}
+
+	return filepath.Join(r.root, meetingID), nil
+}
@@ -0,0 +1,265 @@
+package repo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+)
+
+// newWebhookRepoFunc - пустой репозиторий с журналом на logSize доставок
+// и deadLetterSize недоставленных
+type newWebhookRepoFunc func(t *testing.T, logSize, deadLetterSize int) usecase.WebhookRepo
+
+func TestMemoryWebhookRepository(t *testing.T) {
+	runWebhookRepoContract(t, func(t *testing.T, logSize, deadLetterSize int) usecase.WebhookRepo {
+		return repo.NewMemoryWebhookRepository(logSize, deadLetterSize)
+	})
+}
+
+// runWebhookRepoContract - общие требования к реализациям WebhookRepo
+func runWebhookRepoContract(t *testing.T, newRepo newWebhookRepoFunc) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, newRepo newWebhookRepoFunc)
+	}{
+		{
+			name: "save and update delivery",
+			run: func(t *testing.T, newRepo newWebhookRepoFunc) {
+				ctx := context.Background()
+				r := newRepo(t, 10, 10)
+
+				first := newTestDelivery("first")
+				second := newTestDelivery("second")
+				mustSaveDelivery(t, r, first)
+				mustSaveDelivery(t, r, second)
+
+				// Обновление меняет доставку, но не ее место в журнале
+				first.Status = entity.WebhookDeliveryDelivered
+				first.Attempts = 2
+				first.LastStatusCode = 200
+				first.LastError = ""
+				first.UpdatedAt = first.UpdatedAt.Add(time.Minute)
+				first.NextAttemptAt = nil
+				mustSaveDelivery(t, r, first)
+
+				deliveries, err := r.ListDeliveries(ctx, "", 10)
+				if err != nil {
+					t.Fatalf("list deliveries: %v", err)
+				}
+				if len(deliveries) != 2 {
+					t.Fatalf("got %d deliveries, want 2", len(deliveries))
+				}
+				assertDelivery(t, deliveries[0], second)
+				assertDelivery(t, deliveries[1], first)
+			},
+		},
+		{
+			name: "filter deliveries",
+			run: func(t *testing.T, newRepo newWebhookRepoFunc) {
+				ctx := context.Background()
+				r := newRepo(t, 10, 10)
+
+				for _, id := range []string{"a", "b", "c", "d"} {
+					delivery := newTestDelivery(id)
+					if id == "b" || id == "d" {
+						delivery.Status = entity.WebhookDeliveryDeadLetter
+					}
+					mustSaveDelivery(t, r, delivery)
+				}
+
+				dead, err := r.ListDeliveries(ctx, entity.WebhookDeliveryDeadLetter, 10)
+				if err != nil || len(dead) != 2 || dead[0].ID != "d" || dead[1].ID != "b" {
+					t.Fatalf("got dead deliveries %+v, %v, want d and b", dead, err)
+				}
+
+				latest, err := r.ListDeliveries(ctx, "", 3)
+				if err != nil || len(latest) != 3 || latest[0].ID != "d" || latest[2].ID != "b" {
+					t.Fatalf("got latest deliveries %+v, %v, want d, c and b", latest, err)
+				}
+
+				delivered, err := r.ListDeliveries(ctx, entity.WebhookDeliveryDelivered, 10)
+				if err != nil || len(delivered) != 0 {
+					t.Fatalf("got delivered %+v, %v, want none", delivered, err)
+				}
+			},
+		},
+		{
+			name: "delivery log keeps latest",
+			run: func(t *testing.T, newRepo newWebhookRepoFunc) {
+				ctx := context.Background()
+				r := newRepo(t, 2, 10)
+
+				for _, id := range []string{"a", "b", "c"} {
+					mustSaveDelivery(t, r, newTestDelivery(id))
+				}
+				// Обновление доставки журнал не удлиняет
+				updated := newTestDelivery("c")
+				updated.Attempts = 2
+				mustSaveDelivery(t, r, updated)
+
+				deliveries, err := r.ListDeliveries(ctx, "", 10)
+				if err != nil || len(deliveries) != 2 || deliveries[0].ID != "c" || deliveries[1].ID != "b" {
+					t.Fatalf("got deliveries %+v, %v, want c and b", deliveries, err)
+				}
+			},
+		},
+		{
+			name: "take dead letter",
+			run: func(t *testing.T, newRepo newWebhookRepoFunc) {
+				ctx := context.Background()
+				r := newRepo(t, 10, 10)
+
+				first := newTestDeadLetter("first")
+				second := newTestDeadLetter("second")
+				mustSaveDeadLetter(t, r, first)
+				mustSaveDeadLetter(t, r, second)
+
+				letters, err := r.ListDeadLetters(ctx)
+				if err != nil || len(letters) != 2 {
+					t.Fatalf("got dead letters %+v, %v, want two", letters, err)
+				}
+				assertDeadLetter(t, letters[0], first)
+				assertDeadLetter(t, letters[1], second)
+
+				taken, err := r.TakeDeadLetter(ctx, "first")
+				if err != nil {
+					t.Fatalf("take dead letter: %v", err)
+				}
+				assertDeadLetter(t, *taken, first)
+
+				if _, err := r.TakeDeadLetter(ctx, "first"); !errors.Is(err, entity.ErrDeadLetterNotFound) {
+					t.Fatalf("take dead letter twice: got %v, want %v", err, entity.ErrDeadLetterNotFound)
+				}
+
+				letters, err = r.ListDeadLetters(ctx)
+				if err != nil || len(letters) != 1 || letters[0].Delivery.ID != "second" {
+					t.Fatalf("got dead letters %+v, %v, want only second", letters, err)
+				}
+			},
+		},
+		{
+			name: "dead letters keep latest",
+			run: func(t *testing.T, newRepo newWebhookRepoFunc) {
+				ctx := context.Background()
+				r := newRepo(t, 10, 2)
+
+				for _, id := range []string{"a", "b", "c"} {
+					mustSaveDeadLetter(t, r, newTestDeadLetter(id))
+				}
+
+				letters, err := r.ListDeadLetters(ctx)
+				if err != nil || len(letters) != 2 || letters[0].Delivery.ID != "b" || letters[1].Delivery.ID != "c" {
+					t.Fatalf("got dead letters %+v, %v, want b and c", letters, err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo)
+		})
+	}
+}
+
+// newTestDelivery - ожидающая повтора доставка. Время округлено до микросекунд,
+// с такой точностью его хранит Postgres
+func newTestDelivery(id string) *entity.WebhookDelivery {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	next := now.Add(time.Second)
+
+	return &entity.WebhookDelivery{
+		ID:             id,
+		EventID:        "event-" + id,
+		EventType:      entity.WebhookMeetingCreated,
+		MeetingID:      "meeting-1",
+		URL:            "https://example.com/hooks",
+		Status:         entity.WebhookDeliveryPending,
+		Attempts:       1,
+		LastStatusCode: 503,
+		LastError:      "unexpected status 503",
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		NextAttemptAt:  &next,
+	}
+}
+
+func newTestDeadLetter(deliveryID string) *entity.WebhookDeadLetter {
+	delivery := newTestDelivery(deliveryID)
+	delivery.Status = entity.WebhookDeliveryDeadLetter
+	delivery.NextAttemptAt = nil
+
+	return &entity.WebhookDeadLetter{
+		Delivery: *delivery,
+		Event: entity.WebhookEvent{
+			ID:        delivery.EventID,
+			Type:      delivery.EventType,
+			MeetingID: delivery.MeetingID,
+			CreatedAt: delivery.CreatedAt,
+			Data:      &entity.WebhookMeeting{Name: "Contract", Code: "abc-defg-hij", HostID: "host"},
+		},
+	}
+}
+
+func mustSaveDelivery(t *testing.T, r usecase.WebhookRepo, delivery *entity.WebhookDelivery) {
+	t.Helper()
+
+	if err := r.SaveDelivery(context.Background(), delivery); err != nil {
+		t.Fatalf("save delivery %s: %v", delivery.ID, err)
+	}
+}
+
+func mustSaveDeadLetter(t *testing.T, r usecase.WebhookRepo, letter *entity.WebhookDeadLetter) {
+	t.Helper()
+
+	if err := r.SaveDeadLetter(context.Background(), letter); err != nil {
+		t.Fatalf("save dead letter %s: %v", letter.Delivery.ID, err)
+	}
+}
+
+func assertDelivery(t *testing.T, got entity.WebhookDelivery, want *entity.WebhookDelivery) {
+	t.Helper()
+
+	sameNext := (got.NextAttemptAt == nil) == (want.NextAttemptAt == nil) &&
+		(got.NextAttemptAt == nil || got.NextAttemptAt.Equal(*want.NextAttemptAt))
+	if got.ID != want.ID || got.EventID != want.EventID || got.EventType != want.EventType || got.MeetingID != want.MeetingID ||
+		got.URL != want.URL || got.Status != want.Status || got.Attempts != want.Attempts ||
+		got.LastStatusCode != want.LastStatusCode || got.LastError != want.LastError ||
+		!got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) || !sameNext {
+		t.Fatalf("got delivery %+v, want %+v", got, *want)
+	}
+}
+
+// assertDeadLetter - событие сравнивается в том виде, в каком его получит подписчик
+func assertDeadLetter(t *testing.T, got entity.WebhookDeadLetter, want *entity.WebhookDeadLetter) {
+	t.Helper()
+
+	assertDelivery(t, got.Delivery, &want.Delivery)
+
+	if gotEvent, wantEvent := eventJSON(t, got.Event), eventJSON(t, want.Event); !reflect.DeepEqual(gotEvent, wantEvent) {
+		t.Fatalf("got event %v, want %v", gotEvent, wantEvent)
+	}
+}
+
+// eventJSON - тело события, разобранное без учета порядка полей
+func eventJSON(t *testing.T, event entity.WebhookEvent) any {
+	t.Helper()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal event: %v", err)
+	}
+
+	var body any
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	return body
+}
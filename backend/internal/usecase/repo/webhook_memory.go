@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// MemoryWebhookRepository - хранит последние logSize доставок и до deadLetterSize
+// недоставленных событий. При переполнении теряются самые старые записи
+type MemoryWebhookRepository struct {
+	deliveries     []entity.WebhookDelivery
+	deadLetters    []entity.WebhookDeadLetter
+	logSize        int
+	deadLetterSize int
+	mu             sync.RWMutex
+}
+
+func NewMemoryWebhookRepository(logSize, deadLetterSize int) *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		logSize:        logSize,
+		deadLetterSize: deadLetterSize,
+	}
+}
+
+func (r *MemoryWebhookRepository) SaveDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Обновляются обычно недавние доставки, поэтому поиск идет с конца
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].ID == delivery.ID {
+			r.deliveries[i] = *delivery
+			return nil
+		}
+	}
+
+	r.deliveries = append(r.deliveries, *delivery)
+	if len(r.deliveries) > r.logSize {
+		r.deliveries = append([]entity.WebhookDelivery(nil), r.deliveries[len(r.deliveries)-r.logSize:]...)
+	}
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) ListDeliveries(ctx context.Context, status string, limit int) ([]entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := make([]entity.WebhookDelivery, 0, min(limit, len(r.deliveries)))
+	for i := len(r.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if status == "" || r.deliveries[i].Status == status {
+			deliveries = append(deliveries, r.deliveries[i])
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *MemoryWebhookRepository) SaveDeadLetter(ctx context.Context, letter *entity.WebhookDeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deadLetters = append(r.deadLetters, *letter)
+	if len(r.deadLetters) > r.deadLetterSize {
+		r.deadLetters = append([]entity.WebhookDeadLetter(nil), r.deadLetters[len(r.deadLetters)-r.deadLetterSize:]...)
+	}
+
+	return nil
+}
+
+func (r *MemoryWebhookRepository) TakeDeadLetter(ctx context.Context, deliveryID string) (*entity.WebhookDeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.deadLetters {
+		if r.deadLetters[i].Delivery.ID == deliveryID {
+			letter := r.deadLetters[i]
+			r.deadLetters = append(r.deadLetters[:i:i], r.deadLetters[i+1:]...)
+			return &letter, nil
+		}
+	}
+
+	return nil, entity.ErrDeadLetterNotFound
+}
+
+func (r *MemoryWebhookRepository) ListDeadLetters(ctx context.Context) ([]entity.WebhookDeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]entity.WebhookDeadLetter{}, r.deadLetters...), nil
+}
@@ -0,0 +1,174 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+const _webhookDeliveryColumns = `id, event_id, event_type, meeting_id, url, status, attempts, last_status_code, last_error, created_at, updated_at, next_attempt_at`
+
+// PostgresWebhookRepository - журнал доставок и недоставленные события в Postgres.
+// Как и в памяти, хранятся последние logSize доставок и deadLetterSize недоставленных
+type PostgresWebhookRepository struct {
+	pg             *postgres.Postgres
+	logSize        int
+	deadLetterSize int
+}
+
+func NewPostgresWebhookRepository(pg *postgres.Postgres, logSize, deadLetterSize int) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{
+		pg:             pg,
+		logSize:        logSize,
+		deadLetterSize: deadLetterSize,
+	}
+}
+
+func (r *PostgresWebhookRepository) SaveDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		// xmax = 0 только у вставленной строки, обновление журнал не удлиняет
+		var inserted bool
+		err := tx.QueryRow(ctx,
+			`INSERT INTO webhook_deliveries (`+_webhookDeliveryColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT (id) DO UPDATE SET
+				status = EXCLUDED.status,
+				attempts = EXCLUDED.attempts,
+				last_status_code = EXCLUDED.last_status_code,
+				last_error = EXCLUDED.last_error,
+				updated_at = EXCLUDED.updated_at,
+				next_attempt_at = EXCLUDED.next_attempt_at
+			RETURNING xmax = 0`,
+			delivery.ID, delivery.EventID, delivery.EventType, delivery.MeetingID, delivery.URL, delivery.Status, delivery.Attempts,
+			delivery.LastStatusCode, delivery.LastError, delivery.CreatedAt, delivery.UpdatedAt, delivery.NextAttemptAt,
+		).Scan(&inserted)
+		if err != nil {
+			return fmt.Errorf("failed to save webhook delivery: %w", err)
+		}
+
+		if inserted {
+			return trimBySeq(ctx, tx, "webhook_deliveries", r.logSize)
+		}
+		return nil
+	})
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, status string, limit int) ([]entity.WebhookDelivery, error) {
+	rows, err := r.pg.Pool.Query(ctx,
+		`SELECT `+_webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE $1 = '' OR status = $1
+		ORDER BY seq DESC
+		LIMIT $2`,
+		status, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select webhook deliveries: %w", err)
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.WebhookDelivery, error) {
+		var d entity.WebhookDelivery
+		err := row.Scan(&d.ID, &d.EventID, &d.EventType, &d.MeetingID, &d.URL, &d.Status, &d.Attempts,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &d.NextAttemptAt)
+		return d, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *PostgresWebhookRepository) SaveDeadLetter(ctx context.Context, letter *entity.WebhookDeadLetter) error {
+	delivery, err := json.Marshal(letter.Delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter delivery: %w", err)
+	}
+	event, err := json.Marshal(letter.Event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter event: %w", err)
+	}
+
+	return pgx.BeginFunc(ctx, r.pg.Pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO webhook_dead_letters (delivery_id, delivery, event) VALUES ($1, $2, $3)
+			ON CONFLICT (delivery_id) DO UPDATE SET delivery = EXCLUDED.delivery, event = EXCLUDED.event`,
+			letter.Delivery.ID, delivery, event,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert dead letter: %w", err)
+		}
+
+		return trimBySeq(ctx, tx, "webhook_dead_letters", r.deadLetterSize)
+	})
+}
+
+func (r *PostgresWebhookRepository) TakeDeadLetter(ctx context.Context, deliveryID string) (*entity.WebhookDeadLetter, error) {
+	var delivery, event []byte
+	err := r.pg.Pool.QueryRow(ctx,
+		`DELETE FROM webhook_dead_letters WHERE delivery_id = $1 RETURNING delivery, event`,
+		deliveryID,
+	).Scan(&delivery, &event)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entity.ErrDeadLetterNotFound
+		}
+		return nil, fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+
+	return unmarshalDeadLetter(delivery, event)
+}
+
+func (r *PostgresWebhookRepository) ListDeadLetters(ctx context.Context) ([]entity.WebhookDeadLetter, error) {
+	rows, err := r.pg.Pool.Query(ctx, `SELECT delivery, event FROM webhook_dead_letters ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select dead letters: %w", err)
+	}
+
+	letters, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.WebhookDeadLetter, error) {
+		var delivery, event []byte
+		if err := row.Scan(&delivery, &event); err != nil {
+			return entity.WebhookDeadLetter{}, err
+		}
+
+		letter, err := unmarshalDeadLetter(delivery, event)
+		if err != nil {
+			return entity.WebhookDeadLetter{}, err
+		}
+		return *letter, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan dead letters: %w", err)
+	}
+
+	return letters, nil
+}
+
+// unmarshalDeadLetter - Data события читается как JSON объект, при повторной
+// отправке подписчик получает то же тело
+func unmarshalDeadLetter(delivery, event []byte) (*entity.WebhookDeadLetter, error) {
+	var letter entity.WebhookDeadLetter
+	if err := json.Unmarshal(delivery, &letter.Delivery); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dead letter delivery: %w", err)
+	}
+	if err := json.Unmarshal(event, &letter.Event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dead letter event: %w", err)
+	}
+	return &letter, nil
+}
+
+// trimBySeq - оставляет в таблице size последних по seq строк
+func trimBySeq(ctx context.Context, tx pgx.Tx, table string, size int) error {
+	_, err := tx.Exec(ctx,
+		`DELETE FROM `+table+` WHERE seq <= (SELECT seq FROM `+table+` ORDER BY seq DESC OFFSET $1 LIMIT 1)`,
+		size,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to trim %s: %w", table, err)
+	}
+	return nil
+}
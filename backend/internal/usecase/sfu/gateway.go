@@ -0,0 +1,61 @@
+package sfu
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+)
+
+// errPeerExists - ID соединения WHIP или WHEP клиента уже занят
+var errPeerExists = errors.New("sfu peer already exists")
+
+var _ usecase.MediaGateway = (*SFU)(nil)
+
+// Ingest - соединение WHIP клиента, который публикует дорожки от имени участника userID.
+// Возвращает ответ на offer со всеми кандидатами сервера
+func (s *SFU) Ingest(ctx context.Context, meetingID, userID, sdp string) (string, error) {
+	r, p, created, err := s.join(s.rooms, meetingID, userID, true, peerOptions{kind: peerIngest})
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return "", errPeerExists
+	}
+
+	answer, err := p.answerHTTP(ctx, sdp, nil)
+	if err != nil {
+		s.removePeer(r, p)
+		return "", err
+	}
+
+	return answer, nil
+}
+
+// Watch - соединение WHEP клиента viewerID, который получает дорожки участника publisherID.
+// Если участник ничего не публикует, возвращает entity.ErrNotPublishing
+func (s *SFU) Watch(ctx context.Context, meetingID, viewerID, publisherID, sdp string) (string, error) {
+	r, p, created, err := s.join(s.rooms, meetingID, viewerID, true, peerOptions{kind: peerViewer, watch: publisherID})
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return "", errPeerExists
+	}
+
+	answer, err := p.answerHTTP(ctx, sdp, func() error {
+		return r.attachViewer(p)
+	})
+	if err != nil {
+		s.removePeer(r, p)
+		return "", err
+	}
+
+	return answer, nil
+}
+
+// OnPeerFailed - handler вызывается, когда рвется соединение WHIP или WHEP клиента.
+// Задается до начала работы сервера
+func (s *SFU) OnPeerFailed(handler func(meetingID, peerID string)) {
+	s.peerFailedHandler = handler
+}
@@ -0,0 +1,288 @@
+package sfu
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+	"github.com/pion/webrtc/v4"
+)
+
+// peerKind - откуда пришло соединение. Сигнальные сообщения через WebSocket получает
+// только peerClient, соединения WHIP и WHEP согласуются одним HTTP запросом
+type peerKind int
+
+const (
+	peerClient peerKind = iota
+	// peerIngest - внешний кодировщик (WHIP), только публикует дорожки
+	peerIngest
+	// peerViewer - внешний плеер (WHEP), только получает дорожки одного участника
+	peerViewer
+)
+
+// peerOptions - параметры нового соединения. watch - чьи дорожки получает peerViewer
+type peerOptions struct {
+	kind  peerKind
+	watch string
+}
+
+// peer - соединение участника с сервером
+type peer struct {
+	room   *room
+	userID string
+	kind   peerKind
+	watch  string
+	pc     *webrtc.PeerConnection
+	// senders - дорожки других участников, которые получает этот участник, под room.mu
+	senders map[string]*webrtc.RTPSender
+	// slots - отправители плеера по типу дорожки, под room.mu. Плеер не может
+	// пересогласовать соединение, поэтому новая дорожка подменяет старую в том же отправителе
+	slots map[webrtc.RTPCodecType]*viewerSlot
+
+	// mu сериализует согласование SDP. negotiationPending - состав дорожек
+	// изменился, пока шло согласование, и серверу нужно отправить новый offer
+	mu                 sync.Mutex
+	negotiationPending bool
+
+	// Кандидаты сервера копятся, пока клиент не получил первое описание сессии
+	candidatesMu sync.Mutex
+	described    bool
+	candidates   []webrtc.ICECandidateInit
+}
+
+// viewerSlot - отправитель плеера и дорожка, которую он сейчас отправляет
+type viewerSlot struct {
+	sender *webrtc.RTPSender
+	track  atomic.Pointer[forwardedTrack]
+}
+
+func newPeer(r *room, userID string, opts peerOptions) (*peer, error) {
+	pc, err := r.sfu.api.NewPeerConnection(r.sfu.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peer connection: %w", err)
+	}
+
+	p := &peer{
+		room:    r,
+		userID:  userID,
+		kind:    opts.kind,
+		watch:   opts.watch,
+		pc:      pc,
+		senders: make(map[string]*webrtc.RTPSender),
+		slots:   make(map[webrtc.RTPCodecType]*viewerSlot),
+	}
+
+	pc.OnICECandidate(p.onICECandidate)
+	pc.OnTrack(func(remote *webrtc.TrackRemote, _ *webrtc.RTPReceiver) {
+		r.publish(p, remote)
+	})
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		// Клиент может переподключиться к медиасерверу новым offer
+		if state == webrtc.PeerConnectionStateFailed {
+			go r.sfu.peerFailed(r, p)
+		}
+	})
+
+	r.sfu.logger.Debug("sfu peer created", "meeting_id", r.meetingID, "user_id", userID)
+
+	return p, nil
+}
+
+// acceptOffer - отвечает на offer клиента. Если сервер в этот момент ждет ответа
+// на свой offer, он уступает: откатывает его и повторяет после ответа клиенту
+func (p *peer) acceptOffer(sdp string) error {
+	p.mu.Lock()
+
+	if p.pc.SignalingState() == webrtc.SignalingStateHaveLocalOffer {
+		if err := p.pc.SetLocalDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeRollback}); err != nil {
+			p.mu.Unlock()
+			return fmt.Errorf("failed to rollback local offer: %w", err)
+		}
+		p.negotiationPending = true
+	}
+
+	err := p.pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp})
+	if err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("failed to set remote offer: %w", err)
+	}
+
+	answer, err := p.pc.CreateAnswer(nil)
+	if err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("failed to create answer: %w", err)
+	}
+
+	if err := p.pc.SetLocalDescription(answer); err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("failed to set local answer: %w", err)
+	}
+
+	p.room.sfu.signal(p.room.meetingID, p.userID, protocol.NewAnswer(p.room.peerID(), p.userID, answer.SDP))
+	p.flushCandidates()
+
+	pending := p.negotiationPending
+	p.mu.Unlock()
+
+	if pending {
+		p.negotiate()
+	}
+
+	return nil
+}
+
+// answerHTTP - отвечает на offer WHIP/WHEP клиента. У него нет сигнального канала,
+// поэтому ответ возвращается со всеми кандидатами сервера. beforeAnswer вызывается,
+// когда известны трансиверы клиента, и может добавить в них дорожки
+func (p *peer) answerHTTP(ctx context.Context, sdp string, beforeAnswer func() error) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp})
+	if err != nil {
+		return "", &entity.ValidationError{Field: "sdp", Reason: err.Error()}
+	}
+
+	if beforeAnswer != nil {
+		if err := beforeAnswer(); err != nil {
+			return "", err
+		}
+	}
+
+	answer, err := p.pc.CreateAnswer(nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create answer: %w", err)
+	}
+
+	gathered := webrtc.GatheringCompletePromise(p.pc)
+	if err := p.pc.SetLocalDescription(answer); err != nil {
+		return "", fmt.Errorf("failed to set local answer: %w", err)
+	}
+
+	// Если сбор кандидатов не успел завершиться, клиент получит те, что уже есть
+	select {
+	case <-gathered:
+	case <-ctx.Done():
+	}
+
+	return p.pc.LocalDescription().SDP, nil
+}
+
+func (p *peer) acceptAnswer(sdp string) error {
+	p.mu.Lock()
+
+	if p.pc.SignalingState() != webrtc.SignalingStateHaveLocalOffer {
+		p.mu.Unlock()
+		return fmt.Errorf("unexpected answer in signaling state %s", p.pc.SignalingState())
+	}
+
+	err := p.pc.SetRemoteDescription(webrtc.SessionDescription{Type: webrtc.SDPTypeAnswer, SDP: sdp})
+	if err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("failed to set remote answer: %w", err)
+	}
+
+	pending := p.negotiationPending
+	p.mu.Unlock()
+
+	if pending {
+		p.negotiate()
+	}
+
+	return nil
+}
+
+// negotiate - отправляет клиенту offer с актуальным набором дорожек.
+// Пока идет другое согласование, offer откладывается до его завершения
+func (p *peer) negotiate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pc.ConnectionState() == webrtc.PeerConnectionStateClosed {
+		return
+	}
+
+	if p.pc.SignalingState() != webrtc.SignalingStateStable || p.pc.CurrentRemoteDescription() == nil {
+		p.negotiationPending = true
+		return
+	}
+	p.negotiationPending = false
+
+	offer, err := p.pc.CreateOffer(nil)
+	if err != nil {
+		p.room.sfu.logger.Error("failed to create sfu offer", "error", err, "meeting_id", p.room.meetingID, "user_id", p.userID)
+		return
+	}
+
+	if err := p.pc.SetLocalDescription(offer); err != nil {
+		p.room.sfu.logger.Error("failed to set sfu offer", "error", err, "meeting_id", p.room.meetingID, "user_id", p.userID)
+		return
+	}
+
+	p.room.sfu.signal(p.room.meetingID, p.userID, protocol.NewOffer(p.room.peerID(), p.userID, offer.SDP))
+	p.flushCandidates()
+}
+
+func (p *peer) addICECandidate(candidate *entity.ICECandidate) error {
+	err := p.pc.AddICECandidate(webrtc.ICECandidateInit{
+		Candidate:        candidate.Candidate,
+		SDPMid:           candidate.SDPMid,
+		SDPMLineIndex:    candidate.SDPMLineIndex,
+		UsernameFragment: candidate.UsernameFragment,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add ice candidate: %w", err)
+	}
+
+	return nil
+}
+
+func (p *peer) onICECandidate(candidate *webrtc.ICECandidate) {
+	// Кандидаты WHIP/WHEP соединений уходят клиенту в ответе на offer
+	if candidate == nil || p.kind != peerClient {
+		return
+	}
+
+	init := candidate.ToJSON()
+
+	p.candidatesMu.Lock()
+	if !p.described {
+		p.candidates = append(p.candidates, init)
+		p.candidatesMu.Unlock()
+		return
+	}
+	p.candidatesMu.Unlock()
+
+	p.sendCandidate(init)
+}
+
+// flushCandidates - клиент получил описание сессии, кандидаты можно отправлять сразу
+func (p *peer) flushCandidates() {
+	p.candidatesMu.Lock()
+	candidates := p.candidates
+	p.candidates = nil
+	p.described = true
+	p.candidatesMu.Unlock()
+
+	for _, candidate := range candidates {
+		p.sendCandidate(candidate)
+	}
+}
+
+func (p *peer) sendCandidate(init webrtc.ICECandidateInit) {
+	p.room.sfu.signal(p.room.meetingID, p.userID, protocol.NewICECandidate(p.room.peerID(), p.userID, &entity.ICECandidate{
+		Candidate:        init.Candidate,
+		SDPMid:           init.SDPMid,
+		SDPMLineIndex:    init.SDPMLineIndex,
+		UsernameFragment: init.UsernameFragment,
+	}))
+}
+
+func (p *peer) close() {
+	if err := p.pc.Close(); err != nil {
+		p.room.sfu.logger.Warn("failed to close sfu peer", "error", err, "meeting_id", p.room.meetingID, "user_id", p.userID)
+	}
+}
@@ -0,0 +1,240 @@
+package sfu
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/h264writer"
+	"github.com/pion/webrtc/v4/pkg/media/ivfwriter"
+	"github.com/pion/webrtc/v4/pkg/media/oggwriter"
+)
+
+var _ usecase.Recorder = (*SFU)(nil)
+
+// recording - запись дорожек комнаты, каждая дорожка пишется в свой файл каталога dir
+type recording struct {
+	dir     string
+	mu      sync.Mutex
+	writers []*trackWriter
+}
+
+// trackWriter - файл одной дорожки. Пакеты пишет горутина пересылки,
+// а закрывает остановка записи, поэтому запись и закрытие идут под mu
+type trackWriter struct {
+	file   entity.RecordingFile
+	path   string
+	writer media.Writer
+	mu     sync.Mutex
+	closed bool
+}
+
+// StartRecording - во встрече в режиме sfu пишутся дорожки, которые участники
+// уже публикуют серверу. В режиме mesh создается скрытый рекордер,
+// которому участники отправляют потоки сами
+func (s *SFU) StartRecording(meetingID, mode, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create recording dir: %w", err)
+	}
+
+	s.mu.Lock()
+	rooms, recorder := s.rooms, false
+	if mode != entity.MeetingModeSFU {
+		rooms, recorder = s.recorders, true
+	}
+
+	r, exists := rooms[meetingID]
+	if !exists {
+		r = newRoom(s, meetingID, recorder)
+		rooms[meetingID] = r
+	}
+	s.mu.Unlock()
+
+	return r.startRecording(&recording{dir: dir})
+}
+
+// StopRecording - закрывает файлы записи. Соединения с рекордером разрываются
+func (s *SFU) StopRecording(meetingID string) ([]entity.RecordingFile, error) {
+	s.mu.Lock()
+	r := s.recorders[meetingID]
+	if r == nil {
+		r = s.rooms[meetingID]
+	}
+	s.mu.Unlock()
+
+	if r == nil {
+		return nil, entity.ErrNotRecording
+	}
+
+	rec := r.stopRecording()
+	if rec == nil {
+		return nil, entity.ErrNotRecording
+	}
+
+	if r.recorder {
+		s.mu.Lock()
+		if s.recorders[meetingID] == r {
+			delete(s.recorders, meetingID)
+		}
+		s.mu.Unlock()
+
+		r.close()
+	} else {
+		s.dropIfIdle(r)
+	}
+
+	return rec.stop(), nil
+}
+
+func (s *SFU) HandleRecorderOffer(ctx context.Context, meetingID, userID, sdp string) error {
+	r, p, _, err := s.join(s.recorders, meetingID, userID, false, peerOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := p.acceptOffer(sdp); err != nil {
+		s.removePeer(r, p)
+		return err
+	}
+
+	return nil
+}
+
+func (s *SFU) AddRecorderICECandidate(ctx context.Context, meetingID, userID string, candidate *entity.ICECandidate) error {
+	p := s.findPeer(s.recorders, meetingID, userID)
+	if p == nil {
+		return ErrPeerNotFound
+	}
+
+	return p.addICECandidate(candidate)
+}
+
+// startRecording - начинает писать уже опубликованные дорожки, новые подключаются в publish
+func (r *room) startRecording(rec *recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.recording != nil {
+		return entity.ErrRecordingInProgress
+	}
+	r.recording = rec
+
+	for _, track := range r.tracks {
+		rec.attach(track)
+	}
+
+	return nil
+}
+
+func (r *room) stopRecording() *recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec := r.recording
+	r.recording = nil
+
+	for _, track := range r.tracks {
+		track.sink.Store(nil)
+	}
+
+	return rec
+}
+
+// attach - создает файл для дорожки. Дорожки с кодеком, для которого
+// нет контейнера, не записываются
+func (rec *recording) attach(track *forwardedTrack) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	codec := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(track.codec.MimeType, "audio/"), "video/"))
+	name := fmt.Sprintf("%s-%s-%d", track.publisher.userID, track.kind.String(), len(rec.writers)+1)
+
+	var writer media.Writer
+	var err error
+
+	switch {
+	case strings.EqualFold(track.codec.MimeType, webrtc.MimeTypeOpus):
+		name += ".ogg"
+		writer, err = oggwriter.New(filepath.Join(rec.dir, name), track.codec.ClockRate, track.codec.Channels)
+	case strings.EqualFold(track.codec.MimeType, webrtc.MimeTypeVP8),
+		strings.EqualFold(track.codec.MimeType, webrtc.MimeTypeVP9),
+		strings.EqualFold(track.codec.MimeType, webrtc.MimeTypeAV1):
+		name += ".ivf"
+		writer, err = ivfwriter.New(filepath.Join(rec.dir, name),
+			ivfwriter.WithCodec("video/"+strings.ToUpper(codec)),
+			ivfwriter.WithFrameRate(1, track.codec.ClockRate),
+			ivfwriter.WithDirectPTS(),
+		)
+	case strings.EqualFold(track.codec.MimeType, webrtc.MimeTypeH264):
+		name += ".h264"
+		writer, err = h264writer.New(filepath.Join(rec.dir, name))
+	default:
+		track.room.sfu.logger.Warn("codec is not supported for recording", "codec", track.codec.MimeType, "meeting_id", track.room.meetingID)
+		return
+	}
+	if err != nil {
+		track.room.sfu.logger.Error("failed to create recording file", "error", err, "meeting_id", track.room.meetingID)
+		return
+	}
+
+	w := &trackWriter{
+		file: entity.RecordingFile{
+			UserID: track.publisher.userID,
+			Kind:   track.kind.String(),
+			Codec:  codec,
+			Name:   name,
+		},
+		path:   filepath.Join(rec.dir, name),
+		writer: writer,
+	}
+	rec.writers = append(rec.writers, w)
+	track.sink.Store(w)
+
+	track.requestKeyframe()
+}
+
+// stop - закрывает все файлы и возвращает их описание
+func (rec *recording) stop() []entity.RecordingFile {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	files := make([]entity.RecordingFile, 0, len(rec.writers))
+	for _, w := range rec.writers {
+		files = append(files, w.close())
+	}
+
+	return files
+}
+
+func (w *trackWriter) WriteRTP(packet *rtp.Packet) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		_ = w.writer.WriteRTP(packet)
+	}
+}
+
+func (w *trackWriter) close() entity.RecordingFile {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.closed {
+		w.closed = true
+		_ = w.writer.Close()
+	}
+
+	if info, err := os.Stat(w.path); err == nil {
+		w.file.Size = info.Size()
+	}
+
+	return w.file
+}
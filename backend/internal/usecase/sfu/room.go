@@ -0,0 +1,432 @@
+package sfu
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+	"github.com/pion/rtcp"
+	"github.com/pion/webrtc/v4"
+)
+
+// _keyframeInterval - не чаще одного запроса ключевого кадра у публикующего,
+// иначе при входе многих участников сразу публикующий получит шторм PLI
+const _keyframeInterval = 500 * time.Millisecond
+
+// room - участники одной встречи и опубликованные ими дорожки.
+// Комната рекордера только принимает дорожки и никому их не пересылает
+type room struct {
+	sfu       *SFU
+	meetingID string
+	recorder  bool
+	peers     map[string]*peer
+	tracks    map[string]*forwardedTrack
+	recording *recording
+	mu        sync.Mutex
+}
+
+// forwardedTrack - дорожка участника, которую сервер пересылает остальным.
+// StreamID локальной дорожки равен ID публикующего, по нему клиент понимает, чей это поток
+// Если идет запись, пакеты дорожки также пишутся в sink
+type forwardedTrack struct {
+	key          string
+	room         *room
+	publisher    *peer
+	local        *webrtc.TrackLocalStaticRTP
+	ssrc         webrtc.SSRC
+	kind         webrtc.RTPCodecType
+	codec        webrtc.RTPCodecParameters
+	sink         atomic.Pointer[trackWriter]
+	lastKeyframe atomic.Int64
+}
+
+func newRoom(sfu *SFU, meetingID string, recorder bool) *room {
+	return &room{
+		sfu:       sfu,
+		meetingID: meetingID,
+		recorder:  recorder,
+		peers:     make(map[string]*peer),
+		tracks:    make(map[string]*forwardedTrack),
+	}
+}
+
+// peerID - от чьего имени сервер отправляет сигнальные сообщения участникам комнаты
+func (r *room) peerID() string {
+	if r.recorder {
+		return protocol.RecorderPeerID
+	}
+	return protocol.SFUPeerID
+}
+
+// join - соединение участника, при первом offer создается новое
+func (r *room) join(userID string, opts peerOptions) (*peer, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, exists := r.peers[userID]; exists {
+		return p, false, nil
+	}
+
+	p, err := newPeer(r, userID, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	r.peers[userID] = p
+
+	return p, true, nil
+}
+
+func (r *room) findPeer(userID string) *peer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.peers[userID]
+}
+
+// isIdle - в комнате нет участников и не идет запись, ее можно удалить
+func (r *room) isIdle() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.peers) == 0 && r.recording == nil
+}
+
+// subscribe - отдает новому участнику дорожки, опубликованные до его прихода
+func (r *room) subscribe(p *peer) {
+	if r.recorder {
+		return
+	}
+
+	r.mu.Lock()
+	added := false
+	for _, track := range r.tracks {
+		if track.publisher != p && r.addSender(p, track) {
+			added = true
+		}
+	}
+	r.mu.Unlock()
+
+	if added {
+		p.negotiate()
+	}
+}
+
+// publish - начинает пересылать дорожку участника всем остальным
+func (r *room) publish(p *peer, remote *webrtc.TrackRemote) {
+	trackID := remote.ID()
+	if trackID == "" {
+		trackID = strconv.FormatUint(uint64(remote.SSRC()), 10)
+	}
+
+	local, err := webrtc.NewTrackLocalStaticRTP(remote.Codec().RTPCodecCapability, trackID, p.userID)
+	if err != nil {
+		r.sfu.logger.Error("failed to create forwarded track", "error", err, "meeting_id", r.meetingID, "user_id", p.userID)
+		return
+	}
+
+	track := &forwardedTrack{
+		key:       p.userID + "/" + trackID,
+		room:      r,
+		publisher: p,
+		local:     local,
+		ssrc:      remote.SSRC(),
+		kind:      remote.Kind(),
+		codec:     remote.Codec(),
+	}
+
+	r.mu.Lock()
+	if r.peers[p.userID] != p {
+		r.mu.Unlock()
+		return
+	}
+
+	r.tracks[track.key] = track
+
+	if r.recording != nil {
+		r.recording.attach(track)
+	}
+
+	var subscribers []*peer
+	for _, subscriber := range r.peers {
+		if r.recorder || subscriber == p {
+			continue
+		}
+
+		if subscriber.kind == peerViewer {
+			r.feedViewer(subscriber, track)
+			continue
+		}
+
+		if subscriber.kind == peerClient && r.addSender(subscriber, track) {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	r.mu.Unlock()
+
+	r.sfu.logger.Debug("sfu track published", "meeting_id", r.meetingID, "user_id", p.userID, "kind", track.kind.String())
+
+	for _, subscriber := range subscribers {
+		subscriber.negotiate()
+	}
+
+	track.requestKeyframe()
+
+	go r.forward(track, remote)
+}
+
+// forward - копирует RTP пакеты публикующего в локальную дорожку до конца потока
+func (r *room) forward(track *forwardedTrack, remote *webrtc.TrackRemote) {
+	for {
+		packet, _, err := remote.ReadRTP()
+		if err != nil {
+			break
+		}
+
+		if sink := track.sink.Load(); sink != nil {
+			sink.WriteRTP(packet)
+		}
+
+		if err := track.local.WriteRTP(packet); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			break
+		}
+	}
+
+	r.unpublish(track)
+}
+
+// unpublish - снимает закончившуюся дорожку у всех получателей
+func (r *room) unpublish(track *forwardedTrack) {
+	r.mu.Lock()
+	if r.tracks[track.key] != track {
+		r.mu.Unlock()
+		return
+	}
+
+	delete(r.tracks, track.key)
+	subscribers := r.removeSenders(track.key)
+	r.mu.Unlock()
+
+	for subscriber := range subscribers {
+		subscriber.negotiate()
+	}
+}
+
+// remove - закрывает соединение участника и снимает его дорожки у остальных.
+// Возвращает true, если комнату можно удалить
+func (r *room) remove(p *peer) bool {
+	r.mu.Lock()
+	if r.peers[p.userID] != p {
+		idle := len(r.peers) == 0 && r.recording == nil
+		r.mu.Unlock()
+		return idle
+	}
+
+	delete(r.peers, p.userID)
+
+	affected := make(map[*peer]struct{})
+	for key, track := range r.tracks {
+		if track.publisher != p {
+			continue
+		}
+
+		delete(r.tracks, key)
+		for subscriber := range r.removeSenders(key) {
+			affected[subscriber] = struct{}{}
+		}
+	}
+
+	idle := len(r.peers) == 0 && r.recording == nil
+	r.mu.Unlock()
+
+	p.close()
+	r.sfu.logger.Debug("sfu peer removed", "meeting_id", r.meetingID, "user_id", p.userID)
+
+	for subscriber := range affected {
+		subscriber.negotiate()
+	}
+
+	return idle
+}
+
+func (r *room) close() {
+	r.mu.Lock()
+	peers := make([]*peer, 0, len(r.peers))
+	for _, p := range r.peers {
+		peers = append(peers, p)
+	}
+	r.peers = make(map[string]*peer)
+	r.tracks = make(map[string]*forwardedTrack)
+	r.mu.Unlock()
+
+	for _, p := range peers {
+		p.close()
+	}
+}
+
+// addSender - вызывается под r.mu. Возвращает false, если участник уже получает дорожку
+func (r *room) addSender(subscriber *peer, track *forwardedTrack) bool {
+	if _, exists := subscriber.senders[track.key]; exists {
+		return false
+	}
+
+	sender, err := subscriber.pc.AddTrack(track.local)
+	if err != nil {
+		r.sfu.logger.Error("failed to add forwarded track", "error", err, "meeting_id", r.meetingID, "user_id", subscriber.userID)
+		return false
+	}
+	subscriber.senders[track.key] = sender
+
+	go readRTCP(sender, func() *forwardedTrack { return track })
+
+	return true
+}
+
+// attachViewer - добавляет плееру дорожки участника, которого он смотрит, по одной
+// каждого типа. Вызывается до ответа на offer плеера
+func (r *room) attachViewer(viewer *peer) error {
+	kinds := make(map[webrtc.RTPCodecType]bool)
+	for _, transceiver := range viewer.pc.GetTransceivers() {
+		kinds[transceiver.Kind()] = true
+	}
+
+	r.mu.Lock()
+	var attached []*forwardedTrack
+	for _, track := range r.tracks {
+		if track.publisher.userID != viewer.watch || !kinds[track.kind] || viewer.slots[track.kind] != nil {
+			continue
+		}
+
+		sender, err := viewer.pc.AddTrack(track.local)
+		if err != nil {
+			r.mu.Unlock()
+			return fmt.Errorf("failed to add watched track: %w", err)
+		}
+
+		slot := &viewerSlot{sender: sender}
+		slot.track.Store(track)
+		viewer.slots[track.kind] = slot
+		attached = append(attached, track)
+
+		go readRTCP(sender, slot.track.Load)
+	}
+	r.mu.Unlock()
+
+	if len(attached) == 0 {
+		return entity.ErrNotPublishing
+	}
+
+	for _, track := range attached {
+		track.requestKeyframe()
+	}
+
+	return nil
+}
+
+// feedViewer - вызывается под r.mu. Отдает плееру новую дорожку участника,
+// которого он смотрит, если в отправителе этого типа сейчас ничего нет
+func (r *room) feedViewer(viewer *peer, track *forwardedTrack) {
+	slot := viewer.slots[track.kind]
+	if track.publisher.userID != viewer.watch || slot == nil {
+		return
+	}
+
+	if current := slot.track.Load(); current != nil && r.tracks[current.key] == current {
+		return
+	}
+
+	if err := slot.sender.ReplaceTrack(track.local); err != nil {
+		r.sfu.logger.Warn("failed to replace watched track", "error", err, "meeting_id", r.meetingID, "user_id", viewer.userID)
+		return
+	}
+	slot.track.Store(track)
+
+	go track.requestKeyframe()
+}
+
+// removeSenders - вызывается под r.mu. Возвращает участников, у которых дорожка была
+func (r *room) removeSenders(key string) map[*peer]struct{} {
+	subscribers := make(map[*peer]struct{})
+
+	for _, subscriber := range r.peers {
+		if subscriber.kind == peerViewer {
+			r.refillViewer(subscriber, key)
+			continue
+		}
+
+		sender, exists := subscriber.senders[key]
+		if !exists {
+			continue
+		}
+
+		delete(subscriber.senders, key)
+		_ = subscriber.pc.RemoveTrack(sender)
+		subscribers[subscriber] = struct{}{}
+	}
+
+	return subscribers
+}
+
+// refillViewer - вызывается под r.mu после удаления дорожки из r.tracks. Если плеер
+// получал эту дорожку, он переключается на другую дорожку того же участника
+func (r *room) refillViewer(viewer *peer, key string) {
+	for kind, slot := range viewer.slots {
+		current := slot.track.Load()
+		if current == nil || current.key != key {
+			continue
+		}
+
+		slot.track.Store(nil)
+		for _, track := range r.tracks {
+			if track.kind == kind {
+				r.feedViewer(viewer, track)
+				if slot.track.Load() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// readRTCP - RTCP от получателя нужно вычитывать, чтобы работали interceptor'ы.
+// Запросы ключевого кадра передаются публикующему дорожки, которую сейчас отправляет sender
+func readRTCP(sender *webrtc.RTPSender, track func() *forwardedTrack) {
+	for {
+		packets, _, err := sender.ReadRTCP()
+		if err != nil {
+			return
+		}
+
+		for _, packet := range packets {
+			switch packet.(type) {
+			case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+				if current := track(); current != nil {
+					current.requestKeyframe()
+				}
+			}
+		}
+	}
+}
+
+// requestKeyframe - просит публикующего прислать ключевой кадр, чтобы новый
+// получатель смог начать декодирование видео
+func (t *forwardedTrack) requestKeyframe() {
+	if t.kind != webrtc.RTPCodecTypeVideo {
+		return
+	}
+
+	now := time.Now().UnixNano()
+	last := t.lastKeyframe.Load()
+	if now-last < int64(_keyframeInterval) || !t.lastKeyframe.CompareAndSwap(last, now) {
+		return
+	}
+
+	_ = t.publisher.pc.WriteRTCP([]rtcp.Packet{
+		&rtcp.PictureLossIndication{MediaSSRC: uint32(t.ssrc)},
+	})
+}
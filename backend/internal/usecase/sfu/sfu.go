@@ -0,0 +1,257 @@
+// Package sfu - встроенный медиасервер (selective forwarding unit) на pion/webrtc.
+// Каждый участник встречи держит одно соединение с сервером: публикует в него
+// свои дорожки и получает дорожки остальных участников
+package sfu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	"github.com/pion/interceptor"
+	"github.com/pion/webrtc/v4"
+)
+
+// ErrPeerNotFound - участник еще не отправил offer серверу
+var ErrPeerNotFound = errors.New("sfu peer not found, send an offer first")
+
+// Config - сетевые параметры медиасервера
+type Config struct {
+	// PublicIP - адрес, который объявляется в host кандидатах вместо локального
+	PublicIP    string
+	UDPPortMin  uint16
+	UDPPortMax  uint16
+	STUNServers []string
+}
+
+// SFU - комнаты медиасервера по встречам этой реплики. recorders - комнаты
+// рекордеров встреч в режиме mesh, они существуют только пока идет запись
+type SFU struct {
+	api       *webrtc.API
+	config    webrtc.Configuration
+	broker    usecase.SignalingBroker
+	logger    logger.Interface
+	rooms     map[string]*room
+	recorders map[string]*room
+	mu        sync.Mutex
+
+	// peerFailedHandler - вызывается, когда рвется соединение WHIP или WHEP клиента
+	peerFailedHandler func(meetingID, peerID string)
+}
+
+var _ usecase.SFU = (*SFU)(nil)
+
+func New(broker usecase.SignalingBroker, cfg Config, logger logger.Interface) (*SFU, error) {
+	media := &webrtc.MediaEngine{}
+	if err := media.RegisterDefaultCodecs(); err != nil {
+		return nil, fmt.Errorf("failed to register codecs: %w", err)
+	}
+
+	interceptors := &interceptor.Registry{}
+	if err := webrtc.RegisterDefaultInterceptors(media, interceptors); err != nil {
+		return nil, fmt.Errorf("failed to register interceptors: %w", err)
+	}
+
+	settings := webrtc.SettingEngine{}
+	if cfg.UDPPortMin != 0 {
+		if err := settings.SetEphemeralUDPPortRange(cfg.UDPPortMin, cfg.UDPPortMax); err != nil {
+			return nil, fmt.Errorf("failed to set udp port range: %w", err)
+		}
+	}
+	if cfg.PublicIP != "" {
+		err := settings.SetICEAddressRewriteRules(webrtc.ICEAddressRewriteRule{
+			External:        []string{cfg.PublicIP},
+			AsCandidateType: webrtc.ICECandidateTypeHost,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set public ip: %w", err)
+		}
+	}
+
+	config := webrtc.Configuration{}
+	if len(cfg.STUNServers) > 0 {
+		config.ICEServers = []webrtc.ICEServer{{URLs: cfg.STUNServers}}
+	}
+
+	return &SFU{
+		api: webrtc.NewAPI(
+			webrtc.WithMediaEngine(media),
+			webrtc.WithInterceptorRegistry(interceptors),
+			webrtc.WithSettingEngine(settings),
+		),
+		config:    config,
+		broker:    broker,
+		logger:    logger,
+		rooms:     make(map[string]*room),
+		recorders: make(map[string]*room),
+	}, nil
+}
+
+// HandleOffer - первый offer участника создает его соединение с сервером,
+// следующие - пересогласование, например при включении демонстрации экрана
+func (s *SFU) HandleOffer(ctx context.Context, meetingID, userID, sdp string) error {
+	r, p, created, err := s.join(s.rooms, meetingID, userID, true, peerOptions{})
+	if err != nil {
+		return err
+	}
+
+	if err := p.acceptOffer(sdp); err != nil {
+		if created {
+			s.removePeer(r, p)
+		}
+		return err
+	}
+
+	if created {
+		r.subscribe(p)
+	}
+
+	return nil
+}
+
+func (s *SFU) HandleAnswer(ctx context.Context, meetingID, userID, sdp string) error {
+	p := s.findPeer(s.rooms, meetingID, userID)
+	if p == nil {
+		return ErrPeerNotFound
+	}
+
+	return p.acceptAnswer(sdp)
+}
+
+func (s *SFU) AddICECandidate(ctx context.Context, meetingID, userID string, candidate *entity.ICECandidate) error {
+	p := s.findPeer(s.rooms, meetingID, userID)
+	if p == nil {
+		return ErrPeerNotFound
+	}
+
+	return p.addICECandidate(candidate)
+}
+
+// RemovePeer - закрывает соединения участника с медиасервером и с рекордером
+func (s *SFU) RemovePeer(meetingID, userID string) {
+	s.mu.Lock()
+	rooms := []*room{s.rooms[meetingID], s.recorders[meetingID]}
+	s.mu.Unlock()
+
+	for _, r := range rooms {
+		if r == nil {
+			continue
+		}
+
+		if p := r.findPeer(userID); p != nil {
+			s.removePeer(r, p)
+		}
+	}
+}
+
+// Close - закрывает соединения всех участников, вызывается при остановке сервера
+func (s *SFU) Close() {
+	s.mu.Lock()
+	rooms := make([]*room, 0, len(s.rooms)+len(s.recorders))
+	for _, r := range s.rooms {
+		rooms = append(rooms, r)
+	}
+	for _, r := range s.recorders {
+		rooms = append(rooms, r)
+	}
+	s.rooms = make(map[string]*room)
+	s.recorders = make(map[string]*room)
+	s.mu.Unlock()
+
+	for _, r := range rooms {
+		r.close()
+	}
+}
+
+// join - находит или создает соединение участника. Комнаты создаются и удаляются
+// под s.mu, поэтому участник не может попасть в комнату, которую уже удалили.
+// Без create участник подключается только к существующей комнате
+func (s *SFU) join(rooms map[string]*room, meetingID, userID string, create bool, opts peerOptions) (*room, *peer, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, exists := rooms[meetingID]
+	if !exists {
+		if !create {
+			return nil, nil, false, entity.ErrNotRecording
+		}
+		r = newRoom(s, meetingID, false)
+		rooms[meetingID] = r
+	}
+
+	p, created, err := r.join(userID, opts)
+	if err != nil {
+		if r.isIdle() {
+			delete(rooms, meetingID)
+		}
+		return nil, nil, false, err
+	}
+
+	return r, p, created, nil
+}
+
+func (s *SFU) removePeer(r *room, p *peer) {
+	if idle := r.remove(p); idle {
+		s.dropIfIdle(r)
+	}
+}
+
+// peerFailed - соединение не восстановилось. Клиент WebSocket может переподключиться
+// новым offer, а о WHIP и WHEP сессиях нужно сообщить их владельцу
+func (s *SFU) peerFailed(r *room, p *peer) {
+	s.removePeer(r, p)
+
+	if p.kind != peerClient && s.peerFailedHandler != nil {
+		s.peerFailedHandler(r.meetingID, p.userID)
+	}
+}
+
+// dropIfIdle - удаляет комнату, в которой не осталось участников и записи
+func (s *SFU) dropIfIdle(r *room) {
+	s.mu.Lock()
+	rooms := s.rooms
+	if r.recorder {
+		rooms = s.recorders
+	}
+
+	if rooms[r.meetingID] == r && r.isIdle() {
+		delete(rooms, r.meetingID)
+	}
+	s.mu.Unlock()
+}
+
+func (s *SFU) findPeer(rooms map[string]*room, meetingID, userID string) *peer {
+	s.mu.Lock()
+	r := rooms[meetingID]
+	s.mu.Unlock()
+
+	if r == nil {
+		return nil
+	}
+
+	return r.findPeer(userID)
+}
+
+// signal - отправляет участнику сообщение от имени медиасервера через брокер
+func (s *SFU) signal(meetingID, userID string, message *entity.WSMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		s.logger.Error("failed to marshal sfu message", "error", err)
+		return
+	}
+
+	envelope := &entity.SignalEnvelope{
+		MeetingID: meetingID,
+		UserID:    userID,
+		Message:   data,
+	}
+
+	if err := s.broker.Publish(context.Background(), envelope); err != nil {
+		s.logger.Error("failed to publish sfu message", "error", err, "meeting_id", meetingID, "user_id", userID)
+	}
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// publishMessage - отправляет сообщение участникам встречи через брокер.
+// Пустой userID означает рассылку всем участникам
+func publishMessage(ctx context.Context, broker SignalingBroker, meetingID, userID string, message *entity.WSMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	envelope := &entity.SignalEnvelope{
+		MeetingID: meetingID,
+		UserID:    userID,
+		Message:   data,
+	}
+
+	if err := broker.Publish(ctx, envelope); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	return nil
+}
+
+// publishClose - просит реплику, которая держит соединение пользователя, закрыть его.
+// Пустой userID закрывает соединения всех участников встречи
+func publishClose(ctx context.Context, broker SignalingBroker, meetingID, userID string, code int, reason string) error {
+	envelope := &entity.SignalEnvelope{
+		MeetingID:   meetingID,
+		UserID:      userID,
+		CloseCode:   code,
+		CloseReason: reason,
+	}
+
+	if err := broker.Publish(ctx, envelope); err != nil {
+		return fmt.Errorf("failed to publish close: %w", err)
+	}
+
+	return nil
+}
+
+// Причины выхода участника и отбрасывания сообщений для метрик
+const (
+	leaveReasonLeft         = "left"
+	leaveReasonKicked       = "kicked"
+	leaveReasonMeetingEnded = "meeting_ended"
+	leaveReasonGone         = "gone"
+
+	dropReasonInvalid        = "invalid"
+	dropReasonDeliveryFailed = "delivery_failed"
+	dropReasonCommandFailed  = "command_failed"
+	dropReasonQueueOverflow  = "queue_overflow"
+	dropReasonSlowConsumer   = "slow_consumer"
+
+	// outboundMessageType - тип для метрик исходящих сообщений, которые не разбираются
+	outboundMessageType = "outbound"
+)
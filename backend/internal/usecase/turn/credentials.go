@@ -0,0 +1,59 @@
+package turn
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+// Credentials - учетные данные в стиле TURN REST API: имя пользователя
+// "<срок действия unix>:<meeting_id>:<user_id>", пароль - base64(HMAC-SHA1(secret, имя)).
+// Сервер ничего не хранит и проверяет пароль по общему секрету
+type Credentials struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewCredentials(secret string, ttl time.Duration) *Credentials {
+	return &Credentials{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+func (c *Credentials) Issue(meetingID, userID string) (*entity.TURNCredentials, error) {
+	expiresAt := time.Now().Add(c.ttl).Truncate(time.Second)
+	username := strings.Join([]string{strconv.FormatInt(expiresAt.Unix(), 10), meetingID, userID}, ":")
+
+	return &entity.TURNCredentials{
+		Username:  username,
+		Password:  c.password(username),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// verify - проверяет имя пользователя и возвращает пароль для него
+func (c *Credentials) verify(username string, now time.Time) (string, bool) {
+	parts := strings.Split(username, ":")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+
+	expiresAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || now.Unix() > expiresAt {
+		return "", false
+	}
+
+	return c.password(username), true
+}
+
+func (c *Credentials) password(username string) string {
+	mac := hmac.New(sha1.New, c.secret)
+	mac.Write([]byte(username))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
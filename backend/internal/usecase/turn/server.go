@@ -0,0 +1,166 @@
+// Package turn - встроенный TURN/STUN сервер на pion/turn для клиентов за NAT,
+// через который не проходит прямое соединение
+package turn
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+	pionturn "github.com/pion/turn/v4"
+)
+
+// Config - сетевые параметры TURN сервера
+type Config struct {
+	// PublicIP - адрес, на котором клиенты получают relay кандидатов
+	PublicIP string
+	// Port - порт STUN/TURN для UDP и TCP
+	Port  int
+	Realm string
+	// RelayPortMin и RelayPortMax - диапазон портов relay, 0 - любые порты
+	RelayPortMin uint16
+	RelayPortMax uint16
+	// AllowedPeers - CIDR внутренних и служебных сетей, куда relay все же разрешен
+	AllowedPeers []string
+}
+
+type Server struct {
+	server *pionturn.Server
+}
+
+func New(cfg Config, credentials *Credentials, logger logger.Interface) (*Server, error) {
+	publicIP := net.ParseIP(cfg.PublicIP)
+	if publicIP == nil {
+		return nil, fmt.Errorf("invalid turn public ip: %q", cfg.PublicIP)
+	}
+
+	allowedPeers := make([]*net.IPNet, 0, len(cfg.AllowedPeers))
+	for _, cidr := range cfg.AllowedPeers {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid turn allowed peer %q: %w", cidr, err)
+		}
+		allowedPeers = append(allowedPeers, network)
+	}
+
+	address := net.JoinHostPort("0.0.0.0", strconv.Itoa(cfg.Port))
+
+	udpConn, err := net.ListenPacket("udp4", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen udp: %w", err)
+	}
+
+	tcpListener, err := net.Listen("tcp4", address)
+	if err != nil {
+		_ = udpConn.Close()
+		return nil, fmt.Errorf("failed to listen tcp: %w", err)
+	}
+
+	server, err := pionturn.NewServer(pionturn.ServerConfig{
+		Realm:       cfg.Realm,
+		AuthHandler: authHandler(credentials, logger),
+		PacketConnConfigs: []pionturn.PacketConnConfig{{
+			PacketConn:            udpConn,
+			RelayAddressGenerator: relayGenerator(cfg, publicIP),
+			PermissionHandler:     permissionHandler(publicIP, allowedPeers, logger),
+		}},
+		ListenerConfigs: []pionturn.ListenerConfig{{
+			Listener:              tcpListener,
+			RelayAddressGenerator: relayGenerator(cfg, publicIP),
+			PermissionHandler:     permissionHandler(publicIP, allowedPeers, logger),
+		}},
+	})
+	if err != nil {
+		_ = udpConn.Close()
+		_ = tcpListener.Close()
+		return nil, fmt.Errorf("failed to start turn server: %w", err)
+	}
+
+	return &Server{server: server}, nil
+}
+
+// Close - закрывает слушающие сокеты и все выделенные relay
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// authHandler - ключ long-term credentials для имени пользователя, выданного Credentials.Issue
+func authHandler(credentials *Credentials, logger logger.Interface) pionturn.AuthHandler {
+	return func(username, realm string, srcAddr net.Addr) ([]byte, bool) {
+		password, ok := credentials.verify(username, time.Now())
+		if !ok {
+			logger.Warn("turn auth rejected", "username", username, "addr", srcAddr.String())
+			return nil, false
+		}
+
+		return pionturn.GenerateAuthKey(username, realm, password), true
+	}
+}
+
+// _reservedPeers - сети, которые не покрывают проверки net.IP: "этот" хост и сеть,
+// shared address space операторов (CGNAT) и ограниченный broadcast
+var _reservedPeers = mustParseCIDRs("0.0.0.0/8", "100.64.0.0/10", "255.255.255.255/32")
+
+// permissionHandler - relay только к публичным адресам. Иначе любой участник встречи
+// мог бы через TURN обращаться к сервисам во внутренней сети сервера или к самому
+// серверу по его публичному адресу
+func permissionHandler(publicIP net.IP, allowedPeers []*net.IPNet, logger logger.Interface) pionturn.PermissionHandler {
+	return func(clientAddr net.Addr, peerIP net.IP) bool {
+		for _, network := range allowedPeers {
+			if network.Contains(peerIP) {
+				return true
+			}
+		}
+
+		if !isPublicPeer(peerIP) || peerIP.Equal(publicIP) {
+			logger.Warn("turn permission rejected", "peer", peerIP.String(), "addr", clientAddr.String())
+			return false
+		}
+
+		return true
+	}
+}
+
+func isPublicPeer(ip net.IP) bool {
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, network := range _reservedPeers {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func relayGenerator(cfg Config, publicIP net.IP) pionturn.RelayAddressGenerator {
+	if cfg.RelayPortMin != 0 {
+		return &pionturn.RelayAddressGeneratorPortRange{
+			RelayAddress: publicIP,
+			Address:      "0.0.0.0",
+			MinPort:      cfg.RelayPortMin,
+			MaxPort:      cfg.RelayPortMax,
+		}
+	}
+
+	return &pionturn.RelayAddressGeneratorStatic{
+		RelayAddress: publicIP,
+		Address:      "0.0.0.0",
+	}
+}
@@ -0,0 +1,54 @@
+package turn
+
+import (
+	"net"
+	"testing"
+
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/logger"
+)
+
+func TestPermissionHandler(t *testing.T) {
+	allowed := mustParseCIDRs("10.0.5.0/24", "100.64.1.0/24")
+	handler := permissionHandler(net.ParseIP("198.51.100.1"), allowed, logger.Nop{})
+	client := &net.UDPAddr{IP: net.ParseIP("203.0.113.10"), Port: 50000}
+
+	tests := []struct {
+		peer string
+		want bool
+	}{
+		{peer: "198.51.100.7", want: true},
+		{peer: "2001:db8::1", want: true},
+		{peer: "10.0.5.20", want: true},
+		{peer: "10.0.6.20", want: false},
+		{peer: "172.16.0.1", want: false},
+		{peer: "192.168.1.1", want: false},
+		{peer: "127.0.0.1", want: false},
+		{peer: "::1", want: false},
+		{peer: "169.254.169.254", want: false},
+		{peer: "fe80::1", want: false},
+		{peer: "fd00::1", want: false},
+		{peer: "0.0.0.0", want: false},
+		{peer: "::", want: false},
+		{peer: "::ffff:192.168.1.1", want: false},
+		{peer: "198.51.100.1", want: false},
+		{peer: "::ffff:198.51.100.1", want: false},
+		{peer: "100.64.0.1", want: false},
+		{peer: "100.127.255.254", want: false},
+		{peer: "100.128.0.1", want: true},
+		{peer: "100.64.1.5", want: true},
+		{peer: "0.1.2.3", want: false},
+		{peer: "255.255.255.255", want: false},
+		{peer: "224.0.0.251", want: false},
+		{peer: "239.255.255.250", want: false},
+		{peer: "ff02::1", want: false},
+		{peer: "ff0e::1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.peer, func(t *testing.T) {
+			if got := handler(client, net.ParseIP(tt.peer)); got != tt.want {
+				t.Fatalf("got permission %v for %s, want %v", got, tt.peer, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+)
+
+const (
+	_defaultDeliveriesLimit = 50
+	_maxDeliveriesLimit     = 500
+)
+
+// WebhookConfig - подписчики и политика повторов. Попытка i ждет
+// InitialBackoff * 2^(i-1), но не больше MaxBackoff
+type WebhookConfig struct {
+	Subscriptions  []entity.WebhookSubscription
+	Workers        int
+	QueueSize      int
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// webhookJob - доставка в очереди вместе с событием и секретом подписчика
+type webhookJob struct {
+	delivery entity.WebhookDelivery
+	event    *entity.WebhookEvent
+	secret   string
+}
+
+type webhookService struct {
+	repo   WebhookRepo
+	sender WebhookSender
+	events EventBus
+	clock  Clock
+	cfg    WebhookConfig
+
+	jobs         chan *webhookJob
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	wg           sync.WaitGroup
+}
+
+// NewWebhookService - без подписчиков сервис не подписывается на события
+func NewWebhookService(repo WebhookRepo, sender WebhookSender, events EventBus, clock Clock, cfg WebhookConfig) *webhookService {
+	return &webhookService{
+		repo:     repo,
+		sender:   sender,
+		events:   events,
+		clock:    clock,
+		cfg:      cfg,
+		jobs:     make(chan *webhookJob, cfg.QueueSize),
+		shutdown: make(chan struct{}),
+	}
+}
+
+var _ WebhookUseCase = (*webhookService)(nil)
+
+// Start - подписывается на доменные события и запускает отправителей
+func (uc *webhookService) Start(ctx context.Context) {
+	if len(uc.cfg.Subscriptions) == 0 {
+		return
+	}
+
+	uc.events.Subscribe(ctx, uc.onEvent)
+
+	for range uc.cfg.Workers {
+		uc.wg.Add(1)
+		go uc.worker(ctx)
+	}
+}
+
+// Shutdown - дожидается текущих попыток. Отложенные повторы не выполняются,
+// такие доставки остаются в журнале в статусе pending
+func (uc *webhookService) Shutdown() {
+	uc.shutdownOnce.Do(func() {
+		close(uc.shutdown)
+	})
+	uc.wg.Wait()
+}
+
+// onEvent - события встреч и участников, которые видят внешние системы
+func (uc *webhookService) onEvent(ctx context.Context, event entity.DomainEvent) {
+	meta := event.Meta()
+
+	switch e := event.(type) {
+	case *entity.MeetingCreatedEvent:
+		uc.publish(newWebhookEvent(entity.WebhookMeetingCreated, meta, &entity.WebhookMeeting{
+			Name:   e.Meeting.Name,
+			Code:   e.Meeting.Code,
+			HostID: e.Meeting.HostID,
+		}))
+	case *entity.MeetingEndedEvent:
+		uc.publish(newWebhookEvent(entity.WebhookMeetingEnded, meta, &entity.WebhookMeeting{
+			Name:    e.Meeting.Name,
+			Code:    e.Meeting.Code,
+			HostID:  e.Meeting.HostID,
+			Reason:  e.Reason,
+			EndedBy: e.By,
+		}))
+	case *entity.ParticipantConnectedEvent:
+		uc.publish(newWebhookEvent(entity.WebhookParticipantJoined, meta, &entity.WebhookParticipant{
+			UserID:   e.UserID,
+			UserName: e.UserName,
+		}))
+	case *entity.ParticipantDisconnectedEvent:
+		uc.publish(newWebhookEvent(entity.WebhookParticipantLeft, meta, &entity.WebhookParticipant{
+			UserID:   e.UserID,
+			UserName: e.UserName,
+		}))
+	}
+}
+
+func (uc *webhookService) publish(event *entity.WebhookEvent) {
+	for _, subscription := range uc.cfg.Subscriptions {
+		if !subscription.Accepts(event.Type) {
+			continue
+		}
+
+		uc.enqueue(uc.newJob(event, subscription))
+	}
+}
+
+func (uc *webhookService) ListDeliveries(ctx context.Context, status string, limit int) ([]entity.WebhookDelivery, error) {
+	switch status {
+	case "", entity.WebhookDeliveryPending, entity.WebhookDeliveryDelivered, entity.WebhookDeliveryDeadLetter:
+	default:
+		return nil, &entity.ValidationError{Field: "status", Reason: "must be pending, delivered or dead"}
+	}
+
+	if limit <= 0 {
+		limit = _defaultDeliveriesLimit
+	}
+	limit = min(limit, _maxDeliveriesLimit)
+
+	deliveries, err := uc.repo.ListDeliveries(ctx, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (uc *webhookService) ListDeadLetters(ctx context.Context) ([]entity.WebhookDeadLetter, error) {
+	letters, err := uc.repo.ListDeadLetters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	return letters, nil
+}
+
+// RetryDeadLetter - повтор идет новой доставкой того же события, прежняя
+// остается в журнале в статусе dead
+func (uc *webhookService) RetryDeadLetter(ctx context.Context, deliveryID string) (*entity.WebhookDelivery, error) {
+	letter, err := uc.repo.TakeDeadLetter(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Секрет не хранится вместе с событием, поэтому подписчик должен остаться в конфигурации
+	var subscription *entity.WebhookSubscription
+	for i := range uc.cfg.Subscriptions {
+		if uc.cfg.Subscriptions[i].URL == letter.Delivery.URL {
+			subscription = &uc.cfg.Subscriptions[i]
+			break
+		}
+	}
+	if subscription == nil {
+		_ = uc.repo.SaveDeadLetter(ctx, letter)
+		return nil, entity.ErrWebhookSubscriptionGone
+	}
+
+	// Копия снимается до постановки в очередь: дальше доставку меняет отправитель
+	job := uc.newJob(&letter.Event, *subscription)
+	delivery := job.delivery
+	uc.enqueue(job)
+
+	return &delivery, nil
+}
+
+func (uc *webhookService) newJob(event *entity.WebhookEvent, subscription entity.WebhookSubscription) *webhookJob {
+	now := uc.clock.Now()
+
+	job := &webhookJob{
+		delivery: entity.WebhookDelivery{
+			ID:        entity.GenerateWebhookDeliveryID(),
+			EventID:   event.ID,
+			EventType: event.Type,
+			MeetingID: event.MeetingID,
+			URL:       subscription.URL,
+			Status:    entity.WebhookDeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		event:  event,
+		secret: subscription.Secret,
+	}
+	_ = uc.repo.SaveDelivery(context.Background(), &job.delivery)
+
+	return job
+}
+
+// enqueue - не блокируется: доставка, для которой нет места в очереди, сразу
+// уходит в недоставленные, чтобы медленный подписчик не задерживал встречи
+func (uc *webhookService) enqueue(job *webhookJob) {
+	select {
+	case uc.jobs <- job:
+	default:
+		job.delivery.LastError = "delivery queue is full"
+		uc.bury(job)
+	}
+}
+
+func (uc *webhookService) worker(ctx context.Context) {
+	defer uc.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-uc.shutdown:
+			return
+		case job := <-uc.jobs:
+			uc.deliver(ctx, job)
+		}
+	}
+}
+
+func (uc *webhookService) deliver(ctx context.Context, job *webhookJob) {
+	status, err := uc.sender.Send(ctx, job.delivery.URL, job.secret, job.delivery.ID, job.event)
+
+	delivery := &job.delivery
+	delivery.Attempts++
+	delivery.LastStatusCode = status
+	delivery.UpdatedAt = uc.clock.Now()
+	delivery.NextAttemptAt = nil
+
+	if err == nil {
+		delivery.Status = entity.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		_ = uc.repo.SaveDelivery(ctx, delivery)
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= uc.cfg.MaxAttempts {
+		uc.bury(job)
+		return
+	}
+
+	backoff := uc.backoff(delivery.Attempts)
+	nextAttemptAt := delivery.UpdatedAt.Add(backoff)
+	delivery.NextAttemptAt = &nextAttemptAt
+	_ = uc.repo.SaveDelivery(ctx, delivery)
+
+	uc.clock.AfterFunc(backoff, func() {
+		select {
+		case <-uc.shutdown:
+		default:
+			uc.enqueue(job)
+		}
+	})
+}
+
+// bury - доставка исчерпала попытки или не поместилась в очередь
+func (uc *webhookService) bury(job *webhookJob) {
+	delivery := &job.delivery
+	delivery.Status = entity.WebhookDeliveryDeadLetter
+	delivery.UpdatedAt = uc.clock.Now()
+	delivery.NextAttemptAt = nil
+
+	_ = uc.repo.SaveDelivery(context.Background(), delivery)
+	_ = uc.repo.SaveDeadLetter(context.Background(), &entity.WebhookDeadLetter{
+		Delivery: *delivery,
+		Event:    *job.event,
+	})
+}
+
+func (uc *webhookService) backoff(attempts int) time.Duration {
+	backoff := uc.cfg.InitialBackoff
+	for i := 1; i < attempts && backoff < uc.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, uc.cfg.MaxBackoff)
+}
+
+func newWebhookEvent(eventType string, meta entity.EventMeta, data any) *entity.WebhookEvent {
+	return &entity.WebhookEvent{
+		ID:        entity.GenerateWebhookEventID(),
+		Type:      eventType,
+		MeetingID: meta.MeetingID,
+		CreatedAt: meta.At,
+		Data:      data,
+	}
+}
@@ -0,0 +1,117 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/webhook"
+)
+
+const _testSecret = "s3cret"
+
+// receivedRequest - то, что получил подписчик
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func TestHTTPSenderSend(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantErr    bool
+		wantStatus int
+	}{
+		{name: "delivered", status: http.StatusOK, wantStatus: http.StatusOK},
+		{name: "no content", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true, wantStatus: http.StatusInternalServerError},
+		{name: "redirect", status: http.StatusFound, wantErr: true, wantStatus: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan receivedRequest, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received <- receivedRequest{header: r.Header.Clone(), body: body}
+
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			event := &entity.WebhookEvent{ID: "event-1", Type: entity.WebhookMeetingCreated, MeetingID: "meeting-1"}
+			before := time.Now()
+
+			status, err := webhook.NewHTTPSender(time.Second).Send(context.Background(), server.URL, _testSecret, "delivery-1", event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if status != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", status, tt.wantStatus)
+			}
+
+			request := <-received
+			if got := request.header.Get(webhook.HeaderEvent); got != entity.WebhookMeetingCreated {
+				t.Fatalf("got event header %q, want %q", got, entity.WebhookMeetingCreated)
+			}
+			if got := request.header.Get(webhook.HeaderDelivery); got != "delivery-1" {
+				t.Fatalf("got delivery header %q, want delivery-1", got)
+			}
+
+			var got entity.WebhookEvent
+			if err := json.Unmarshal(request.body, &got); err != nil || got.ID != event.ID {
+				t.Fatalf("got body %s, want event %s", request.body, event.ID)
+			}
+
+			verifySignature(t, request.header.Get(webhook.HeaderSignature), request.body, before)
+		})
+	}
+}
+
+// verifySignature - проверяет подпись так, как это делает подписчик: разбирает
+// t и v1 и сверяет v1 с HMAC от "<t>.<тело>"
+func verifySignature(t *testing.T, signature string, body []byte, sentAfter time.Time) {
+	t.Helper()
+
+	parts := strings.Split(signature, ",")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") || !strings.HasPrefix(parts[1], "v1=") {
+		t.Fatalf("got signature %q, want t=<unix>,v1=<hex>", signature)
+	}
+
+	timestamp, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+	if err != nil {
+		t.Fatalf("parse signature timestamp: %v", err)
+	}
+	at := time.Unix(timestamp, 0)
+	if at.Before(sentAfter.Truncate(time.Second)) || at.After(time.Now()) {
+		t.Fatalf("got signature time %s, want the time of sending", at)
+	}
+
+	if want := webhook.Sign(_testSecret, at, body); signature != want {
+		t.Fatalf("got signature %q, want %q", signature, want)
+	}
+	if webhook.Sign("other", at, body) == signature {
+		t.Fatal("signature does not depend on the secret")
+	}
+}
+
+func TestSignKnownValue(t *testing.T) {
+	// Значение посчитано независимо:
+	// printf '1700000000.{}' | openssl dgst -sha256 -hmac s3cret
+	const want = "t=1700000000,v1=97926816e98fbb41ccb1673225ff29a2f35369099990e1b1561651e7bd097ebf"
+
+	if got := webhook.Sign(_testSecret, time.Unix(1700000000, 0), []byte("{}")); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
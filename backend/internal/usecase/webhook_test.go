@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/webhook"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+const (
+	_testWebhookSecret  = "s3cret"
+	_testInitialBackoff = time.Second
+	_testMaxBackoff     = 3 * time.Second
+)
+
+// webhookReceiver - подписчик, который отвечает статусами из responses по очереди,
+// а после них - последним. Подпись каждого запроса проверяется
+type webhookReceiver struct {
+	server   *httptest.Server
+	attempts atomic.Int32
+}
+
+func newWebhookReceiver(t *testing.T, responses ...int) *webhookReceiver {
+	t.Helper()
+
+	receiver := &webhookReceiver{}
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt := int(receiver.attempts.Add(1))
+
+		body, _ := io.ReadAll(r.Body)
+		signature := r.Header.Get(webhook.HeaderSignature)
+		var timestamp int64
+		if _, err := fmt.Sscanf(signature, "t=%d,", &timestamp); err != nil ||
+			signature != webhook.Sign(_testWebhookSecret, time.Unix(timestamp, 0), body) {
+			t.Errorf("attempt %d: bad signature %q", attempt, signature)
+		}
+
+		w.WriteHeader(responses[min(attempt, len(responses))-1])
+	}))
+	t.Cleanup(receiver.server.Close)
+
+	return receiver
+}
+
+type webhookFixture struct {
+	clock    *clock.Fake
+	repo     *repo.MemoryWebhookRepository
+	events   EventBus
+	service  *webhookService
+	receiver *webhookReceiver
+}
+
+func newWebhookFixture(t *testing.T, maxAttempts int, responses ...int) *webhookFixture {
+	t.Helper()
+
+	f := &webhookFixture{
+		clock:    clock.NewFake(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)),
+		repo:     repo.NewMemoryWebhookRepository(100, 100),
+		events:   NewEventBus(),
+		receiver: newWebhookReceiver(t, responses...),
+	}
+	f.service = NewWebhookService(f.repo, webhook.NewHTTPSender(time.Second), f.events, f.clock, WebhookConfig{
+		Subscriptions:  []entity.WebhookSubscription{{URL: f.receiver.server.URL, Secret: _testWebhookSecret}},
+		Workers:        1,
+		QueueSize:      16,
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: _testInitialBackoff,
+		MaxBackoff:     _testMaxBackoff,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.service.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		f.service.Shutdown()
+	})
+
+	return f
+}
+
+// publish - событие о создании встречи, на которое подписан receiver
+func (f *webhookFixture) publish() {
+	f.events.Publish(context.Background(), &entity.MeetingCreatedEvent{
+		EventMeta: entity.EventMeta{MeetingID: "meeting-1", At: f.clock.Now()},
+		Meeting:   &entity.Meeting{ID: "meeting-1", Code: "abc-defg-hij", HostID: "host"},
+	})
+}
+
+// waitDelivery - ждет, пока единственная доставка в журнале не сделает attempts попыток
+func (f *webhookFixture) waitDelivery(t *testing.T, attempts int) entity.WebhookDelivery {
+	t.Helper()
+
+	var delivery entity.WebhookDelivery
+	waitFor(t, func() bool {
+		deliveries, err := f.service.ListDeliveries(context.Background(), "", 0)
+		if err != nil || len(deliveries) != 1 {
+			return false
+		}
+		delivery = deliveries[0]
+		return delivery.Attempts == attempts
+	})
+	return delivery
+}
+
+// advanceToRetry - сдвигает время до следующей попытки, когда доставка ее запланировала
+func (f *webhookFixture) advanceToRetry(t *testing.T, delivery entity.WebhookDelivery, wantBackoff time.Duration) {
+	t.Helper()
+
+	if delivery.NextAttemptAt == nil {
+		t.Fatalf("delivery %+v has no next attempt", delivery)
+	}
+	if backoff := delivery.NextAttemptAt.Sub(f.clock.Now()); backoff != wantBackoff {
+		t.Fatalf("got backoff %s, want %s", backoff, wantBackoff)
+	}
+
+	f.clock.BlockUntil(1)
+	f.clock.Advance(wantBackoff - time.Millisecond)
+	if attempts := f.receiver.attempts.Load(); attempts != int32(delivery.Attempts) {
+		t.Fatalf("retried before the backoff passed: %d attempts", attempts)
+	}
+	f.clock.Advance(time.Millisecond)
+}
+
+func TestWebhookRetriesUntilDelivered(t *testing.T) {
+	f := newWebhookFixture(t, 3, http.StatusInternalServerError, http.StatusOK)
+
+	f.publish()
+
+	delivery := f.waitDelivery(t, 1)
+	if delivery.Status != entity.WebhookDeliveryPending || delivery.LastStatusCode != http.StatusInternalServerError {
+		t.Fatalf("got delivery %+v after a failed attempt, want pending with status 500", delivery)
+	}
+	f.advanceToRetry(t, delivery, _testInitialBackoff)
+
+	delivery = f.waitDelivery(t, 2)
+	if delivery.Status != entity.WebhookDeliveryDelivered || delivery.LastStatusCode != http.StatusOK {
+		t.Fatalf("got delivery %+v, want delivered with status 200", delivery)
+	}
+	if delivery.LastError != "" || delivery.NextAttemptAt != nil {
+		t.Fatalf("delivered delivery keeps retry state: %+v", delivery)
+	}
+	if attempts := f.receiver.attempts.Load(); attempts != 2 {
+		t.Fatalf("receiver got %d requests, want 2", attempts)
+	}
+
+	letters, err := f.service.ListDeadLetters(context.Background())
+	if err != nil || len(letters) != 0 {
+		t.Fatalf("got dead letters %+v, %v, want none", letters, err)
+	}
+}
+
+func TestWebhookDeadLetterAfterMaxAttempts(t *testing.T) {
+	f := newWebhookFixture(t, 4, http.StatusInternalServerError)
+
+	f.publish()
+
+	// Пауза удваивается и упирается в MaxBackoff
+	for attempt, backoff := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		f.advanceToRetry(t, f.waitDelivery(t, attempt+1), backoff)
+	}
+
+	delivery := f.waitDelivery(t, 4)
+	if delivery.Status != entity.WebhookDeliveryDeadLetter || delivery.NextAttemptAt != nil {
+		t.Fatalf("got delivery %+v, want dead without next attempt", delivery)
+	}
+
+	letters, err := f.service.ListDeadLetters(context.Background())
+	if err != nil {
+		t.Fatalf("list dead letters: %v", err)
+	}
+	if len(letters) != 1 || letters[0].Delivery.ID != delivery.ID || letters[0].Event.Type != entity.WebhookMeetingCreated {
+		t.Fatalf("got dead letters %+v, want the exhausted delivery", letters)
+	}
+
+	// Больше попыток не будет
+	f.clock.Advance(time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if attempts := f.receiver.attempts.Load(); attempts != 4 {
+		t.Fatalf("receiver got %d requests, want 4", attempts)
+	}
+
+	dead, err := f.service.ListDeliveries(context.Background(), entity.WebhookDeliveryDeadLetter, 0)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("got dead deliveries %+v, %v, want one", dead, err)
+	}
+}
+
+func TestWebhookRetryDeadLetter(t *testing.T) {
+	f := newWebhookFixture(t, 1, http.StatusInternalServerError, http.StatusOK)
+
+	f.publish()
+	dead := f.waitDelivery(t, 1)
+	waitFor(t, func() bool {
+		letters, _ := f.service.ListDeadLetters(context.Background())
+		return len(letters) == 1
+	})
+
+	retry, err := f.service.RetryDeadLetter(context.Background(), dead.ID)
+	if err != nil {
+		t.Fatalf("retry dead letter: %v", err)
+	}
+	if retry.ID == dead.ID || retry.EventID != dead.EventID {
+		t.Fatalf("got retry %+v, want a new delivery of event %s", retry, dead.EventID)
+	}
+
+	waitFor(t, func() bool {
+		delivered, _ := f.service.ListDeliveries(context.Background(), entity.WebhookDeliveryDelivered, 0)
+		return len(delivered) == 1 && delivered[0].ID == retry.ID
+	})
+
+	// Прежняя доставка остается в журнале, очередь недоставленных пуста
+	old, err := f.service.ListDeliveries(context.Background(), entity.WebhookDeliveryDeadLetter, 0)
+	if err != nil || len(old) != 1 || old[0].ID != dead.ID {
+		t.Fatalf("got dead deliveries %+v, %v, want the original one", old, err)
+	}
+	if letters, _ := f.service.ListDeadLetters(context.Background()); len(letters) != 0 {
+		t.Fatalf("got dead letters %+v after retry, want none", letters)
+	}
+}
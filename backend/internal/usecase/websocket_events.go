@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+)
+
+// onEvent - переводит доменные события в сообщения участникам. Сообщения идут через
+// брокер, поэтому доходят до соединений на всех репликах, а не только на этой
+func (uc *websocketService) onEvent(ctx context.Context, event entity.DomainEvent) {
+	meetingID := event.Meta().MeetingID
+
+	switch e := event.(type) {
+	case *entity.MeetingUpdatedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewMeetingUpdated(e.Meeting, e.By))
+	case *entity.MeetingLockedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewMeetingLocked(e.Locked, e.By))
+	case *entity.MeetingEndedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewMeetingEnded(e.Reason, e.By))
+		_ = publishClose(ctx, uc.broker, meetingID, "", entity.CloseMeetingEnded, "meeting ended")
+	case *entity.HostChangedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewHostChanged(e.HostID, e.PreviousHostID))
+		// Новый ведущий узнает обо всех, кто уже ждет в лобби
+		uc.notifyHostLobby(ctx, meetingID, e.HostID)
+
+	case *entity.ParticipantConnectedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewUserJoined(e.UserID, e.UserName))
+	case *entity.ParticipantDisconnectedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewUserLeft(e.UserID))
+	case *entity.ParticipantRemovedEvent:
+		uc.participantRemoved(ctx, e)
+
+	case *entity.LobbyJoinedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, e.HostID, protocol.NewLobbyRequest(e.User.ID, e.User.Name))
+	case *entity.LobbyLeftEvent:
+		uc.lobbyLeft(ctx, e)
+
+	case *entity.RecordingStartedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewRecordingStarted(e.Recording))
+	case *entity.RecordingStoppedEvent:
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewRecordingStopped(e.Recording, e.By))
+
+	case *entity.ChatMessageSentEvent:
+		uc.chatMessageSent(ctx, e.Message)
+	}
+}
+
+// participantRemoved - соединение того, кто больше не участник, закрывается, а
+// user_left остальные получают, когда оно закроется. Кого удалил janitor, тот уже
+// давно отключен
+func (uc *websocketService) participantRemoved(ctx context.Context, e *entity.ParticipantRemovedEvent) {
+	meetingID := e.MeetingID
+
+	switch e.Reason {
+	case entity.RemoveReasonKicked:
+		_ = publishMessage(ctx, uc.broker, meetingID, e.UserID, protocol.NewKicked(e.By))
+		_ = publishClose(ctx, uc.broker, meetingID, e.UserID, entity.CloseKicked, "kicked by host")
+		_ = publishMessage(ctx, uc.broker, meetingID, "", protocol.NewUserKicked(e.UserID, e.By))
+	case entity.RemoveReasonLeft:
+		// Пользователь вышел через REST, например из другой вкладки
+		_ = publishClose(ctx, uc.broker, meetingID, e.UserID, entity.CloseLeft, "left the meeting")
+	}
+}
+
+// lobbyLeft - ведущий узнает, что пользователь больше не ждет, а пользователь -
+// решение ведущего, после чего его соединение лобби закрывается
+func (uc *websocketService) lobbyLeft(ctx context.Context, e *entity.LobbyLeftEvent) {
+	meetingID := e.MeetingID
+
+	switch e.Outcome {
+	case entity.LobbyOutcomeAdmitted:
+		_ = publishMessage(ctx, uc.broker, meetingID, e.UserID, protocol.NewLobbyAdmitted(e.By))
+		_ = publishClose(ctx, uc.broker, meetingID, e.UserID, entity.CloseLobbyAdmitted, "admitted to the meeting")
+	case entity.LobbyOutcomeDenied:
+		_ = publishMessage(ctx, uc.broker, meetingID, e.UserID, protocol.NewLobbyDenied(e.By))
+		_ = publishClose(ctx, uc.broker, meetingID, e.UserID, entity.CloseLobbyDenied, e.Reason)
+	}
+
+	_ = publishMessage(ctx, uc.broker, meetingID, e.HostID, protocol.NewLobbyLeft(e.UserID, e.By))
+}
+
+// chatMessageSent - личное сообщение получают адресат и отправитель
+func (uc *websocketService) chatMessageSent(ctx context.Context, message *entity.ChatMessage) {
+	wsMessage := protocol.NewChatMessage(message)
+
+	if message.To == "" {
+		_ = publishMessage(ctx, uc.broker, message.MeetingID, "", wsMessage)
+		return
+	}
+
+	_ = publishMessage(ctx, uc.broker, message.MeetingID, message.To, wsMessage)
+	if message.To != message.From {
+		_ = publishMessage(ctx, uc.broker, message.MeetingID, message.From, wsMessage)
+	}
+}
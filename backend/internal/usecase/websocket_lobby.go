@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+)
+
+// HandleLobbyConnection - пользователь ждет в лобби. Сессию в лобби нельзя
+// возобновить, поэтому hello приходит без resume токена
+func (uc *websocketService) HandleLobbyConnection(ctx context.Context, conn WSConnection, session *entity.WSSession) {
+	meetingID, userID := session.MeetingID, session.UserID
+
+	defer func() {
+		conn.Close()
+	}()
+
+	uc.metrics.ConnectionOpened()
+	defer uc.metrics.ConnectionClosed()
+
+	out := uc.attachLobby(conn, session)
+	defer out.stop()
+	defer uc.detachLobby(meetingID, userID, out)
+
+	// Ведущий мог принять решение между проверкой в обработчике и подключением
+	switch err := uc.meetingUC.CheckMembership(ctx, meetingID, userID); {
+	case err == nil:
+		conn.CloseWithReason(entity.CloseLobbyAdmitted, "admitted to the meeting")
+		return
+	case errors.Is(err, entity.ErrNotMember):
+		conn.CloseWithReason(entity.CloseLobbyDenied, "denied by host")
+		return
+	case errors.Is(err, entity.ErrMeetingNotFound):
+		conn.CloseWithReason(entity.CloseMeetingNotFound, "meeting not found")
+		return
+	}
+
+	for {
+		select {
+		case <-uc.shutdown:
+			conn.CloseWithReason(entity.CloseGoingAway, "server is shutting down")
+			return
+		case <-ctx.Done():
+			conn.CloseWithReason(entity.CloseGoingAway, "session cancelled")
+			return
+		default:
+			if _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// attachLobby - повторное подключение из лобби вытесняет старое соединение
+func (uc *websocketService) attachLobby(conn WSConnection, ws *entity.WSSession) *outboundQueue {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	meetingID, userID := ws.MeetingID, ws.UserID
+	out := newOutboundQueue(conn, uc.cfg.SendQueueSize, uc.cfg.OverflowPolicy, uc.metrics)
+
+	if _, exists := uc.lobby[meetingID]; !exists {
+		uc.lobby[meetingID] = make(map[string]*outboundQueue)
+	}
+
+	if previous := uc.lobby[meetingID][userID]; previous != nil {
+		previous.close(entity.CloseSessionReplaced, "session replaced by a new connection")
+	}
+	uc.lobby[meetingID][userID] = out
+
+	hello, err := json.Marshal(protocol.NewHello(ws.ProtocolVersion, meetingID, userID, "", false))
+	if err == nil {
+		out.push(hello)
+	}
+
+	return out
+}
+
+func (uc *websocketService) detachLobby(meetingID, userID string, out *outboundQueue) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	meetingLobby := uc.lobby[meetingID]
+	if meetingLobby[userID] != out {
+		return
+	}
+
+	delete(meetingLobby, userID)
+	if len(meetingLobby) == 0 {
+		delete(uc.lobby, meetingID)
+	}
+}
+
+// deliverLobby - вызывается под uc.mu. Пользователь в лобби получает только решение
+// ведущего: участники не должны слать ему сигнальные сообщения в обход ведущего
+func (uc *websocketService) deliverLobby(envelope *entity.SignalEnvelope) {
+	if envelope.UserID == "" {
+		if envelope.CloseCode == 0 {
+			return
+		}
+
+		for _, out := range uc.lobby[envelope.MeetingID] {
+			out.close(envelope.CloseCode, envelope.CloseReason)
+		}
+		return
+	}
+
+	out := uc.lobby[envelope.MeetingID][envelope.UserID]
+	if out == nil {
+		return
+	}
+
+	if envelope.CloseCode != 0 {
+		out.close(envelope.CloseCode, envelope.CloseReason)
+		return
+	}
+
+	if isLobbyDecision(envelope.Message) {
+		out.push(envelope.Message)
+	}
+}
+
+func isLobbyDecision(message []byte) bool {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(message, &header); err != nil {
+		return false
+	}
+
+	return header.Type == protocol.TypeLobbyAdmitted || header.Type == protocol.TypeLobbyDenied
+}
+
+// notifyHostLobby - ведущий после подключения получает lobby_request по всем, кто ждет
+func (uc *websocketService) notifyHostLobby(ctx context.Context, meetingID, userID string) {
+	lobby, err := uc.meetingUC.GetLobby(ctx, meetingID, userID)
+	if err != nil {
+		return
+	}
+
+	for _, user := range lobby {
+		uc.SendToUser(meetingID, userID, protocol.NewLobbyRequest(user.ID, user.Name))
+	}
+}
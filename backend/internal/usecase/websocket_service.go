@@ -3,52 +3,106 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
 )
 
+var errUnexpectedRecorderAnswer = errors.New("recorder does not send offers")
+
 type websocketService struct {
-	meetingRepo   MeetingRepo
-	connections   map[string]map[string]WSConnection
-	mu            sync.RWMutex
-	shutdown      chan struct{}
-	userJoinDelay time.Duration
+	meetingRepo MeetingRepo
+	meetingUC   MeetingUseCase
+	chatUC      ChatUseCase
+	broker      SignalingBroker
+	sfu         SFU
+	recordingUC RecordingUseCase
+	events      EventBus
+	clock       Clock
+	metrics     Metrics
+	sessions    map[string]map[string]*userSession
+	mu          sync.Mutex
+	shutdown    chan struct{}
+	cfg         SessionConfig
+
+	// replicaID - отличает объявления о сессиях этой реплики от чужих
+	replicaID string
+
+	// lobby - соединения пользователей, которые ждут в лобби
+	lobby map[string]map[string]*outboundQueue
 }
 
-func NewWebSocketService(meetingRepo MeetingRepo, userJoinDelay time.Duration) *websocketService {
+// NewWebSocketService - sfu может быть nil, если медиасервер выключен
+func NewWebSocketService(meetingRepo MeetingRepo, meetingUC MeetingUseCase, chatUC ChatUseCase, recordingUC RecordingUseCase, broker SignalingBroker, sfu SFU, events EventBus, clock Clock, metrics Metrics, cfg SessionConfig) *websocketService {
 	return &websocketService{
-		meetingRepo:   meetingRepo,
-		connections:   make(map[string]map[string]WSConnection),
-		shutdown:      make(chan struct{}),
-		userJoinDelay: userJoinDelay,
+		meetingRepo: meetingRepo,
+		meetingUC:   meetingUC,
+		chatUC:      chatUC,
+		broker:      broker,
+		sfu:         sfu,
+		recordingUC: recordingUC,
+		events:      events,
+		clock:       clock,
+		metrics:     metrics,
+		replicaID:   entity.GenerateReplicaID(),
+		sessions:    make(map[string]map[string]*userSession),
+		lobby:       make(map[string]map[string]*outboundQueue),
+		shutdown:    make(chan struct{}),
+		cfg:         cfg,
 	}
 }
 
 var _ WebSocketUseCase = (*websocketService)(nil)
 
-func (uc *websocketService) HandleConnection(ctx context.Context, conn WSConnection, meetingID, userID string) {
+func (uc *websocketService) HandleConnection(ctx context.Context, conn WSConnection, session *entity.WSSession) {
+	meetingID, userID := session.MeetingID, session.UserID
+
 	defer func() {
 		conn.Close()
 	}()
 
-	uc.registerConnection(meetingID, userID, conn)
-	defer uc.unregisterConnection(meetingID, userID)
-
-	if err := uc.meetingRepo.SetUserOnlineStatus(ctx, meetingID, userID, true); err != nil {
+	// Возобновленная сессия продолжает открытую, новая в истории не появляется
+	if err := uc.meetingRepo.StartUserSession(ctx, meetingID, userID, uc.clock.Now()); err != nil {
+		conn.CloseWithReason(entity.CloseSessionRejected, "user is not a member of the meeting")
 		return
 	}
-	defer uc.meetingRepo.SetUserOnlineStatus(ctx, meetingID, userID, false)
 
-	uc.broadcastUserJoined(meetingID, userID)
+	uc.metrics.ConnectionOpened()
+	defer uc.metrics.ConnectionClosed()
+
+	out, resumed := uc.attach(conn, session)
+	defer out.stop()
+	defer uc.detach(meetingID, userID, conn)
+
+	uc.announceSession(meetingID, userID)
+
+	if !resumed {
+		// Медиасоединение прошлой сессии принадлежало другому клиенту
+		uc.removeMediaPeers(meetingID, userID)
+
+		if history, err := uc.chatUC.GetHistory(ctx, meetingID, userID, "", 0); err == nil && len(history.Messages) > 0 {
+			uc.SendToUser(meetingID, userID, protocol.NewChatHistory(history))
+		}
+
+		if recording := uc.recordingUC.ActiveRecording(meetingID); recording != nil {
+			uc.SendToUser(meetingID, userID, protocol.NewRecordingStarted(recording))
+		}
+
+		uc.notifyHostLobby(ctx, meetingID, userID)
+		uc.userJoined(meetingID, userID)
+	}
 
 	for {
 		select {
 		case <-uc.shutdown:
+			conn.CloseWithReason(entity.CloseGoingAway, "server is shutting down")
 			return
 		case <-ctx.Done():
+			conn.CloseWithReason(entity.CloseGoingAway, "session cancelled")
 			return
 		default:
 			message, err := conn.ReadMessage()
@@ -56,111 +110,105 @@ func (uc *websocketService) HandleConnection(ctx context.Context, conn WSConnect
 				return
 			}
 
-			var wsMsg entity.WSMessage
-			if err := json.Unmarshal(message, &wsMsg); err != nil {
+			inbound, protoErr := protocol.Decode(message)
+			if protoErr != nil {
+				uc.metrics.MessageDropped(droppedType(protoErr), dropReasonInvalid)
+				uc.SendToUser(meetingID, userID, protocol.NewError(protoErr))
 				continue
 			}
 
-			uc.handleMessage(ctx, meetingID, userID, &wsMsg)
+			uc.handleMessage(ctx, meetingID, userID, inbound)
 		}
 	}
 }
 
 func (uc *websocketService) BroadcastToMeeting(meetingID string, message *entity.WSMessage) error {
-	uc.mu.RLock()
-	defer uc.mu.RUnlock()
-
-	meetingConnections, exists := uc.connections[meetingID]
-	if !exists {
-		return fmt.Errorf("meeting not found: %s", meetingID)
-	}
-
-	data, err := json.Marshal(message)
-	if err != nil {
-		return err
-	}
-
-	var wg sync.WaitGroup
-	for targetUserID, conn := range meetingConnections {
-		wg.Add(1)
-		go func(targetUserID string, conn WSConnection) {
-			defer wg.Done()
-			_ = conn.WriteMessage(1, data)
-		}(targetUserID, conn)
-	}
-	wg.Wait()
-
-	return nil
+	return uc.publish(meetingID, "", message)
 }
 
 func (uc *websocketService) SendToUser(meetingID, targetUserID string, message *entity.WSMessage) error {
-	uc.mu.RLock()
-	defer uc.mu.RUnlock()
+	return uc.publish(meetingID, targetUserID, message)
+}
 
-	meetingConnections, exists := uc.connections[meetingID]
-	if !exists {
-		return fmt.Errorf("meeting not found: %s", meetingID)
-	}
+// Start - подписывает сервис на брокер, чтобы получать сообщения со всех реплик,
+// и на доменные события, чтобы рассылать их участникам через брокер
+func (uc *websocketService) Start(ctx context.Context) error {
+	uc.events.Subscribe(ctx, uc.onEvent)
+	return uc.broker.Subscribe(ctx, uc.deliver)
+}
 
-	conn, exists := meetingConnections[targetUserID]
-	if !exists {
-		return fmt.Errorf("user not found in meeting: %s", targetUserID)
-	}
+func (uc *websocketService) publish(meetingID, targetUserID string, message *entity.WSMessage) error {
+	return publishMessage(context.Background(), uc.broker, meetingID, targetUserID, message)
+}
 
+// replyLocal - отправляет сообщение пользователю, подключенному к этой реплике, минуя брокер
+func (uc *websocketService) replyLocal(meetingID, userID string, message *entity.WSMessage) {
 	data, err := json.Marshal(message)
 	if err != nil {
-		return err
-	}
-
-	if err := conn.WriteMessage(1, data); err != nil {
-		return err
+		return
 	}
 
-	return nil
+	uc.deliver(&entity.SignalEnvelope{MeetingID: meetingID, UserID: userID, Message: data})
 }
 
-func (uc *websocketService) registerConnection(meetingID, userID string, conn WSConnection) {
+// deliver - отправляет сообщение из брокера локальным соединениям этой реплики.
+// Отключившимся пользователям, чье место еще держится, сообщение ставится в очередь
+func (uc *websocketService) deliver(envelope *entity.SignalEnvelope) {
 	uc.mu.Lock()
 	defer uc.mu.Unlock()
 
-	if _, exists := uc.connections[meetingID]; !exists {
-		uc.connections[meetingID] = make(map[string]WSConnection)
-	}
+	meetingSessions := uc.sessions[envelope.MeetingID]
 
-	uc.connections[meetingID][userID] = conn
-}
+	if envelope.UserID != "" {
+		session, exists := meetingSessions[envelope.UserID]
 
-func (uc *websocketService) unregisterConnection(meetingID, userID string) {
-	uc.mu.Lock()
-	defer uc.mu.Unlock()
+		if envelope.CloseCode == entity.CloseSessionReplaced && envelope.Origin != "" {
+			if exists && envelope.Origin != uc.replicaID {
+				uc.replaceSession(envelope.MeetingID, envelope.UserID, session)
+			}
+			return
+		}
+
+		if !exists {
+			uc.deliverLobby(envelope)
+			return
+		}
 
-	message := &entity.WSMessage{
-		Type: "user_left",
-		Data: map[string]string{"user_id": userID},
-		From: userID,
+		if envelope.CloseCode != 0 {
+			uc.closeSession(envelope.MeetingID, envelope.UserID, session, envelope.CloseCode, envelope.CloseReason)
+			return
+		}
+
+		if session.conn == nil {
+			uc.enqueue(session, envelope.Message)
+			return
+		}
+
+		session.out.push(envelope.Message)
+		return
 	}
 
-	if meetingConnections, exists := uc.connections[meetingID]; exists {
-		data, err := json.Marshal(message)
-		if err == nil {
-			for targetUserID, conn := range meetingConnections {
-				if targetUserID != userID {
-					go func(targetUserID string, conn WSConnection) {
-						_ = conn.WriteMessage(1, data)
-					}(targetUserID, conn)
-				}
-			}
+	if envelope.CloseCode != 0 {
+		for userID, session := range meetingSessions {
+			uc.closeSession(envelope.MeetingID, userID, session, envelope.CloseCode, envelope.CloseReason)
 		}
+		uc.deliverLobby(envelope)
+		return
+	}
 
-		delete(meetingConnections, userID)
-		if len(meetingConnections) == 0 {
-			delete(uc.connections, meetingID)
+	for _, session := range meetingSessions {
+		if session.conn == nil {
+			uc.enqueue(session, envelope.Message)
+			continue
 		}
+
+		session.out.push(envelope.Message)
 	}
 }
 
-func (uc *websocketService) broadcastUserJoined(meetingID, userID string) {
-	time.Sleep(uc.userJoinDelay)
+// userJoined - остальные участники получат user_joined из ParticipantConnectedEvent
+func (uc *websocketService) userJoined(meetingID, userID string) {
+	time.Sleep(uc.cfg.UserJoinDelay)
 
 	users, err := uc.meetingRepo.GetMeetingUsers(context.Background(), meetingID)
 	if err != nil {
@@ -175,54 +223,225 @@ func (uc *websocketService) broadcastUserJoined(meetingID, userID string) {
 		}
 	}
 
-	message := &entity.WSMessage{
-		Type: "user_joined",
-		Data: map[string]string{
-			"user_id":   userID,
-			"user_name": userName,
-		},
-		From: userID,
-	}
-
-	uc.BroadcastToMeeting(meetingID, message)
+	uc.events.Publish(context.Background(), &entity.ParticipantConnectedEvent{
+		EventMeta: eventMeta(uc.clock, meetingID),
+		UserID:    userID,
+		UserName:  userName,
+	})
 }
 
-func (uc *websocketService) handleMessage(ctx context.Context, meetingID, userID string, message *entity.WSMessage) {
+func (uc *websocketService) handleMessage(ctx context.Context, meetingID, userID string, message *protocol.Inbound) {
 	switch message.Type {
-	case "offer":
-		if message.To != "" {
-			uc.SendToUser(meetingID, message.To, &entity.WSMessage{
-				Type: "offer",
-				Data: message.Data,
-				From: userID,
-			})
-		}
-	case "answer":
-		if message.To != "" {
-			uc.SendToUser(meetingID, message.To, &entity.WSMessage{
-				Type: "answer",
-				Data: message.Data,
-				From: userID,
-			})
-		}
-	case "ice_candidate":
-		if message.To != "" {
-			uc.SendToUser(meetingID, message.To, &entity.WSMessage{
-				Type: "ice_candidate",
-				Data: message.Data,
-				From: userID,
-			})
-		}
-	case "user_left":
-		uc.BroadcastToMeeting(meetingID, &entity.WSMessage{
-			Type: "user_left",
-			Data: map[string]string{"user_id": userID},
+	case protocol.TypeOffer, protocol.TypeAnswer, protocol.TypeICECandidate:
+		if message.To == protocol.SFUPeerID || message.To == protocol.RecorderPeerID {
+			if err := uc.handleMediaServer(ctx, meetingID, userID, message); err != nil {
+				protoErr := &protocol.Error{
+					Code:    protocol.ErrCodeCommandFailed,
+					Message: err.Error(),
+					RefType: message.Type,
+				}
+				if errors.Is(err, entity.ErrSFUUnavailable) {
+					protoErr.Code = protocol.ErrCodeForbidden
+				}
+
+				uc.metrics.MessageDropped(message.Type, dropReasonCommandFailed)
+				uc.SendToUser(meetingID, userID, protocol.NewError(protoErr))
+				return
+			}
+			uc.metrics.MessageRelayed(message.Type)
+			return
+		}
+
+		err := uc.SendToUser(meetingID, message.To, &entity.WSMessage{
+			Type: message.Type,
+			Data: message.Data,
 			From: userID,
 		})
-	default:
+		if err != nil {
+			// Брокер не доставил сообщение, поэтому ошибка уходит отправителю напрямую
+			uc.metrics.MessageDropped(message.Type, dropReasonDeliveryFailed)
+			uc.replyLocal(meetingID, userID, protocol.NewError(&protocol.Error{
+				Code:    protocol.ErrCodeDeliveryFailed,
+				Message: "failed to deliver message",
+				RefType: message.Type,
+			}))
+			return
+		}
+		uc.metrics.MessageRelayed(message.Type)
+	case protocol.TypeUserLeft:
+		uc.forbidResume(meetingID, userID)
+		uc.BroadcastToMeeting(meetingID, protocol.NewUserLeft(userID))
+		uc.metrics.MessageRelayed(message.Type)
+	case protocol.TypeChatMessage:
+		payload := message.Payload.(*protocol.ChatPayload)
+		if _, err := uc.chatUC.SendMessage(ctx, meetingID, userID, message.To, payload.Text); err != nil {
+			protoErr := &protocol.Error{
+				Code:    protocol.ErrCodeCommandFailed,
+				Message: "failed to send chat message",
+				RefType: message.Type,
+			}
+
+			reason := dropReasonCommandFailed
+
+			var validationErr *entity.ValidationError
+			switch {
+			case errors.As(err, &validationErr):
+				protoErr.Code, protoErr.Message = protocol.ErrCodeInvalidPayload, err.Error()
+				reason = dropReasonInvalid
+			case errors.Is(err, entity.ErrUserNotFound):
+				protoErr.Code, protoErr.Message = protocol.ErrCodeDeliveryFailed, "recipient is not in the meeting"
+				reason = dropReasonDeliveryFailed
+			}
+
+			uc.metrics.MessageDropped(message.Type, reason)
+			uc.SendToUser(meetingID, userID, protocol.NewError(protoErr))
+			return
+		}
+		uc.metrics.MessageRelayed(message.Type)
+	case protocol.TypeKick, protocol.TypeMuteRequest, protocol.TypeLockMeeting, protocol.TypeTransferHost,
+		protocol.TypeLobbyAdmit, protocol.TypeLobbyDeny:
+		if err := uc.handleModeration(ctx, meetingID, userID, message); err != nil {
+			protoErr := &protocol.Error{
+				Code:    protocol.ErrCodeCommandFailed,
+				Message: "failed to execute command",
+				RefType: message.Type,
+			}
+
+			var validationErr *entity.ValidationError
+			switch {
+			case errors.Is(err, entity.ErrNotHost):
+				protoErr.Code, protoErr.Message = protocol.ErrCodeForbidden, err.Error()
+			case errors.Is(err, entity.ErrUserNotFound), errors.As(err, &validationErr):
+				protoErr.Message = err.Error()
+			}
+
+			uc.metrics.MessageDropped(message.Type, dropReasonCommandFailed)
+			uc.SendToUser(meetingID, userID, protocol.NewError(protoErr))
+			return
+		}
+		uc.metrics.MessageRelayed(message.Type)
+	}
+}
+
+// droppedType - тип отброшенного сообщения для метрик. Неизвестные типы
+// схлопываются в один, чтобы клиент не мог раздуть число серий
+func droppedType(err *protocol.Error) string {
+	if err.RefType == "" || err.Code == protocol.ErrCodeUnknownType {
+		return "unknown"
 	}
+	return err.RefType
 }
 
+// handleModeration - выполняет команду ведущего, права проверяет MeetingUseCase
+func (uc *websocketService) handleModeration(ctx context.Context, meetingID, userID string, message *protocol.Inbound) error {
+	switch payload := message.Payload.(type) {
+	case *protocol.TargetPayload:
+		switch message.Type {
+		case protocol.TypeKick:
+			return uc.meetingUC.KickUser(ctx, meetingID, userID, payload.UserID)
+		case protocol.TypeMuteRequest:
+			return uc.meetingUC.RequestMute(ctx, meetingID, userID, payload.UserID)
+		case protocol.TypeTransferHost:
+			return uc.meetingUC.TransferHost(ctx, meetingID, userID, payload.UserID)
+		case protocol.TypeLobbyAdmit:
+			return uc.meetingUC.AdmitUser(ctx, meetingID, userID, payload.UserID)
+		case protocol.TypeLobbyDeny:
+			return uc.meetingUC.DenyUser(ctx, meetingID, userID, payload.UserID)
+		}
+	case *protocol.LockPayload:
+		return uc.meetingUC.SetMeetingLocked(ctx, meetingID, userID, payload.Locked)
+	}
+
+	return nil
+}
+
+// handleMediaServer - сигнальное сообщение медиасерверу или рекордеру
+func (uc *websocketService) handleMediaServer(ctx context.Context, meetingID, userID string, message *protocol.Inbound) error {
+	if message.To == protocol.RecorderPeerID {
+		return uc.handleRecorder(ctx, meetingID, userID, message)
+	}
+	return uc.handleSFU(ctx, meetingID, userID, message)
+}
+
+// handleSFU - соединение с медиасервером создает только offer, поэтому режим встречи проверяется на нем
+func (uc *websocketService) handleSFU(ctx context.Context, meetingID, userID string, message *protocol.Inbound) error {
+	if uc.sfu == nil {
+		return entity.ErrSFUUnavailable
+	}
+
+	switch payload := message.Payload.(type) {
+	case *entity.WebRTCOffer:
+		meeting, err := uc.meetingRepo.GetMeeting(ctx, meetingID)
+		if err != nil {
+			return fmt.Errorf("failed to get meeting: %w", err)
+		}
+		if meeting == nil || meeting.Mode != entity.MeetingModeSFU {
+			return entity.ErrSFUUnavailable
+		}
+		return uc.sfu.HandleOffer(ctx, meetingID, userID, payload.SDP)
+	case *entity.WebRTCAnswer:
+		return uc.sfu.HandleAnswer(ctx, meetingID, userID, payload.SDP)
+	case *entity.ICECandidate:
+		return uc.sfu.AddICECandidate(ctx, meetingID, userID, payload)
+	}
+
+	return nil
+}
+
+// handleRecorder - рекордер только принимает потоки и сам offer не присылает
+func (uc *websocketService) handleRecorder(ctx context.Context, meetingID, userID string, message *protocol.Inbound) error {
+	switch payload := message.Payload.(type) {
+	case *entity.WebRTCOffer:
+		return uc.recordingUC.HandleOffer(ctx, meetingID, userID, payload.SDP)
+	case *entity.WebRTCAnswer:
+		return errUnexpectedRecorderAnswer
+	case *entity.ICECandidate:
+		return uc.recordingUC.AddICECandidate(ctx, meetingID, userID, payload)
+	}
+
+	return nil
+}
+
+// removeMediaPeers - закрывает соединения участника с медиасервером и рекордером
+func (uc *websocketService) removeMediaPeers(meetingID, userID string) {
+	if uc.sfu != nil {
+		uc.sfu.RemovePeer(meetingID, userID)
+	}
+	uc.recordingUC.ParticipantLeft(meetingID, userID)
+}
+
+// Shutdown - закрывает все соединения с кодом going away
 func (uc *websocketService) Shutdown() {
 	close(uc.shutdown)
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, meetingSessions := range uc.sessions {
+		for _, session := range meetingSessions {
+			if session.graceTimer != nil {
+				session.graceTimer.Stop()
+			}
+			if session.conn == nil {
+				continue
+			}
+
+			wg.Add(1)
+			go func(conn WSConnection) {
+				defer wg.Done()
+				_ = conn.CloseWithReason(entity.CloseGoingAway, "server is shutting down")
+			}(session.conn)
+		}
+	}
+	for _, meetingLobby := range uc.lobby {
+		for _, out := range meetingLobby {
+			wg.Add(1)
+			go func(conn WSConnection) {
+				defer wg.Done()
+				_ = conn.CloseWithReason(entity.CloseGoingAway, "server is shutting down")
+			}(out.conn)
+		}
+	}
+	wg.Wait()
 }
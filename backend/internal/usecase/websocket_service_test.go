@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/broker"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/metrics"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+// unreachableBroker - брокер, сообщения которого никто не получает, как Redis,
+// у канала которого не осталось подписчиков
+type unreachableBroker struct {
+	*broker.MemoryBroker
+}
+
+func (b *unreachableBroker) Publish(ctx context.Context, envelope *entity.SignalEnvelope) error {
+	return broker.ErrNoSubscribers
+}
+
+func TestRelayReportsDeliveryFailure(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC))
+	meetingRepo := repo.NewMemoryMeetingRepository()
+	meeting := &entity.Meeting{}
+	createTestMeeting(t, meetingRepo, meeting, fakeClock.Now(), nil, "alice", "bob")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	uc := NewWebSocketService(meetingRepo, stubMeetingUC{}, stubChatUC{}, stubRecordingUC{},
+		&unreachableBroker{MemoryBroker: broker.NewMemoryBroker()}, nil, NewEventBus(), fakeClock, metrics.Nop{},
+		SessionConfig{SendQueueSize: 16, OverflowPolicy: OverflowDropOldest})
+	if err := uc.Start(ctx); err != nil {
+		t.Fatalf("start websocket service: %v", err)
+	}
+
+	alice := newFakeConn()
+	go uc.HandleConnection(ctx, alice, &entity.WSSession{MeetingID: meeting.ID, UserID: "alice", ProtocolVersion: protocol.Version})
+	t.Cleanup(func() { _ = alice.Close() })
+	alice.waitMessage(t, protocol.TypeHello, "")
+
+	alice.incoming <- []byte(`{"type":"offer","to":"bob","data":{"sdp":"v=0"}}`)
+
+	// Ответ об ошибке приходит мимо брокера, который не доставил offer
+	message := alice.waitMessage(t, protocol.TypeError, "")
+	var payload protocol.ErrorPayload
+	data, _ := json.Marshal(message.Data)
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if payload.Code != protocol.ErrCodeDeliveryFailed || payload.RefType != protocol.TypeOffer {
+		t.Fatalf("got error %+v, want delivery_failed for offer", payload)
+	}
+}
@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+// SessionConfig - параметры сигнальных сессий
+type SessionConfig struct {
+	// UserJoinDelay - пауза перед рассылкой user_joined, чтобы клиент успел подготовиться
+	UserJoinDelay time.Duration
+	// ResumeGrace - сколько держать место отключившегося пользователя. Ноль отключает возобновление
+	ResumeGrace time.Duration
+	// ResumeQueueSize - сколько сообщений копить для отключившегося пользователя
+	ResumeQueueSize int
+	// SendQueueSize и OverflowPolicy - очередь отправки каждого соединения
+	SendQueueSize  int
+	OverflowPolicy OverflowPolicy
+}
+
+// userSession - место пользователя во встрече на этой реплике.
+// Пока conn == nil, пользователь отключился и может возобновить сессию
+// по resumeToken, а адресованные ему сообщения копятся в pending
+type userSession struct {
+	conn        WSConnection
+	out         *outboundQueue
+	resumeToken string
+	pending     [][]byte
+	// noResume - сессию закрыл сервер (kick, конец встречи) или пользователь ушел сам
+	noResume bool
+	// replaced - пользователь подключился к другой реплике и остается во встрече
+	replaced   bool
+	graceTimer clock.Timer
+}
+
+// attach - привязывает соединение к месту пользователя. Сессия возобновляется,
+// если совпал resume токен, иначе создается новая и старое соединение вытесняется.
+// Первым в очередь отправки встает hello, за ним - сообщения, накопленные за время отключения
+func (uc *websocketService) attach(conn WSConnection, ws *entity.WSSession) (*outboundQueue, bool) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	meetingID, userID := ws.MeetingID, ws.UserID
+	out := newOutboundQueue(conn, uc.cfg.SendQueueSize, uc.cfg.OverflowPolicy, uc.metrics)
+
+	if _, exists := uc.sessions[meetingID]; !exists {
+		uc.sessions[meetingID] = make(map[string]*userSession)
+	}
+
+	session, exists := uc.sessions[meetingID][userID]
+	if exists {
+		if session.graceTimer != nil {
+			session.graceTimer.Stop()
+			session.graceTimer = nil
+		}
+
+		// Повторное подключение того же пользователя вытесняет старую сессию
+		if session.conn != nil {
+			session.out.close(entity.CloseSessionReplaced, "session replaced by a new connection")
+		}
+
+		if ws.ResumeToken != "" && ws.ResumeToken == session.resumeToken && !session.noResume {
+			session.conn, session.out = conn, out
+			uc.start(session, ws, true)
+			return out, true
+		}
+	}
+
+	session = &userSession{
+		conn:        conn,
+		out:         out,
+		resumeToken: entity.GenerateResumeToken(),
+	}
+	uc.sessions[meetingID][userID] = session
+	uc.metrics.SetActiveMeetings(len(uc.sessions))
+	uc.start(session, ws, false)
+
+	return out, false
+}
+
+// start - вызывается под uc.mu
+func (uc *websocketService) start(session *userSession, ws *entity.WSSession, resumed bool) {
+	hello, err := json.Marshal(protocol.NewHello(ws.ProtocolVersion, ws.MeetingID, ws.UserID, session.resumeToken, resumed))
+	if err == nil {
+		session.out.push(hello)
+	}
+
+	for _, message := range session.pending {
+		session.out.push(message)
+	}
+	session.pending = nil
+}
+
+// detach - отвязывает закрытое соединение. Место пользователя держится
+// ResumeGrace, и только после этого участники получают user_left
+func (uc *websocketService) detach(meetingID, userID string, conn WSConnection) {
+	uc.mu.Lock()
+	session := uc.sessions[meetingID][userID]
+	if session == nil || session.conn != conn {
+		// Сессию уже вытеснило новое подключение, пользователь остается во встрече
+		uc.mu.Unlock()
+		return
+	}
+
+	if session.replaced {
+		uc.removeSession(meetingID, userID)
+		uc.mu.Unlock()
+
+		uc.removeMediaPeers(meetingID, userID)
+		return
+	}
+
+	if uc.cfg.ResumeGrace > 0 && !session.noResume && !uc.isShuttingDown() {
+		session.conn, session.out = nil, nil
+		session.graceTimer = uc.clock.AfterFunc(uc.cfg.ResumeGrace, func() {
+			uc.expire(meetingID, userID, session)
+		})
+		uc.mu.Unlock()
+		return
+	}
+
+	uc.removeSession(meetingID, userID)
+	uc.mu.Unlock()
+
+	uc.userLeft(meetingID, userID)
+}
+
+// expire - пользователь не вернулся за ResumeGrace
+func (uc *websocketService) expire(meetingID, userID string, session *userSession) {
+	uc.mu.Lock()
+	if uc.sessions[meetingID][userID] != session || session.conn != nil {
+		uc.mu.Unlock()
+		return
+	}
+
+	uc.removeSession(meetingID, userID)
+	uc.mu.Unlock()
+
+	uc.userLeft(meetingID, userID)
+}
+
+func (uc *websocketService) userLeft(meetingID, userID string) {
+	now := uc.clock.Now()
+	_ = uc.meetingRepo.EndUserSession(context.Background(), meetingID, userID, now)
+	uc.removeMediaPeers(meetingID, userID)
+
+	uc.events.Publish(context.Background(), &entity.ParticipantDisconnectedEvent{
+		EventMeta: entity.EventMeta{MeetingID: meetingID, At: now},
+		UserID:    userID,
+		UserName:  attendeeName(context.Background(), uc.meetingRepo, meetingID, userID),
+	})
+}
+
+// removeSession - вызывается под uc.mu
+func (uc *websocketService) removeSession(meetingID, userID string) {
+	meetingSessions := uc.sessions[meetingID]
+
+	if session := meetingSessions[userID]; session != nil && session.graceTimer != nil {
+		session.graceTimer.Stop()
+	}
+
+	delete(meetingSessions, userID)
+	if len(meetingSessions) == 0 {
+		delete(uc.sessions, meetingID)
+	}
+	uc.metrics.SetActiveMeetings(len(uc.sessions))
+}
+
+// announceSession - сообщает другим репликам, что пользователь подключился к этой.
+// Место, которое для него держит другая реплика, иначе истекло бы и закрыло эту сессию
+func (uc *websocketService) announceSession(meetingID, userID string) {
+	_ = uc.broker.Publish(context.Background(), &entity.SignalEnvelope{
+		MeetingID:   meetingID,
+		UserID:      userID,
+		CloseCode:   entity.CloseSessionReplaced,
+		CloseReason: "session replaced by a new connection",
+		Origin:      uc.replicaID,
+	})
+}
+
+// replaceSession - вызывается под uc.mu. Пользователь подключился к другой реплике:
+// место здесь освобождается без user_left, ведь он остается во встрече
+func (uc *websocketService) replaceSession(meetingID, userID string, session *userSession) {
+	if session.conn == nil {
+		uc.removeSession(meetingID, userID)
+		go uc.removeMediaPeers(meetingID, userID)
+		return
+	}
+
+	session.replaced = true
+	session.out.close(entity.CloseSessionReplaced, "session replaced by a new connection")
+}
+
+// forbidResume - пользователь ушел сам, ждать его не нужно
+func (uc *websocketService) forbidResume(meetingID, userID string) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if session := uc.sessions[meetingID][userID]; session != nil {
+		session.noResume = true
+	}
+}
+
+// enqueue - вызывается под uc.mu. При переполнении теряются самые старые сообщения
+func (uc *websocketService) enqueue(session *userSession, message []byte) {
+	session.pending = append(session.pending, message)
+	if overflow := len(session.pending) - uc.cfg.ResumeQueueSize; overflow > 0 {
+		session.pending = append([][]byte(nil), session.pending[overflow:]...)
+	}
+}
+
+// closeSession - вызывается под uc.mu. Закрытую сервером сессию нельзя возобновить.
+// Место отключившегося пользователя освобождается сразу, не дожидаясь ResumeGrace
+func (uc *websocketService) closeSession(meetingID, userID string, session *userSession, code int, reason string) {
+	session.noResume = true
+
+	if session.conn == nil {
+		uc.removeSession(meetingID, userID)
+		go uc.userLeft(meetingID, userID)
+		return
+	}
+
+	session.out.close(code, reason)
+}
+
+func (uc *websocketService) isShuttingDown() bool {
+	select {
+	case <-uc.shutdown:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,203 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/AlexandrKudryavtsev/zvonim/internal/entity"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/protocol"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/broker"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/metrics"
+	"github.com/AlexandrKudryavtsev/zvonim/internal/usecase/repo"
+	"github.com/AlexandrKudryavtsev/zvonim/pkg/clock"
+)
+
+const _testResumeGrace = 30 * time.Second
+
+// sessionFixture - реплики сигнального сервиса с общими хранилищем, брокером и часами
+type sessionFixture struct {
+	clock    *clock.Fake
+	repo     *repo.MemoryMeetingRepository
+	broker   *broker.MemoryBroker
+	meeting  *entity.Meeting
+	replicas []*websocketService
+}
+
+func newSessionFixture(t *testing.T, replicas int) *sessionFixture {
+	t.Helper()
+
+	f := &sessionFixture{
+		clock:   clock.NewFake(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)),
+		repo:    repo.NewMemoryMeetingRepository(),
+		broker:  broker.NewMemoryBroker(),
+		meeting: &entity.Meeting{},
+	}
+	createTestMeeting(t, f.repo, f.meeting, f.clock.Now(), nil, "alice", "bob")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	for i := 0; i < replicas; i++ {
+		uc := NewWebSocketService(f.repo, stubMeetingUC{}, stubChatUC{}, stubRecordingUC{}, f.broker, nil, NewEventBus(), f.clock, metrics.Nop{}, SessionConfig{
+			ResumeGrace:     _testResumeGrace,
+			ResumeQueueSize: 16,
+			SendQueueSize:   16,
+			OverflowPolicy:  OverflowDropOldest,
+		})
+		if err := uc.Start(ctx); err != nil {
+			t.Fatalf("start websocket service: %v", err)
+		}
+		f.replicas = append(f.replicas, uc)
+	}
+
+	return f
+}
+
+// connect - подключает пользователя к реплике и возвращает соединение и hello
+func (f *sessionFixture) connect(t *testing.T, replica int, userID, resumeToken string) (*fakeConn, protocol.HelloPayload) {
+	t.Helper()
+
+	conn := newFakeConn()
+	go f.replicas[replica].HandleConnection(context.Background(), conn, &entity.WSSession{
+		MeetingID:       f.meeting.ID,
+		UserID:          userID,
+		ProtocolVersion: protocol.Version,
+		ResumeToken:     resumeToken,
+	})
+	t.Cleanup(func() { _ = conn.Close() })
+
+	message := conn.waitMessage(t, protocol.TypeHello, "")
+
+	var hello protocol.HelloPayload
+	data, _ := json.Marshal(message.Data)
+	if err := json.Unmarshal(data, &hello); err != nil {
+		t.Fatalf("decode hello: %v", err)
+	}
+	return conn, hello
+}
+
+func (f *sessionFixture) online(t *testing.T, userID string) bool {
+	t.Helper()
+
+	users, err := f.repo.GetMeetingUsers(context.Background(), f.meeting.ID)
+	if err != nil {
+		t.Fatalf("get users: %v", err)
+	}
+	for _, user := range users {
+		if user.ID == userID {
+			return user.IsOnline
+		}
+	}
+	return false
+}
+
+func (f *sessionFixture) holds(replica int, userID string) bool {
+	uc := f.replicas[replica]
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	return uc.sessions[f.meeting.ID][userID] != nil
+}
+
+func TestResumeGraceExpires(t *testing.T) {
+	f := newSessionFixture(t, 1)
+
+	alice, _ := f.connect(t, 0, "alice", "")
+	bob, _ := f.connect(t, 0, "bob", "")
+	alice.waitMessage(t, protocol.TypeUserJoined, "bob")
+
+	_ = bob.Close()
+	f.clock.BlockUntil(1)
+
+	f.clock.Advance(_testResumeGrace - time.Second)
+	alice.noMessage(t, protocol.TypeUserLeft, 50*time.Millisecond)
+	if !f.online(t, "bob") {
+		t.Fatal("bob went offline before the grace period expired")
+	}
+
+	f.clock.Advance(time.Second)
+	alice.waitMessage(t, protocol.TypeUserLeft, "bob")
+	waitFor(t, func() bool { return !f.online(t, "bob") })
+}
+
+func TestResumeWithinGrace(t *testing.T) {
+	f := newSessionFixture(t, 1)
+
+	alice, _ := f.connect(t, 0, "alice", "")
+	bob, hello := f.connect(t, 0, "bob", "")
+	alice.waitMessage(t, protocol.TypeUserJoined, "bob")
+
+	_ = bob.Close()
+	f.clock.BlockUntil(1)
+	f.clock.Advance(_testResumeGrace / 2)
+
+	// Сообщение, пришедшее за время отключения, доставляется после возобновления
+	if err := f.replicas[0].SendToUser(f.meeting.ID, "bob", &entity.WSMessage{Type: protocol.TypeOffer, From: "alice"}); err != nil {
+		t.Fatalf("send to bob: %v", err)
+	}
+
+	bob, resumed := f.connect(t, 0, "bob", hello.ResumeToken)
+	if !resumed.Resumed {
+		t.Fatal("session was not resumed")
+	}
+	bob.waitMessage(t, protocol.TypeOffer, "alice")
+
+	f.clock.Advance(_testResumeGrace)
+	alice.noMessage(t, protocol.TypeUserLeft, 50*time.Millisecond)
+	alice.noMessage(t, protocol.TypeUserJoined, 0)
+	if !f.online(t, "bob") {
+		t.Fatal("resumed user is offline")
+	}
+}
+
+func TestReconnectToAnotherReplica(t *testing.T) {
+	f := newSessionFixture(t, 2)
+
+	alice, _ := f.connect(t, 0, "alice", "")
+	bob, hello := f.connect(t, 0, "bob", "")
+	alice.waitMessage(t, protocol.TypeUserJoined, "bob")
+
+	_ = bob.Close()
+	f.clock.BlockUntil(1)
+
+	// Вторая реплика не знает прошлую сессию и открывает новую, а первая
+	// освобождает место, которое держала, не дожидаясь конца ResumeGrace
+	_, resumed := f.connect(t, 1, "bob", hello.ResumeToken)
+	if resumed.Resumed {
+		t.Fatal("session was resumed on a replica that did not hold it")
+	}
+	waitFor(t, func() bool { return !f.holds(0, "bob") })
+
+	f.clock.Advance(2 * _testResumeGrace)
+	alice.noMessage(t, protocol.TypeUserLeft, 50*time.Millisecond)
+	if !f.online(t, "bob") {
+		t.Fatal("user connected to another replica went offline")
+	}
+	if !f.holds(1, "bob") {
+		t.Fatal("second replica dropped the new session")
+	}
+}
+
+func TestDuplicateConnectionOnAnotherReplica(t *testing.T) {
+	f := newSessionFixture(t, 2)
+
+	alice, _ := f.connect(t, 0, "alice", "")
+	first, _ := f.connect(t, 0, "bob", "")
+	alice.waitMessage(t, protocol.TypeUserJoined, "bob")
+
+	// Новое подключение на другой реплике вытесняет старое без user_left
+	f.connect(t, 1, "bob", "")
+	if code := first.waitClosed(t); code != entity.CloseSessionReplaced {
+		t.Fatalf("got close code %d, want %d", code, entity.CloseSessionReplaced)
+	}
+	waitFor(t, func() bool { return !f.holds(0, "bob") })
+
+	f.clock.Advance(2 * _testResumeGrace)
+	alice.noMessage(t, protocol.TypeUserLeft, 50*time.Millisecond)
+	if !f.online(t, "bob") {
+		t.Fatal("replaced user went offline")
+	}
+}
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS meetings (
+    id         TEXT PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id         TEXT        NOT NULL,
+    meeting_id TEXT        NOT NULL REFERENCES meetings (id) ON DELETE CASCADE,
+    name       TEXT        NOT NULL,
+    is_online  BOOLEAN     NOT NULL DEFAULT FALSE,
+    joined_at  TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (meeting_id, id)
+);
+
+CREATE INDEX IF NOT EXISTS users_meeting_id_joined_at_idx ON users (meeting_id, joined_at);
@@ -0,0 +1,3 @@
+ALTER TABLE meetings
+    DROP COLUMN IF EXISTS locked,
+    DROP COLUMN IF EXISTS host_id;
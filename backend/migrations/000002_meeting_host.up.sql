@@ -0,0 +1,3 @@
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS host_id TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS locked  BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+ALTER TABLE meetings
+    DROP COLUMN IF EXISTS ends_at,
+    DROP COLUMN IF EXISTS starts_at;
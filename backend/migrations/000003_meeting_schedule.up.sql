@@ -0,0 +1,3 @@
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS starts_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS ends_at   TIMESTAMPTZ;
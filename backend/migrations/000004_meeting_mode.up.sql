@@ -0,0 +1,2 @@
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'mesh';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS lobby_users;
+
+ALTER TABLE meetings
+    DROP COLUMN IF EXISTS lobby;
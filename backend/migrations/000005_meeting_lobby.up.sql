@@ -0,0 +1,10 @@
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS lobby BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS lobby_users (
+    id           TEXT        NOT NULL,
+    meeting_id   TEXT        NOT NULL REFERENCES meetings (id) ON DELETE CASCADE,
+    name         TEXT        NOT NULL,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    PRIMARY KEY (meeting_id, id)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE meetings
+    DROP COLUMN IF EXISTS passcode_hash;
@@ -0,0 +1,2 @@
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS passcode_hash TEXT NOT NULL DEFAULT '';
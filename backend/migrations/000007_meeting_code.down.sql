@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS meetings_code_key;
+
+ALTER TABLE meetings
+    DROP COLUMN IF EXISTS code;
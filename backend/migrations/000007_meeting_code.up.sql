@@ -0,0 +1,10 @@
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS code TEXT;
+
+-- У встреч, созданных до появления кодов, кодом служит ID
+UPDATE meetings SET code = id WHERE code IS NULL;
+
+ALTER TABLE meetings
+    ALTER COLUMN code SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS meetings_code_key ON meetings (code);
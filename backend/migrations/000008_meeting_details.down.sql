@@ -0,0 +1,5 @@
+ALTER TABLE meetings
+    DROP COLUMN IF EXISTS capacity;
+
+ALTER TABLE meetings
+    DROP COLUMN IF EXISTS description;
@@ -0,0 +1,6 @@
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+-- 0 - без ограничения числа участников
+ALTER TABLE meetings
+    ADD COLUMN IF NOT EXISTS capacity INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS is_online BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users
+SET is_online = TRUE
+WHERE EXISTS (
+    SELECT 1 FROM user_sessions
+    WHERE user_sessions.meeting_id = users.meeting_id AND user_sessions.user_id = users.id AND left_at IS NULL
+);
+
+DROP TABLE IF EXISTS user_sessions;
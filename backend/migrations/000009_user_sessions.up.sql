@@ -0,0 +1,15 @@
+-- История подключений участников. Не ссылается на users, чтобы пережить выход из встречи
+CREATE TABLE IF NOT EXISTS user_sessions (
+    meeting_id TEXT        NOT NULL REFERENCES meetings (id) ON DELETE CASCADE,
+    user_id    TEXT        NOT NULL,
+    joined_at  TIMESTAMPTZ NOT NULL,
+    left_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_meeting_id_user_id_idx ON user_sessions (meeting_id, user_id, joined_at);
+
+-- У пользователя не больше одной открытой сессии
+CREATE UNIQUE INDEX IF NOT EXISTS user_sessions_open_key ON user_sessions (meeting_id, user_id) WHERE left_at IS NULL;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS is_online;
@@ -0,0 +1,11 @@
+-- Журнал посещаемости. Не ссылается на meetings, чтобы отчет был доступен и после завершения встречи
+CREATE TABLE IF NOT EXISTS attendance_events (
+    id         BIGSERIAL PRIMARY KEY,
+    meeting_id TEXT        NOT NULL,
+    user_id    TEXT        NOT NULL,
+    user_name  TEXT        NOT NULL,
+    type       TEXT        NOT NULL,
+    at         TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS attendance_events_meeting_id_at_idx ON attendance_events (meeting_id, at, id);
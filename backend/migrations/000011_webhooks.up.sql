@@ -0,0 +1,28 @@
+-- Журнал доставок вебхуков. seq - порядок добавления, по нему журнал отдается
+-- и обрезается до webhooks.log_size
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    seq              BIGSERIAL   NOT NULL,
+    id               TEXT        PRIMARY KEY,
+    event_id         TEXT        NOT NULL,
+    event_type       TEXT        NOT NULL,
+    meeting_id       TEXT        NOT NULL,
+    url              TEXT        NOT NULL,
+    status           TEXT        NOT NULL,
+    attempts         INTEGER     NOT NULL,
+    last_status_code INTEGER     NOT NULL DEFAULT 0,
+    last_error       TEXT        NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL,
+    updated_at       TIMESTAMPTZ NOT NULL,
+    next_attempt_at  TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_seq_key ON webhook_deliveries (seq);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_seq_idx ON webhook_deliveries (status, seq);
+
+-- Недоставленные события. Доставка и событие хранятся целиком, как их отдает API
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    seq         BIGSERIAL PRIMARY KEY,
+    delivery_id TEXT      NOT NULL UNIQUE,
+    delivery    JSONB     NOT NULL,
+    event       JSONB     NOT NULL
+);
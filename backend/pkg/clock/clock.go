@@ -0,0 +1,37 @@
+// Package clock - тикеры и таймеры, которые можно подменить в тестах управляемыми часами
+package clock
+
+import "time"
+
+// Ticker - тикер с периодом, как time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer - отложенный вызов функции, как time.Timer из time.AfterFunc
+type Timer interface {
+	Stop() bool
+}
+
+// AfterFunc - вызывает f в своей горутине через d, на основе time.AfterFunc
+func AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+// NewTicker - тикер на основе time.NewTicker
+func NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,156 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake - часы, время которых двигает только Advance. Тикеры и таймеры срабатывают
+// по очереди в порядке своего времени, как если бы время шло непрерывно
+type Fake struct {
+	now     time.Time
+	waiters []*fakeWaiter
+	mu      sync.Mutex
+	changed *sync.Cond
+}
+
+// fakeWaiter - тикер, если period больше нуля, иначе таймер AfterFunc
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+	f      func()
+}
+
+func NewFake(now time.Time) *Fake {
+	c := &Fake{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Fake) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &fakeWaiter{at: c.now.Add(d), period: d, c: make(chan time.Time, 1)}
+	c.add(w)
+	return &fakeTicker{clock: c, waiter: w}
+}
+
+func (c *Fake) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &fakeWaiter{at: c.now.Add(d), f: f}
+	c.add(w)
+	return &fakeTimer{clock: c, waiter: w}
+}
+
+// Advance - сдвигает время на d, срабатывают все тикеры и таймеры, чье время подошло
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	target := c.now.Add(d)
+	for {
+		w := c.next()
+		if w == nil || w.at.After(target) {
+			break
+		}
+
+		c.now = w.at
+		c.fire(w)
+	}
+	c.now = target
+}
+
+// BlockUntil - ждет, пока не наберется n активных тикеров и таймеров. Нужен, чтобы
+// не сдвигать время раньше, чем горутина под тестом успеет их завести
+func (c *Fake) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.waiters) < n {
+		c.changed.Wait()
+	}
+}
+
+// next - ближайший по времени ожидающий, вызывается под c.mu
+func (c *Fake) next() *fakeWaiter {
+	var next *fakeWaiter
+	for _, w := range c.waiters {
+		if next == nil || w.at.Before(next.at) {
+			next = w
+		}
+	}
+	return next
+}
+
+// fire - вызывается под c.mu. Как и у time.Ticker, тик теряется, если прошлый не прочитан.
+// Функция таймера вызывается в своей горутине, чтобы могла сама обращаться к часам
+func (c *Fake) fire(w *fakeWaiter) {
+	if w.period == 0 {
+		c.remove(w)
+		go w.f()
+		return
+	}
+
+	select {
+	case w.c <- c.now:
+	default:
+	}
+	w.at = w.at.Add(w.period)
+}
+
+// add и remove вызываются под c.mu
+func (c *Fake) add(w *fakeWaiter) {
+	c.waiters = append(c.waiters, w)
+	c.changed.Broadcast()
+}
+
+func (c *Fake) remove(w *fakeWaiter) bool {
+	for i, existing := range c.waiters {
+		if existing == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.changed.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTicker struct {
+	clock  *Fake
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.clock.remove(t.waiter)
+}
+
+type fakeTimer struct {
+	clock  *Fake
+	waiter *fakeWaiter
+}
+
+// Stop - false, если таймер уже сработал или остановлен
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	return t.clock.remove(t.waiter)
+}
@@ -0,0 +1,12 @@
+package logger
+
+// Nop - логгер, который ничего не пишет. Нужен тестам и компонентам без журнала
+type Nop struct{}
+
+var _ Interface = Nop{}
+
+func (Nop) Debug(message interface{}, args ...interface{}) {}
+func (Nop) Info(message string, args ...interface{})       {}
+func (Nop) Warn(message string, args ...interface{})       {}
+func (Nop) Error(message interface{}, args ...interface{}) {}
+func (Nop) Fatal(message interface{}, args ...interface{}) {}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Migrate - применяет все новые миграции из fsys к базе
+func (p *Postgres) Migrate(fsys fs.FS) error {
+	source, err := iofs.New(fsys, ".")
+	if err != nil {
+		return fmt.Errorf("can't open migrations source: %w", err)
+	}
+
+	db := stdlib.OpenDBFromPool(p.Pool)
+	defer db.Close()
+
+	driver, err := pgxmigrate.WithInstance(db, &pgxmigrate.Config{})
+	if err != nil {
+		return fmt.Errorf("can't init migrate driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return fmt.Errorf("can't init migrate: %w", err)
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("can't apply migrations: %w", err)
+	}
+
+	return nil
+}
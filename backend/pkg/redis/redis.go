@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	_defaultConnAttempts = 10
+	_defaultConnTimeout  = time.Second
+)
+
+type Redis struct {
+	password     string
+	db           int
+	connAttempts int
+	connTimeout  time.Duration
+
+	Client *redis.Client
+}
+
+func New(addr string, opts ...Option) (*Redis, error) {
+	r := &Redis{
+		connAttempts: _defaultConnAttempts,
+		connTimeout:  _defaultConnTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.Client = redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: r.password,
+		DB:       r.db,
+	})
+
+	var err error
+	for r.connAttempts > 0 {
+		if err = r.Client.Ping(context.Background()).Err(); err == nil {
+			break
+		}
+
+		log.Printf("redis is trying to connect, attempts left: %d", r.connAttempts)
+
+		time.Sleep(r.connTimeout)
+
+		r.connAttempts--
+	}
+
+	if err != nil {
+		r.Client.Close()
+		return nil, fmt.Errorf("can't connect to redis: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *Redis) Close() error {
+	return r.Client.Close()
+}
@@ -1,6 +1,6 @@
 /* eslint-disable no-case-declarations */
-import { useState, useEffect, useCallback } from 'react';
-import type { UserInfo } from '@/types/meeting';
+import { useState, useEffect, useCallback, useRef } from 'react';
+import type { MeetingMode, UserInfo } from '@/types/meeting';
 import type {
   WSMessage,
   UserJoinedMessage,
@@ -8,10 +8,11 @@ import type {
   OfferMessage,
   AnswerMessage,
   IceCandidateMessage,
+  RecordingStartedMessage,
 } from '@/types/websocket';
 import { apiService } from '@/services/api';
 import { webSocketService } from '@/services/websocket';
-import { webRTCService } from '@/services/webrtc';
+import { webRTCService, SFU_PEER_ID } from '@/services/webrtc';
 import type { CallState } from '@/types/webrtc';
 import { config } from '@/config';
 
@@ -19,6 +20,7 @@ interface UseMeetingProps {
   meetingId: string;
   userId: string;
   userName: string;
+  token: string;
   onUserLeft?: () => void;
   onUsersUpdate?: (users: UserInfo[]) => void;
   onCallStateUpdate?: (state: Partial<CallState>) => void;
@@ -28,6 +30,7 @@ export const useMeeting = ({
   meetingId,
   userId,
   userName,
+  token,
   onUserLeft,
   onUsersUpdate,
   onCallStateUpdate,
@@ -35,6 +38,11 @@ export const useMeeting = ({
   const [users, setUsers] = useState<UserInfo[]>([]);
   const [isConnected, setIsConnected] = useState(false);
   const [error, setError] = useState('');
+  // Режим нужен обработчикам сообщений, поэтому хранится и в ref
+  const [mode, setMode] = useState<MeetingMode>('mesh');
+  const modeRef = useRef<MeetingMode>('mesh');
+  // Рекордер идущей записи во встрече в режиме mesh, ему отправляются свои дорожки
+  const recorderIdRef = useRef<string | null>(null);
   const [callState, setCallState] = useState<CallState>({
     isInCall: false,
     hasLocalStream: false,
@@ -44,6 +52,8 @@ export const useMeeting = ({
   const loadMeetingInfo = useCallback(async () => {
     try {
       const meetingInfo = await apiService.getMeetingInfo(meetingId);
+      modeRef.current = meetingInfo.mode;
+      setMode(meetingInfo.mode);
       setUsers(meetingInfo.users);
       if (onUsersUpdate) {
         onUsersUpdate(meetingInfo.users);
@@ -53,8 +63,51 @@ export const useMeeting = ({
     }
   }, [meetingId, onUsersUpdate]);
 
+  // В режиме sfu одно соединение с сервером: offer отправляет клиент, после
+  // изменения своих дорожек - заново. Чужие дорожки сервер добавляет своим offer
+  const negotiateWithSFU = useCallback(async () => {
+    try {
+      const offerSdp = await webRTCService.createOffer(SFU_PEER_ID);
+
+      webSocketService.sendMessage({
+        type: 'offer',
+        data: { sdp: offerSdp },
+        to: SFU_PEER_ID,
+      });
+    } catch (err) {
+      console.error('SFU negotiation error:', err);
+      setError('Ошибка подключения к медиасерверу');
+    }
+  }, []);
+
+  // Рекордер только принимает потоки: offer всегда отправляет клиент, в том числе
+  // когда дорожки появились уже после начала записи
+  const sendTracksToRecorder = useCallback(async () => {
+    const recorderId = recorderIdRef.current;
+    if (!recorderId || !webRTCService.getMediaStreams().local) {
+      return;
+    }
+
+    try {
+      webRTCService.addLocalTracks(recorderId);
+      const offerSdp = await webRTCService.createOffer(recorderId);
+
+      webSocketService.sendMessage({
+        type: 'offer',
+        data: { sdp: offerSdp },
+        to: recorderId,
+      });
+    } catch (err) {
+      console.error('Recorder negotiation error:', err);
+    }
+  }, []);
+
   const initializeWebRTC = useCallback(async () => {
     try {
+      webRTCService.setSFUConnectionLostCallback(() => {
+        negotiateWithSFU();
+      });
+
       webRTCService.setIceCandidateCallback((targetUserId, candidate) => {
         webSocketService.sendMessage({
           type: 'ice_candidate',
@@ -77,7 +130,7 @@ export const useMeeting = ({
     } catch (err) {
       console.error('WebRTC initialization error:', err);
     }
-  }, [onCallStateUpdate]);
+  }, [onCallStateUpdate, negotiateWithSFU]);
 
   const handleWebRTCSignaling = useCallback(async (message: WSMessage) => {
     try {
@@ -116,6 +169,13 @@ export const useMeeting = ({
     console.log('WebSocket message received:', message);
 
     switch (message.type) {
+      case 'hello':
+        // После возобновления сессии соединение с сервером еще живо
+        if (modeRef.current === 'sfu' && !webRTCService.hasPeerConnection(SFU_PEER_ID)) {
+          negotiateWithSFU();
+        }
+        break;
+
       case 'user_joined':
         const joinMessage = message as UserJoinedMessage;
         setUsers((prev) => {
@@ -125,7 +185,9 @@ export const useMeeting = ({
           const updatedUsers = [...prev, {
             user_id: joinMessage.data.user_id,
             user_name: joinMessage.data.user_name,
+            joined_at: new Date().toISOString(),
             is_online: true,
+            sessions: [],
           }];
           if (onUsersUpdate) {
             onUsersUpdate(updatedUsers);
@@ -163,10 +225,26 @@ export const useMeeting = ({
         handleWebRTCSignaling(message);
         break;
 
+      case 'recording_started':
+        const recordingMessage = message as RecordingStartedMessage;
+        // recorder_id есть только в режиме mesh, в режиме sfu сервер пишет то, что уже получает
+        if (recordingMessage.data.recorder_id) {
+          recorderIdRef.current = recordingMessage.data.recorder_id;
+          sendTracksToRecorder();
+        }
+        break;
+
+      case 'recording_stopped':
+        if (recorderIdRef.current) {
+          webRTCService.closePeerConnection(recorderIdRef.current);
+          recorderIdRef.current = null;
+        }
+        break;
+
       default:
         console.log('Unhandled message type:', message.type);
     }
-  }, [handleWebRTCSignaling, onUsersUpdate]);
+  }, [handleWebRTCSignaling, onUsersUpdate, negotiateWithSFU, sendTracksToRecorder]);
 
   const startCallWithUser = useCallback(async (targetUserId: string) => {
     try {
@@ -191,11 +269,17 @@ export const useMeeting = ({
   const initializeLocalMedia = useCallback(async () => {
     try {
       await webRTCService.initializeLocalStream();
+
+      if (modeRef.current === 'sfu') {
+        webRTCService.addLocalTracks(SFU_PEER_ID);
+        await negotiateWithSFU();
+      }
+      await sendTracksToRecorder();
     } catch (err) {
       console.error('Error initializing media:', err);
       setError('Ошибка доступа к камере/микрофону');
     }
-  }, []);
+  }, [negotiateWithSFU, sendTracksToRecorder]);
 
   const stopAllMedia = useCallback(() => {
     webRTCService.stopAllConnections();
@@ -203,7 +287,7 @@ export const useMeeting = ({
 
   const connectWebSocket = useCallback(async () => {
     try {
-      await webSocketService.connect(meetingId, userId);
+      await webSocketService.connect(meetingId, userId, token);
       setIsConnected(true);
       setError('');
 
@@ -212,7 +296,7 @@ export const useMeeting = ({
       setError(err instanceof Error ? err.message : 'Ошибка подключения WebSocket');
       setIsConnected(false);
     }
-  }, [meetingId, userId, handleWebSocketMessage]);
+  }, [meetingId, userId, token, handleWebSocketMessage]);
 
   const disconnectWebSocket = useCallback(() => {
     webSocketService.removeMessageHandler(handleWebSocketMessage);
@@ -232,7 +316,7 @@ export const useMeeting = ({
       await apiService.leaveMeeting({
         meeting_id: meetingId,
         user_id: userId,
-      });
+      }, token);
       console.log('API leave request completed');
 
     } catch (err) {
@@ -242,7 +326,7 @@ export const useMeeting = ({
       stopAllMedia();
       disconnectWebSocket();
     }
-  }, [meetingId, userId, disconnectWebSocket, stopAllMedia, onUserLeft]);
+  }, [meetingId, userId, token, disconnectWebSocket, stopAllMedia, onUserLeft]);
 
   useEffect(() => {
     const handleBeforeUnload = () => {
@@ -255,7 +339,7 @@ export const useMeeting = ({
           }),
         ], { type: 'application/json' });
 
-        navigator.sendBeacon(`${config.api.baseUrl}/meeting/leave`, data);
+        navigator.sendBeacon(`${config.api.baseUrl}/meeting/leave?token=${encodeURIComponent(token)}`, data);
       }
     };
 
@@ -264,7 +348,7 @@ export const useMeeting = ({
     return () => {
       window.removeEventListener('beforeunload', handleBeforeUnload);
     };
-  }, [meetingId, userId, stopAllMedia]);
+  }, [meetingId, userId, token, stopAllMedia]);
 
   useEffect(() => {
     let isMounted = true;
@@ -288,6 +372,7 @@ export const useMeeting = ({
 
     return () => {
       isMounted = false;
+      webRTCService.setSFUConnectionLostCallback(null);
       stopAllMedia();
       disconnectWebSocket();
     };
@@ -295,6 +380,7 @@ export const useMeeting = ({
 
   return {
     users,
+    mode,
     isConnected,
     error,
     callState,
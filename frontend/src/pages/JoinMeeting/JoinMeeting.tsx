@@ -10,6 +10,7 @@ import cls from './JoinMeeting.module.scss';
 export const JoinMeeting: React.FC = () => {
   const navigate = useNavigate();
   const joinMeeting = useMeetingStore((state) => state.joinMeeting);
+  const previousMeeting = useMeetingStore((state) => state.meetingData);
   const [searchParams] = useSearchParams();
   const [userName, setUserName] = useState('');
   const [meetingId, setMeetingId] = useState('');
@@ -32,18 +33,23 @@ export const JoinMeeting: React.FC = () => {
     setError('');
 
     try {
+      // Возвращаемся во встречу под прежним ID, чтобы не появился второй участник
+      const rejoin = previousMeeting && meetingId.trim() === previousMeeting.meetingId ? previousMeeting : null;
+
       const request = {
         user_name: userName.trim(),
         ...(meetingId.trim() && { meeting_id: meetingId.trim() }),
+        ...(rejoin && { user_id: rejoin.userId }),
       };
 
-      const response = await apiService.joinMeeting(request);
+      const response = await apiService.joinMeeting(request, rejoin?.token);
 
       joinMeeting({
         meetingId: response.meeting_id,
         meetingName: response.meeting_name,
         userId: response.user_id,
         userName: userName.trim(),
+        token: response.token,
       });
 
       navigate(`/meeting/${response.meeting_id}`);
@@ -22,6 +22,7 @@ export const Meeting: React.FC<MeetingProps> = ({ meetingData, onLeaveMeeting })
 
   const {
     users,
+    mode,
     callState,
     leaveMeeting,
     startCallWithUser,
@@ -31,6 +32,7 @@ export const Meeting: React.FC<MeetingProps> = ({ meetingData, onLeaveMeeting })
     meetingId: meetingData.meetingId,
     userId: meetingData.userId,
     userName: meetingData.userName,
+    token: meetingData.token,
     onUserLeft: onLeaveMeeting,
     onUsersUpdate: updateUsers,
     onCallStateUpdate: updateCallState,
@@ -164,7 +166,8 @@ export const Meeting: React.FC<MeetingProps> = ({ meetingData, onLeaveMeeting })
                       </span>
                     </div>
 
-                    {user.user_id !== meetingData.userId && user.is_online && (
+                    {/* В режиме sfu звонить участникам не нужно, их потоки приходят через сервер */}
+                    {mode === 'mesh' && user.user_id !== meetingData.userId && user.is_online && (
                       <Button
                         onClick={() => handleStartCallWithUser(user.user_id)}
                         disabled={isInitializingMedia || !callState.hasLocalStream}
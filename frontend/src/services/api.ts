@@ -1,13 +1,14 @@
 import { config } from '@/config';
-import type { JoinMeetingRequest, JoinMeetingResponse, MeetingInfo, LeaveMeetingRequest } from '@/types/meeting';
+import type { JoinMeetingRequest, JoinMeetingResponse, MeetingInfo, LeaveMeetingRequest, IceServersResponse } from '@/types/meeting';
 
 
 class ApiService {
-    async joinMeeting(request: JoinMeetingRequest): Promise<JoinMeetingResponse> {
+    async joinMeeting(request: JoinMeetingRequest, token?: string): Promise<JoinMeetingResponse> {
         const response = await fetch(`${config.api.baseUrl}/meeting/join`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
+                ...(token && { 'Authorization': `Bearer ${token}` }),
             },
             body: JSON.stringify(request),
         });
@@ -29,11 +30,12 @@ class ApiService {
         return response.json();
     }
 
-    async leaveMeeting(request: LeaveMeetingRequest): Promise<void> {
+    async leaveMeeting(request: LeaveMeetingRequest, token: string): Promise<void> {
         const response = await fetch(`${config.api.baseUrl}/meeting/leave`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
+                'Authorization': `Bearer ${token}`,
             },
             body: JSON.stringify(request),
         });
@@ -42,6 +44,20 @@ class ApiService {
             throw new Error(`HTTP error! status: ${response.status}`);
         }
     }
+
+    async getIceServers(meetingId: string, token: string): Promise<IceServersResponse> {
+        const response = await fetch(`${config.api.baseUrl}/ice-servers?meeting_id=${meetingId}`, {
+            headers: {
+                'Authorization': `Bearer ${token}`,
+            },
+        });
+
+        if (!response.ok) {
+            throw new Error(`HTTP error! status: ${response.status}`);
+        }
+
+        return response.json();
+    }
 }
 
 export const apiService = new ApiService();
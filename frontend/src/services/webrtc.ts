@@ -1,6 +1,9 @@
 import { config } from '@/config';
 import type { RTCPeerConnectionWithUser, MediaStreams, CallState } from '@/types/webrtc';
 
+// Во встрече в режиме sfu сервер - участник с этим id, с ним одно соединение на всех
+export const SFU_PEER_ID = 'sfu';
+
 class WebRTCService {
     private peerConnections = new Map<string, RTCPeerConnectionWithUser>();
     private mediaStreams: MediaStreams = {
@@ -38,6 +41,10 @@ class WebRTCService {
             this.mediaStreams.local.getTracks().forEach((track) => {
                 pc.addTrack(track, this.mediaStreams.local!);
             });
+        } else if (userId === SFU_PEER_ID) {
+            // Без своих дорожек offer серверу не содержал бы медиа и чужие дорожки не пришли бы
+            pc.addTransceiver('audio', { direction: 'recvonly' });
+            pc.addTransceiver('video', { direction: 'recvonly' });
         }
 
         pc.onicecandidate = (event) => {
@@ -48,14 +55,36 @@ class WebRTCService {
 
         pc.ontrack = (event) => {
             const remoteStream = event.streams[0];
-            this.mediaStreams.remote.set(userId, remoteStream);
-            this.notifyRemoteStream(userId, remoteStream);
+            // Через SFU приходят дорожки всех участников, id потока - id того, кто его публикует
+            const remoteUserId = userId === SFU_PEER_ID ? remoteStream.id : userId;
+
+            if (userId === SFU_PEER_ID) {
+                remoteStream.onremovetrack = () => {
+                    if (remoteStream.getTracks().length === 0) {
+                        this.removeRemoteStream(remoteUserId);
+                    }
+                };
+            }
+
+            this.mediaStreams.remote.set(remoteUserId, remoteStream);
+            this.notifyRemoteStream(remoteUserId, remoteStream);
             this.notifyCallStateChange();
         };
 
         pc.onconnectionstatechange = () => {
             console.log(`Connection state for ${userId}:`, pc.connectionState);
 
+            if (userId === SFU_PEER_ID) {
+                // Сервер забывает оборванное соединение, клиент начинает заново
+                if (pc.connectionState === 'failed') {
+                    this.closePeerConnection(SFU_PEER_ID);
+                    this.mediaStreams.remote.clear();
+                    this.notifyCallStateChange();
+                    this.onSFUConnectionLostCallback?.();
+                }
+                return;
+            }
+
             if (pc.connectionState === 'disconnected' || pc.connectionState === 'failed') {
                 this.mediaStreams.remote.delete(userId);
                 this.notifyCallStateChange();
@@ -75,6 +104,45 @@ class WebRTCService {
         return offer.sdp!;
     }
 
+    // Добавляет в соединение локальные дорожки, которых в нем еще нет. После этого нужен новый offer
+    addLocalTracks(userId: string): boolean {
+        const pc = this.peerConnections.get(userId);
+        if (!pc || !this.mediaStreams.local) {
+            return false;
+        }
+
+        const senders = pc.getSenders().map((sender) => sender.track);
+        this.mediaStreams.local.getTracks().forEach((track) => {
+            if (!senders.includes(track)) {
+                pc.addTrack(track, this.mediaStreams.local!);
+            }
+        });
+        return true;
+    }
+
+    hasPeerConnection(userId: string): boolean {
+        return this.peerConnections.has(userId);
+    }
+
+    closePeerConnection(userId: string) {
+        const pc = this.peerConnections.get(userId);
+        if (pc) {
+            pc.close();
+            this.peerConnections.delete(userId);
+        }
+    }
+
+    removeRemoteStream(userId: string) {
+        if (this.mediaStreams.remote.delete(userId)) {
+            this.notifyCallStateChange();
+        }
+    }
+
+    private onSFUConnectionLostCallback: (() => void) | null = null;
+    setSFUConnectionLostCallback(callback: (() => void) | null) {
+        this.onSFUConnectionLostCallback = callback;
+    }
+
     async handleOffer(userId: string, sdp: string): Promise<string> {
         let pc = this.peerConnections.get(userId);
         if (!pc) {
@@ -1,5 +1,5 @@
 import { config } from '@/config';
-import type { WSMessage } from '@/types/websocket';
+import type { HelloMessage, WSMessage } from '@/types/websocket';
 
 type MessageHandler = (message: WSMessage) => void;
 
@@ -9,10 +9,15 @@ class WebSocketService {
   private reconnectAttempts = 0;
   private maxReconnectAttempts = 5;
   private isManualClose = false;
+  // Токен из hello, с ним переподключение возвращает в ту же сессию без user_left
+  private resumeToken: string | null = null;
 
-  connect(meetingId: string, userId: string): Promise<void> {
+  connect(meetingId: string, userId: string, token: string): Promise<void> {
     return new Promise((resolve, reject) => {
-      const wsUrl = `${config.websocket.baseUrl}/meeting/${meetingId}/ws?user_id=${userId}`;
+      let wsUrl = `${config.websocket.baseUrl}/meeting/${meetingId}/ws?user_id=${userId}&token=${encodeURIComponent(token)}`;
+      if (this.resumeToken) {
+        wsUrl += `&resume_token=${encodeURIComponent(this.resumeToken)}`;
+      }
 
       try {
         this.socket = new WebSocket(wsUrl);
@@ -27,6 +32,9 @@ class WebSocketService {
         this.socket.onmessage = (event) => {
           try {
             const message: WSMessage = JSON.parse(event.data);
+            if (message.type === 'hello') {
+              this.resumeToken = (message as HelloMessage).data.resume_token;
+            }
             this.notifyHandlers(message);
           } catch (error) {
             console.error('Error parsing WebSocket message:', error);
@@ -36,10 +44,13 @@ class WebSocketService {
         this.socket.onclose = (event) => {
           console.log('WebSocket disconnected:', event.code, event.reason);
 
-          if (!this.isManualClose && this.reconnectAttempts < this.maxReconnectAttempts) {
+          // Коды 4000-4999 - сервер осознанно завершил сессию, переподключаться бессмысленно
+          const isRejectedByServer = event.code >= 4000 && event.code < 5000;
+
+          if (!this.isManualClose && !isRejectedByServer && this.reconnectAttempts < this.maxReconnectAttempts) {
             this.reconnectAttempts++;
             console.log(`Attempting to reconnect... (${this.reconnectAttempts}/${this.maxReconnectAttempts})`);
-            setTimeout(() => this.connect(meetingId, userId), 3000);
+            setTimeout(() => this.connect(meetingId, userId, token), 3000);
           }
         };
 
@@ -56,6 +67,7 @@ class WebSocketService {
 
   disconnect() {
     this.isManualClose = true;
+    this.resumeToken = null;
     if (this.socket) {
       this.socket.close();
       this.socket = null;
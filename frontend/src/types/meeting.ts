@@ -13,34 +13,161 @@ export interface MeetingData {
   userId: string;
   userName: string;
   meetingName: string;
+  token: string;
 }
 
+export type MeetingMode = 'mesh' | 'sfu';
+
 export interface JoinMeetingRequest {
   meeting_id?: string;
   user_name: string;
+  mode?: MeetingMode;
+  lobby?: boolean;
+  passcode?: string;
+  meeting_code?: string;
+  // Прежний ID для повторного входа, передается вместе с его токеном
+  user_id?: string;
 }
 
 export interface JoinMeetingResponse {
   meeting_id: string;
+  meeting_code: string;
   user_id: string;
   users_in_meeting: string[];
   meeting_name: string;
+  mode: MeetingMode;
+  token: string;
+  token_expires_at: string;
+  // Пользователь ждет в лобби, пока ведущий его не впустит
+  pending: boolean;
+  // Пользователь вернулся под прежним ID
+  rejoined: boolean;
+}
+
+export interface UserSession {
+  joined_at: string;
+  left_at?: string;
 }
 
 export interface UserInfo {
   user_id: string;
   user_name: string;
+  joined_at: string;
   is_online: boolean;
+  sessions: UserSession[];
 }
 
 export interface MeetingInfo {
   meeting_id: string;
+  meeting_code: string;
   meeting_name: string;
+  description: string;
+  // 0 - без ограничения числа участников
+  capacity: number;
+  host_id: string;
+  locked: boolean;
+  mode: MeetingMode;
+  lobby: boolean;
   users: UserInfo[];
   created_at: string;
+  starts_at?: string;
+  ends_at?: string;
+  passcode_required: boolean;
+}
+
+export interface AttendanceRecord {
+  user_id: string;
+  user_name: string;
+  first_joined_at: string;
+  // Нет, пока участник во встрече
+  last_left_at?: string;
+  total_seconds: number;
+  reconnects: number;
+  online: boolean;
+}
+
+export interface AttendanceReport {
+  meeting_id: string;
+  generated_at: string;
+  attendees: AttendanceRecord[];
+}
+
+export interface CreateMeetingRequest {
+  meeting_name?: string;
+  description?: string;
+  capacity?: number;
+  starts_at?: string;
+  ends_at?: string;
+  mode?: MeetingMode;
+  lobby?: boolean;
+  passcode?: string;
+  meeting_code?: string;
+}
+
+export interface CreateMeetingResponse {
+  meeting: MeetingInfo;
+  // Токен ведущего: изменить и удалить встречу, войти в нее ведущим
+  token: string;
+  token_expires_at: string;
 }
 
+// Меняются только переданные поля, пустой passcode снимает код доступа
+export type UpdateMeetingRequest = Partial<
+  Pick<CreateMeetingRequest, 'meeting_name' | 'description' | 'capacity' | 'starts_at' | 'ends_at' | 'lobby' | 'passcode'>
+> & {
+  locked?: boolean;
+};
+
 export interface LeaveMeetingRequest {
   meeting_id: string;
   user_id: string;
 }
+
+export interface IceServersResponse {
+  ice_servers: RTCIceServer[];
+  expires_at?: string;
+}
+
+export type ApiErrorCode =
+  | 'validation_failed'
+  | 'invalid_cursor'
+  | 'invalid_token'
+  | 'token_expired'
+  | 'token_mismatch'
+  | 'not_member'
+  | 'not_host'
+  | 'not_admitted'
+  | 'meeting_locked'
+  | 'meeting_not_started'
+  | 'passcode_required'
+  | 'invalid_passcode'
+  | 'meeting_not_found'
+  | 'user_not_found'
+  | 'media_session_not_found'
+  | 'meeting_exists'
+  | 'user_exists'
+  | 'meeting_code_taken'
+  | 'meeting_full'
+  | 'sfu_unavailable'
+  | 'not_publishing'
+  | 'recording_in_progress'
+  | 'not_recording'
+  | 'meeting_ended'
+  | 'too_many_attempts'
+  | 'recording_disabled';
+
+export interface InvalidParam {
+  field: string;
+  reason: string;
+}
+
+// Ошибка HTTP API в формате RFC 7807 (application/problem+json)
+export interface ApiErrorResponse {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code?: ApiErrorCode;
+  invalid_params?: InvalidParam[];
+}
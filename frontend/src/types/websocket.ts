@@ -6,6 +6,17 @@ export interface WSMessage {
     to?: string;
 }
 
+export interface HelloMessage extends WSMessage {
+    type: 'hello';
+    data: {
+        protocol_version: number;
+        meeting_id: string;
+        user_id: string;
+        resume_token: string;
+        resumed: boolean;
+    };
+}
+
 export interface UserJoinedMessage extends WSMessage {
     type: 'user_joined';
     data: {
@@ -41,3 +52,61 @@ export interface IceCandidateMessage extends WSMessage {
         candidate: string;
     };
 }
+
+export interface RecordingStartedMessage extends WSMessage {
+    type: 'recording_started';
+    data: {
+        recording_id: string;
+        // Есть только во встрече в режиме mesh: свои дорожки нужно отправить рекордеру
+        recorder_id?: string;
+        by: string;
+    };
+}
+
+export interface RecordingStoppedMessage extends WSMessage {
+    type: 'recording_stopped';
+    data: {
+        recording_id: string;
+        by?: string;
+    };
+}
+
+export interface LobbyRequestMessage extends WSMessage {
+    type: 'lobby_request';
+    data: {
+        user_id: string;
+        user_name: string;
+    };
+}
+
+export interface LobbyLeftMessage extends WSMessage {
+    type: 'lobby_left';
+    data: {
+        user_id: string;
+        // Пустой, если пользователь ушел из лобби сам
+        by: string;
+    };
+}
+
+// После решения ведущего сервер закрывает соединение лобби с кодом 4007 или 4008
+export interface LobbyDecisionMessage extends WSMessage {
+    type: 'lobby_admitted' | 'lobby_denied';
+    data: {
+        by: string;
+    };
+}
+
+export interface MeetingUpdatedMessage extends WSMessage {
+    type: 'meeting_updated';
+    data: {
+        meeting_name: string;
+        description: string;
+        capacity: number;
+        locked: boolean;
+        lobby: boolean;
+        passcode_required: boolean;
+        starts_at?: string;
+        ends_at?: string;
+        by: string;
+    };
+}